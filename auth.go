@@ -1,6 +1,179 @@
 package gowsdl
 
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
 type basicAuth struct {
 	Login    string
 	Password string
 }
+
+// NegotiateProvider produces the SPNEGO tokens authenticating downloads
+// with Kerberos, such as in Windows domains. It keeps the Kerberos
+// implementation, which may wrap gokrb5 or SSPI, out of gowsdl.
+type NegotiateProvider interface {
+	// NegotiateToken returns the base64 encoded token of a request to host,
+	// whose service principal is usually HTTP/host.
+	NegotiateToken(host string) (string, error)
+}
+
+// SetNegotiateProvider makes downloads authenticate with the SPNEGO tokens
+// of provider instead of basic auth credentials. A nil provider disables it.
+func (g *GoWSDL) SetNegotiateProvider(provider NegotiateProvider) {
+	g.negotiate = provider
+}
+
+// envCredentialsPrefix starts the names of the environment variables holding
+// download credentials, followed by the host they are sent to.
+const envCredentialsPrefix = "GOWSDL_AUTH_"
+
+// hostCredentials looks up the basic auth credentials of a download by the
+// host it is sent to, in environment variables and in a netrc file.
+type hostCredentials struct {
+	env      bool
+	machines map[string]*basicAuth
+	fallback *basicAuth
+}
+
+// SetEnvCredentials makes downloads without basic auth credentials look them
+// up in the GOWSDL_AUTH_<HOST> environment variable, as login:password. HOST
+// is the host of the request in upper case, with the characters other than
+// letters and digits replaced by underscores, such as
+// GOWSDL_AUTH_WSDL_EXAMPLE_COM_8443 for wsdl.example.com:8443; the variable
+// of the host name without the port is used when that one is not set.
+func (g *GoWSDL) SetEnvCredentials(enabled bool) {
+	g.hostAuth.env = enabled
+}
+
+// SetNetrcFile makes downloads without basic auth credentials take them from
+// the machine entry of their host in the netrc file at path, or from its
+// default entry. An empty path disables the lookup, and a missing file is
+// only logged.
+func (g *GoWSDL) SetNetrcFile(path string) error {
+	g.hostAuth.machines, g.hostAuth.fallback = nil, nil
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		log.Println("[WARN] No netrc file at", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	g.hostAuth.machines, g.hostAuth.fallback = parseNetrc(data)
+	return nil
+}
+
+// DefaultNetrcFile returns the path of the netrc file of the user: the NETRC
+// environment variable when set, and ~/.netrc otherwise.
+func DefaultNetrcFile() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// lookup returns the credentials of host, which may carry a port, or nil.
+func (c hostCredentials) lookup(host string) *basicAuth {
+	hostname := host
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		hostname = host[:i]
+	}
+	hostname = strings.Trim(hostname, "[]")
+
+	if c.env {
+		for _, h := range []string{host, hostname} {
+			value, ok := os.LookupEnv(envCredentialsPrefix + envHostKey(h))
+			if i := strings.Index(value, ":"); ok && i > 0 {
+				return &basicAuth{Login: value[:i], Password: value[i+1:]}
+			}
+		}
+	}
+	if auth, ok := c.machines[hostname]; ok {
+		return auth
+	}
+	return c.fallback
+}
+
+// envHostKey turns host into the suffix of an environment variable name.
+func envHostKey(host string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, host)
+}
+
+// parseNetrc returns the login and password of the machine entries of a
+// netrc file by host, and those of its default entry.
+func parseNetrc(data []byte) (map[string]*basicAuth, *basicAuth) {
+	machines := make(map[string]*basicAuth)
+	var (
+		fallback *basicAuth
+		current  *basicAuth
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// Macro definitions end with an empty line.
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			value := ""
+			if i+1 < len(fields) {
+				value = fields[i+1]
+			}
+			switch fields[i] {
+			case "machine":
+				current = &basicAuth{}
+				if _, ok := machines[value]; !ok {
+					machines[value] = current
+				}
+				i++
+			case "default":
+				current = &basicAuth{}
+				fallback = current
+			case "login":
+				if current != nil {
+					current.Login = value
+				}
+				i++
+			case "password":
+				if current != nil {
+					current.Password = value
+				}
+				i++
+			case "account":
+				i++
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	return machines, fallback
+}
@@ -0,0 +1,154 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	machines, fallback := parseNetrc([]byte(`# comment
+machine wsdl.example.com login alice password s3cret
+machine other.example.com
+	login bob
+	account ignored
+	password hunter2
+macdef init
+machine macro.example.com login eve password x
+
+default login anonymous password guest
+`))
+	for host, expected := range map[string]basicAuth{
+		"wsdl.example.com":  {Login: "alice", Password: "s3cret"},
+		"other.example.com": {Login: "bob", Password: "hunter2"},
+	} {
+		if auth := machines[host]; auth == nil || *auth != expected {
+			t.Errorf("%s: got %v, want %v", host, auth, expected)
+		}
+	}
+	if _, ok := machines["macro.example.com"]; ok {
+		t.Error("macro definitions should be skipped")
+	}
+	if fallback == nil || *fallback != (basicAuth{Login: "anonymous", Password: "guest"}) {
+		t.Errorf("unexpected default entry %v", fallback)
+	}
+}
+
+func TestHostCredentialsLookup(t *testing.T) {
+	t.Setenv("GOWSDL_AUTH_WSDL_EXAMPLE_COM_8443", "port:pass:word")
+	t.Setenv("GOWSDL_AUTH_WSDL_EXAMPLE_COM", "host:pass")
+	c := hostCredentials{
+		env:      true,
+		machines: map[string]*basicAuth{"netrc.example.com": {Login: "netrc", Password: "pass"}},
+	}
+	for host, login := range map[string]string{
+		"wsdl.example.com:8443":  "port",
+		"wsdl.example.com:9443":  "host",
+		"netrc.example.com:8080": "netrc",
+		"unknown.example.com":    "",
+	} {
+		got := ""
+		if auth := c.lookup(host); auth != nil {
+			got = auth.Login
+		}
+		if got != login {
+			t.Errorf("%s: got login %q, want %q", host, got, login)
+		}
+	}
+	if auth := c.lookup("wsdl.example.com:8443"); auth.Password != "pass:word" {
+		t.Errorf("passwords should be split at the first colon, got %q", auth.Password)
+	}
+
+	c.env = false
+	if auth := c.lookup("wsdl.example.com"); auth != nil {
+		t.Errorf("environment variables should be ignored when disabled, got %v", auth)
+	}
+}
+
+func TestDownloadCredentialsFromNetrc(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		login, password, _ := r.BasicAuth()
+		fmt.Fprintf(w, `<auth login=%q password=%q/>`, login, password)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	netrc := filepath.Join(t.TempDir(), "netrc")
+	data := fmt.Sprintf("machine %s login alice password s3cret\n", u.Hostname())
+	if err := ioutil.WriteFile(netrc, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGoWSDL(srv.URL, "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetNetrcFile(netrc); err != nil {
+		t.Fatal(err)
+	}
+
+	body, _, err := downloadFile(srv.URL, g.downloadOptions(nil))
+	if err != nil || string(body) != `<auth login="alice" password="s3cret"/>` {
+		t.Errorf("netrc credentials should be sent: %s %v", body, err)
+	}
+
+	g.SetBasicAuth("bob", "explicit")
+	body, _, err = downloadFile(srv.URL, g.downloadOptions(nil))
+	if err != nil || string(body) != `<auth login="bob" password="explicit"/>` {
+		t.Errorf("explicit credentials should win over netrc: %s %v", body, err)
+	}
+
+	if err := g.SetNetrcFile(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("a missing netrc file should not fail: %v", err)
+	}
+}
+
+type fakeNegotiator struct {
+	hosts []string
+	err   error
+}
+
+func (n *fakeNegotiator) NegotiateToken(host string) (string, error) {
+	n.hosts = append(n.hosts, host)
+	return "dG9rZW4=", n.err
+}
+
+func TestDownloadNegotiate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<auth value=%q/>`, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	g, err := NewGoWSDL(srv.URL, "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetBasicAuth("bob", "ignored")
+	negotiator := &fakeNegotiator{}
+	g.SetNegotiateProvider(negotiator)
+
+	body, _, err := downloadFile(srv.URL, g.downloadOptions(nil))
+	if err != nil || string(body) != `<auth value="Negotiate dG9rZW4="/>` {
+		t.Errorf("the SPNEGO token should be sent instead of basic auth: %s %v", body, err)
+	}
+	if len(negotiator.hosts) != 1 || negotiator.hosts[0] != "127.0.0.1" {
+		t.Errorf("tokens should be requested for the host name, got %v", negotiator.hosts)
+	}
+
+	negotiator.err = errors.New("no ticket")
+	if _, _, err := downloadFile(srv.URL, g.downloadOptions(nil)); err == nil || !strings.Contains(err.Error(), "no ticket") {
+		t.Errorf("negotiation errors should fail the download, got %v", err)
+	}
+}
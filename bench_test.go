@@ -0,0 +1,266 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchmarkCorpus are the sizes of the generated WSDLs benchmarked, in
+// schemas and complex types per schema.
+var benchmarkCorpus = []struct {
+	name           string
+	schemas, types int
+}{
+	{"small", 2, 50},
+	{"medium", 5, 200},
+	{"large", 10, 500},
+}
+
+// writeLargeWSDL writes to dir a WSDL declaring schemas inline schemas of
+// types complex types each, with simple types, global elements, and an
+// operation for every other complex type, and returns its path. The types
+// refer to types of the same and of the previous schema, extend each other
+// and use enumerations, lists and attributes, as real-world WSDLs do.
+func writeLargeWSDL(tb testing.TB, dir string, schemas, types int) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"
+             xmlns:soap="http://schemas.xmlsoap.org/wsdl/soap/"
+             xmlns:xs="http://www.w3.org/2001/XMLSchema"
+             xmlns:tns="http://example.org/bench"`)
+	for s := 0; s < schemas; s++ {
+		fmt.Fprintf(&b, "\n             xmlns:s%d=\"http://example.org/bench/s%d\"", s, s)
+	}
+	b.WriteString("\n             targetNamespace=\"http://example.org/bench\">\n  <types>\n")
+	for s := 0; s < schemas; s++ {
+		fmt.Fprintf(&b, "    <xs:schema targetNamespace=\"http://example.org/bench/s%d\" elementFormDefault=\"qualified\">\n", s)
+		if s > 0 {
+			fmt.Fprintf(&b, "      <xs:import namespace=\"http://example.org/bench/s%d\"/>\n", s-1)
+		}
+		for i := 0; i < types/4; i++ {
+			fmt.Fprintf(&b, `      <xs:simpleType name="Status%d_%d">
+        <xs:restriction base="xs:string">
+          <xs:enumeration value="active"/>
+          <xs:enumeration value="inactive"/>
+          <xs:enumeration value="pending"/>
+        </xs:restriction>
+      </xs:simpleType>
+      <xs:simpleType name="Codes%d_%d">
+        <xs:list itemType="xs:int"/>
+      </xs:simpleType>
+`, s, i, s, i)
+		}
+		for i := 0; i < types; i++ {
+			fmt.Fprintf(&b, "      <xs:complexType name=\"Record%d_%d\">\n", s, i)
+			if i > 0 && i%5 == 0 {
+				fmt.Fprintf(&b, "        <xs:complexContent>\n          <xs:extension base=\"s%d:Record%d_%d\">\n            <xs:sequence>\n", s, s, i-1)
+				fmt.Fprintf(&b, "              <xs:element name=\"extra%d\" type=\"xs:string\" minOccurs=\"0\"/>\n", i)
+				b.WriteString("            </xs:sequence>\n          </xs:extension>\n        </xs:complexContent>\n      </xs:complexType>\n")
+				continue
+			}
+			b.WriteString("        <xs:sequence>\n")
+			b.WriteString("          <xs:element name=\"id\" type=\"xs:long\"/>\n")
+			b.WriteString("          <xs:element name=\"name\" type=\"xs:string\" minOccurs=\"0\"/>\n")
+			b.WriteString("          <xs:element name=\"created\" type=\"xs:dateTime\" minOccurs=\"0\"/>\n")
+			b.WriteString("          <xs:element name=\"amount\" type=\"xs:decimal\" nillable=\"true\"/>\n")
+			if types >= 4 {
+				fmt.Fprintf(&b, "          <xs:element name=\"status\" type=\"s%d:Status%d_%d\"/>\n", s, s, i%(types/4))
+				fmt.Fprintf(&b, "          <xs:element name=\"codes\" type=\"s%d:Codes%d_%d\" minOccurs=\"0\"/>\n", s, s, i%(types/4))
+			}
+			if i > 0 {
+				fmt.Fprintf(&b, "          <xs:element name=\"parent\" type=\"s%d:Record%d_%d\" minOccurs=\"0\"/>\n", s, s, i-1)
+			}
+			if s > 0 {
+				fmt.Fprintf(&b, "          <xs:element name=\"related\" type=\"s%d:Record%d_%d\" minOccurs=\"0\" maxOccurs=\"unbounded\"/>\n", s-1, s-1, i)
+			}
+			b.WriteString("          <xs:element name=\"note\" minOccurs=\"0\">\n")
+			b.WriteString("            <xs:complexType>\n              <xs:sequence>\n")
+			b.WriteString("                <xs:element name=\"text\" type=\"xs:string\"/>\n")
+			b.WriteString("              </xs:sequence>\n            </xs:complexType>\n          </xs:element>\n")
+			b.WriteString("        </xs:sequence>\n")
+			b.WriteString("        <xs:attribute name=\"version\" type=\"xs:int\" use=\"required\"/>\n")
+			b.WriteString("        <xs:attribute name=\"lang\" type=\"xs:language\"/>\n")
+			b.WriteString("      </xs:complexType>\n")
+		}
+		for i := 0; i < types; i += 2 {
+			fmt.Fprintf(&b, `      <xs:element name="get%d_%d">
+        <xs:complexType>
+          <xs:sequence>
+            <xs:element name="id" type="xs:long"/>
+          </xs:sequence>
+        </xs:complexType>
+      </xs:element>
+      <xs:element name="get%d_%dResponse">
+        <xs:complexType>
+          <xs:sequence>
+            <xs:element name="record" type="s%d:Record%d_%d"/>
+          </xs:sequence>
+        </xs:complexType>
+      </xs:element>
+`, s, i, s, i, s, s, i)
+		}
+		b.WriteString("    </xs:schema>\n")
+	}
+	b.WriteString("  </types>\n")
+
+	var ops, bindings strings.Builder
+	for s := 0; s < schemas; s++ {
+		for i := 0; i < types; i += 2 {
+			fmt.Fprintf(&b, `  <message name="get%d_%dRequest">
+    <part name="parameters" element="s%d:get%d_%d"/>
+  </message>
+  <message name="get%d_%dResponse">
+    <part name="parameters" element="s%d:get%d_%dResponse"/>
+  </message>
+`, s, i, s, s, i, s, i, s, s, i)
+			fmt.Fprintf(&ops, `    <operation name="Get%d_%d">
+      <input message="tns:get%d_%dRequest"/>
+      <output message="tns:get%d_%dResponse"/>
+    </operation>
+`, s, i, s, i, s, i)
+			fmt.Fprintf(&bindings, `    <operation name="Get%d_%d">
+      <soap:operation soapAction="http://example.org/bench/Get%d_%d"/>
+      <input><soap:body use="literal"/></input>
+      <output><soap:body use="literal"/></output>
+    </operation>
+`, s, i, s, i)
+		}
+	}
+	fmt.Fprintf(&b, `  <portType name="BenchPortType">
+%s  </portType>
+  <binding name="BenchBinding" type="tns:BenchPortType">
+    <soap:binding style="document" transport="http://schemas.xmlsoap.org/soap/http"/>
+%s  </binding>
+  <service name="BenchService">
+    <port name="BenchPort" binding="tns:BenchBinding">
+      <soap:address location="http://localhost/bench"/>
+    </port>
+  </service>
+</definitions>
+`, ops.String(), bindings.String())
+
+	file := filepath.Join(dir, fmt.Sprintf("bench-%d-%d.wsdl", schemas, types))
+	if err := ioutil.WriteFile(file, []byte(b.String()), 0600); err != nil {
+		tb.Fatal(err)
+	}
+	return file
+}
+
+// quietLog discards the log of the generations until tb ends.
+func quietLog(tb testing.TB) {
+	log.SetOutput(ioutil.Discard)
+	tb.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// newBenchGoWSDL returns a generator of file.
+func newBenchGoWSDL(tb testing.TB, file string) *GoWSDL {
+	g, err := NewGoWSDL(file, "bench", false, true)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return g
+}
+
+// generateSections runs the render phase of g, which must be loaded.
+func generateSections(tb testing.TB, g *GoWSDL) {
+	for _, gen := range []func() ([]byte, error){g.genTypes, g.genOperations, g.genHeader, g.genSOAPClient} {
+		if _, err := gen(); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, corpus := range benchmarkCorpus {
+		b.Run(corpus.name, func(b *testing.B) {
+			quietLog(b)
+			file := writeLargeWSDL(b, b.TempDir(), corpus.schemas, corpus.types)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				g := newBenchGoWSDL(b, file)
+				if err := g.unmarshal(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTraverse(b *testing.B) {
+	for _, corpus := range benchmarkCorpus {
+		b.Run(corpus.name, func(b *testing.B) {
+			quietLog(b)
+			file := writeLargeWSDL(b, b.TempDir(), corpus.schemas, corpus.types)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				g := newBenchGoWSDL(b, file)
+				if err := g.unmarshal(); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				g.prepare()
+			}
+		})
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	for _, corpus := range benchmarkCorpus {
+		b.Run(corpus.name, func(b *testing.B) {
+			quietLog(b)
+			file := writeLargeWSDL(b, b.TempDir(), corpus.schemas, corpus.types)
+			g := newBenchGoWSDL(b, file)
+			if err := g.load(); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				generateSections(b, g)
+			}
+		})
+	}
+}
+
+// TestPerformanceBudget fails when generating code grows much faster than
+// the WSDL does, as it does when lookups scan every schema for every type.
+func TestPerformanceBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the performance budget in short mode")
+	}
+	quietLog(t)
+	dir := t.TempDir()
+	elapsed := func(types int) time.Duration {
+		file := writeLargeWSDL(t, dir, 4, types)
+		best := time.Duration(0)
+		for i := 0; i < 3; i++ {
+			start := time.Now()
+			g := newBenchGoWSDL(t, file)
+			if err := g.load(); err != nil {
+				t.Fatal(err)
+			}
+			generateSections(t, g)
+			if d := time.Since(start); best == 0 || d < best {
+				best = d
+			}
+		}
+		return best
+	}
+	// Quadrupling the types should not take much more than four times as
+	// long; a quadratic pipeline takes sixteen times as long.
+	small, large := elapsed(100), elapsed(400)
+	if budget := 8 * small; large > budget {
+		t.Errorf("generating 4 times as many types took %v, over the budget of %v (8 times %v)", large, budget, small)
+	}
+}
@@ -0,0 +1,116 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AnyTypeMapping controls the Go type of xs:anyType values. xs:anySimpleType
+// values are strings. Both can be mapped to types of other packages with
+// SetTypeMappings instead.
+type AnyTypeMapping string
+
+const (
+	// AnyTypeInterface maps xs:anyType to interface{}, which encoding/xml
+	// leaves empty when unmarshaling.
+	AnyTypeInterface AnyTypeMapping = "interface"
+	// AnyTypeString maps xs:anyType to string, which holds the character data
+	// of values.
+	AnyTypeString AnyTypeMapping = "string"
+	// AnyTypeRaw maps xs:anyType to the generated RawXML type, which holds
+	// the attributes and raw content of values, to be decoded later.
+	AnyTypeRaw AnyTypeMapping = "raw"
+)
+
+// SetAnyTypeMapping controls the Go type of xs:anyType values. It defaults
+// to AnyTypeInterface.
+func (g *GoWSDL) SetAnyTypeMapping(mapping AnyTypeMapping) error {
+	switch mapping {
+	case AnyTypeInterface, AnyTypeString, AnyTypeRaw:
+		g.anyType = mapping
+		return nil
+	case "":
+		g.anyType = AnyTypeInterface
+		return nil
+	}
+	return fmt.Errorf("unknown anyType mapping %q", mapping)
+}
+
+// typeMapping is the Go type a built-in XSD type is overridden with, and
+// the import path of its package, if any.
+type typeMapping struct {
+	GoType string
+	Import string
+}
+
+// SetTypeMappings overrides the Go types of built-in XSD types, keyed by
+// their local names such as "decimal" or "gYear". A Go type qualified by an
+// import path, such as "github.com/shopspring/decimal.Decimal", is imported
+// when used and referred to by the last element of the path; other types,
+// such as "time.Duration", are used as they are and must be declared or
+// imported by the generated code already.
+func (g *GoWSDL) SetTypeMappings(mappings map[string]string) error {
+	g.typeMappings = make(map[string]typeMapping, len(mappings))
+	for xsdType, goType := range mappings {
+		key := strings.ToLower(xsdType)
+		if _, ok := xsd2GoTypes[key]; !ok {
+			return fmt.Errorf("unknown built-in XSD type %q", xsdType)
+		}
+		m, err := parseTypeMapping(goType)
+		if err != nil {
+			return err
+		}
+		g.typeMappings[key] = m
+	}
+	return nil
+}
+
+// parseTypeMapping splits goType into the Go type used in the generated
+// code and the import path it is qualified by.
+func parseTypeMapping(goType string) (typeMapping, error) {
+	name := strings.TrimLeft(goType, "*[]")
+	modifiers := goType[:len(goType)-len(name)]
+	if name == "" {
+		return typeMapping{}, fmt.Errorf("invalid Go type %q", goType)
+	}
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return typeMapping{GoType: goType}, nil
+	}
+	j := strings.Index(name[i:], ".")
+	if j <= 1 || i+j == len(name)-1 {
+		return typeMapping{}, fmt.Errorf("invalid Go type %q, expected path/to/pkg.Type", goType)
+	}
+	return typeMapping{GoType: modifiers + name[i+1:], Import: name[:i+j]}, nil
+}
+
+// useTypeImport records that path is imported by the generated code.
+func (g *GoWSDL) useTypeImport(path string) {
+	if path == "" {
+		return
+	}
+	g.typeImportsMu.Lock()
+	defer g.typeImportsMu.Unlock()
+	if g.typeImports == nil {
+		g.typeImports = make(map[string]bool)
+	}
+	g.typeImports[path] = true
+}
+
+// usedTypeImports returns the sorted import paths of the type mappings used
+// by the generated code.
+func (g *GoWSDL) usedTypeImports() []string {
+	g.typeImportsMu.Lock()
+	defer g.typeImportsMu.Unlock()
+	paths := make([]string, 0, len(g.typeImports))
+	for path := range g.typeImports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,156 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+)
+
+func TestParseTypeMapping(t *testing.T) {
+	tests := []struct {
+		goType   string
+		expected typeMapping
+	}{
+		{"string", typeMapping{GoType: "string"}},
+		{"time.Duration", typeMapping{GoType: "time.Duration"}},
+		{"github.com/shopspring/decimal.Decimal", typeMapping{GoType: "decimal.Decimal", Import: "github.com/shopspring/decimal"}},
+		{"*math/big.Int", typeMapping{GoType: "*big.Int", Import: "math/big"}},
+	}
+	for _, test := range tests {
+		m, err := parseTypeMapping(test.goType)
+		if err != nil || m != test.expected {
+			t.Errorf("%s: got %+v %v, want %+v", test.goType, m, err, test.expected)
+		}
+	}
+	for _, goType := range []string{"", "*", "math/big", "math/big.", "example.com/."} {
+		if _, err := parseTypeMapping(goType); err == nil {
+			t.Errorf("%q should be rejected", goType)
+		}
+	}
+}
+
+func TestBuiltinTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"Language string `xml:\"Language,omitempty\"`",
+		"Size float64 `xml:\"Size,omitempty\"`",
+		"Pages uint64 `xml:\"Pages,omitempty\"`",
+		"Source string `xml:\"source,attr,omitempty\"`",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+
+	g, err = NewGoWSDL("fixtures/usda-awdb.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resp["types"]), "Duration *Duration") {
+		t.Error("declared types should shadow the built-in types of the same name")
+	}
+}
+
+func TestTypeMappings(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetTypeMappings(map[string]string{"gYear": "int"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetTypeMappings(map[string]string{"money": "int64"}); err == nil {
+		t.Error("unknown XSD types should be rejected")
+	}
+	err = g.SetTypeMappings(map[string]string{
+		"decimal":  "github.com/shopspring/decimal.Decimal",
+		"language": "golang.org/x/text/language.Tag",
+		"gYear":    "example.com/unused.Year",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source(append(resp["header"], resp["types"]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(source)
+	for _, expected := range []string{
+		`"github.com/shopspring/decimal"`,
+		`"golang.org/x/text/language"`,
+		"Size decimal.Decimal `xml:\"Size,omitempty\"`",
+		"Language language.Tag `xml:\"Language,omitempty\"`",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	if strings.Contains(code, "example.com/unused") {
+		t.Error("the packages of unused type mappings should not be imported")
+	}
+}
+
+func TestAnyTypeMapping(t *testing.T) {
+	tests := []struct {
+		mapping  AnyTypeMapping
+		expected string
+	}{
+		{"", "Metadata interface{} `xml:\"Metadata,omitempty\"`"},
+		{AnyTypeString, "Metadata string `xml:\"Metadata,omitempty\"`"},
+		{AnyTypeRaw, "Metadata *RawXML `xml:\"Metadata,omitempty\"`"},
+	}
+	for _, test := range tests {
+		g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.SetAnyTypeMapping(test.mapping); err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := typeCheck(t, resp)
+		if !strings.Contains(code, test.expected) {
+			t.Errorf("%s: %s should be generated", test.mapping, test.expected)
+		}
+		if !strings.Contains(code, "Note string `xml:\"note,attr,omitempty\"`") {
+			t.Errorf("%s: anySimpleType values should be strings", test.mapping)
+		}
+		if raw := strings.Contains(code, "type RawXML struct"); raw != (test.mapping == AnyTypeRaw) {
+			t.Errorf("%s: RawXML should only be generated when used", test.mapping)
+		}
+	}
+
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetAnyTypeMapping("json"); err == nil {
+		t.Error("unknown mappings should be rejected")
+	}
+}
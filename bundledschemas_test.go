@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestBundledSchema(t *testing.T) {
+	for location, namespace := range map[string]string{
+		"http://www.w3.org/2001/xml.xsd":                   "http://www.w3.org/XML/1998/namespace",
+		"https://schemas.xmlsoap.org/soap/encoding/":       "http://schemas.xmlsoap.org/soap/encoding/",
+		"http://www.w3.org/2006/03/addressing/ws-addr.xsd": "http://www.w3.org/2005/08/addressing",
+		"http://www.w3.org/2001/XMLSchema.xsd":             "",
+		"/schemas/www.w3.org/2001/xml.xsd":                 "",
+	} {
+		loc, err := ParseLocation(location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, ok := bundledSchema(loc)
+		if ok != (namespace != "") {
+			t.Errorf("%s: got bundled %v", location, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		schema := new(XSDSchema)
+		if err := xml.Unmarshal(data, schema); err != nil {
+			t.Errorf("%s: %v", location, err)
+		} else if schema.TargetNamespace != namespace {
+			t.Errorf("%s: got namespace %s, want %s", location, schema.TargetNamespace, namespace)
+		}
+	}
+}
+
+func TestBundledSchemaImports(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/bundled.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing may be downloaded.
+	g.SetSchemaAllowList([]string{"example.invalid"})
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(g.wsdl.Types.Schemas); n != 4 {
+		t.Errorf("got %d schemas, want the inline one and 3 bundled ones", n)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"Callback *EndpointReferenceType `",
+		"ReferenceParameters *ReferenceParametersType `",
+		"type Array struct {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+}
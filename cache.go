@@ -0,0 +1,121 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// downloadCache keeps downloaded WSDL and XSD files with their validators,
+// so that later downloads are conditional requests answered with 304 Not
+// Modified when nothing changed.
+type downloadCache struct {
+	dir string
+	// readOnly revalidates against the cache without updating it.
+	readOnly bool
+}
+
+// cacheEntry holds the validators of a cached download.
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// paths returns the files holding the entry and the body of rawURL.
+func (c *downloadCache) paths(rawURL string) (entry, body string) {
+	sum := sha256.Sum256([]byte(rawURL))
+	name := filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+	return name + ".json", name + ".xml"
+}
+
+// load returns the cached entry and body of rawURL, or nil when there are
+// none.
+func (c *downloadCache) load(rawURL string) (*cacheEntry, []byte) {
+	entryPath, bodyPath := c.paths(rawURL)
+	data, err := ioutil.ReadFile(entryPath)
+	if err != nil {
+		return nil, nil
+	}
+	entry := new(cacheEntry)
+	if err := json.Unmarshal(data, entry); err != nil || entry.URL != rawURL {
+		return nil, nil
+	}
+	body, err := ioutil.ReadFile(bodyPath)
+	if err != nil {
+		return nil, nil
+	}
+	return entry, body
+}
+
+// cacheWriter caches the body of a download as it is read.
+type cacheWriter struct {
+	cache  *downloadCache
+	rawURL string
+	resp   *http.Response
+	file   *os.File
+}
+
+// writer returns a writer caching the body of the download of rawURL
+// answered with resp, or nil for read-only caches.
+func (c *downloadCache) writer(rawURL string, resp *http.Response) (*cacheWriter, error) {
+	if c.readOnly {
+		return nil, nil
+	}
+	file, err := ioutil.TempFile(c.dir, "download-")
+	if err != nil {
+		return nil, err
+	}
+	return &cacheWriter{cache: c, rawURL: rawURL, resp: resp, file: file}, nil
+}
+
+func (w *cacheWriter) Write(p []byte) (int, error) {
+	return w.file.Write(p)
+}
+
+// commit keeps the body written as the cached download.
+func (w *cacheWriter) commit() error {
+	data, err := json.Marshal(&cacheEntry{
+		URL:          w.rawURL,
+		ETag:         w.resp.Header.Get("ETag"),
+		LastModified: w.resp.Header.Get("Last-Modified"),
+	})
+	if err != nil {
+		w.discard()
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	entryPath, bodyPath := w.cache.paths(w.rawURL)
+	if err := os.Rename(w.file.Name(), bodyPath); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	return ioutil.WriteFile(entryPath, data, 0600)
+}
+
+// discard drops the body written.
+func (w *cacheWriter) discard() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// setValidators makes req conditional on entry.
+func (e *cacheEntry) setValidators(req *http.Request) {
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownloadCacheRevalidation(t *testing.T) {
+	wsdl := `<definitions xmlns="http://schemas.xmlsoap.org/wsdl/" name="v1"/>`
+	etag := `"v1"`
+	var statuses []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			statuses = append(statuses, http.StatusNotModified)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		statuses = append(statuses, http.StatusOK)
+		w.Write([]byte(wsdl))
+	}))
+	defer srv.Close()
+
+	g, err := NewGoWSDL(srv.URL+"/service.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.UpstreamChanged(); err == nil {
+		t.Error("UpstreamChanged should require the download cache")
+	}
+	g.cache = &downloadCache{dir: t.TempDir()}
+
+	if changed, err := g.UpstreamChanged(); err != nil || !changed {
+		t.Errorf("a WSDL never downloaded should be reported as changed: %v, %v", changed, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := g.unmarshal(); err != nil {
+			t.Fatal(err)
+		}
+		if g.wsdl.Name != "v1" {
+			t.Fatalf("unexpected WSDL %q", g.wsdl.Name)
+		}
+	}
+	if changed, err := g.UpstreamChanged(); err != nil || changed {
+		t.Errorf("an unmodified WSDL should not be reported as changed: %v, %v", changed, err)
+	}
+
+	wsdl, etag = `<definitions xmlns="http://schemas.xmlsoap.org/wsdl/" name="v2"/>`, `"v2"`
+	if changed, err := g.UpstreamChanged(); err != nil || !changed {
+		t.Errorf("a modified WSDL should be reported as changed: %v, %v", changed, err)
+	}
+	if changed, _ := g.UpstreamChanged(); !changed {
+		t.Error("UpstreamChanged should not update the cache")
+	}
+	if err := g.unmarshal(); err != nil || g.wsdl.Name != "v2" {
+		t.Fatalf("unexpected WSDL %q: %v", g.wsdl.Name, err)
+	}
+
+	expected := []int{200, 304, 304, 200, 200, 200}
+	if len(statuses) != len(expected) {
+		t.Fatalf("unexpected responses %v", statuses)
+	}
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Fatalf("unexpected responses %v", statuses)
+		}
+	}
+}
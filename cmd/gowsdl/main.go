@@ -49,8 +49,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	gen "github.com/VoIdemar/gowsdl"
 )
@@ -66,11 +68,94 @@ var pkg = flag.String("p", "myservice", "Package under which code will be genera
 var outFile = flag.String("o", "myservice.go", "File where the generated code will be saved")
 var insecure = flag.Bool("i", false, "Skips TLS Verification")
 var makePublic = flag.Bool("make-public", true, "Make the generated types public/exported")
+var exportFields = flag.Bool("export-fields", true, "Make the generated struct fields public/exported; encoding/xml ignores unexported fields")
+var exportEnums = flag.Bool("export-enums", true, "Make the generated enumeration constants public/exported, along with the types")
 var ignoreTypeNs = flag.Bool("ignore-type-ns", false, "Consider types from XSD the same if they have equal names")
+var typeNsPrefixes listFlag
+var schemaLocations listFlag
+var schemaAllow listFlag
+var schemaDeny listFlag
+var typeMappings listFlag
+var anyType = flag.String("any-type", "interface", "Go type of xs:anyType values: interface, string, or raw for RawXML values keeping their XML content")
+var typeNameStrip listFlag
+var typeNameReplace listFlag
+var typeNamePrefix = flag.String("type-name-prefix", "", "Prefix added to the names of the generated types, such as the name of the service")
+var typeNameSuffix = flag.String("type-name-suffix", "", "Suffix added to the names of the generated types")
+var elementSuffix = flag.String("element-suffix", "Element", "Suffix of the Go types of global elements whose names are taken by other types")
 var login = flag.String("login", "", "HTTP Basic auth login")
 var password = flag.String("password", "", "HTTP Basic auth password")
+var netrc = flag.Bool("netrc", false, "Take HTTP Basic auth credentials of downloads from $NETRC or ~/.netrc by host")
+var envAuth = flag.Bool("env-auth", false, "Take HTTP Basic auth credentials of downloads from GOWSDL_AUTH_<HOST>=login:password variables")
+var proxy = flag.String("proxy", "", "Forward proxy of WSDL and XSD downloads, as http://host:port")
+var proxyLogin = flag.String("proxy-login", "", "HTTP Basic auth login of the -proxy")
+var proxyPassword = flag.String("proxy-password", "", "HTTP Basic auth password of the -proxy")
+var redirectAuth = flag.String("redirect-auth", "same-host", "When to send auth and -header headers again on redirected downloads: same-host, always or never")
+var headers listFlag
+var postCommands listFlag
+var maxSchemas = flag.Int("max-schemas", 0, "Maximum number of external schemas read, 0 for no limit")
+var maxFileSize = flag.Int64("max-file-size", 0, "Maximum size in bytes of the WSDL and of each schema, 0 for no limit")
+var maxDownloadSize = flag.Int64("max-download-size", 0, "Maximum size in bytes of the WSDL and its schemas together, 0 for no limit")
+var safeDownloads = flag.Bool("safe-downloads", false, "Refuse downloads from non-public addresses, https to http switches and file schema locations of downloaded documents, for untrusted WSDL URLs")
+var progress = flag.Bool("progress", false, "Log the phases of the generation with the numbers of schemas, types and operations")
+var renderCache = flag.String("render-cache", "", "Directory caching the code rendered for each schema and for the operations, so that generating again only renders what changed")
+var cache = flag.Bool("cache", false, "Cache downloaded WSDL and XSD files and revalidate them with conditional requests")
+var builders = flag.Bool("builders", false, "Generate fluent builders for complex types")
+var ptrHelpers = flag.Bool("ptr-helpers", false, "Generate generic Ptr/Deref helpers for optional fields")
+var nullable = flag.Bool("nullable", false, "Use generic Nullable[T] wrappers for nillable elements")
+var flattenArrays = flag.Bool("flatten-arrays", false, "Flatten elements of ArrayOf wrapper types into slices of their repeated element")
+var unwrapMessages = flag.Bool("unwrap-messages", false, "Make operations take and return the only element of single-element request and response wrappers")
+var streamBase64 = flag.Bool("stream-base64", false, "Stream base64Binary elements through io.Reader-backed Base64Stream values instead of byte slices")
+var clone = flag.Bool("clone", false, "Generate deep-copy Clone methods for complex types")
+var equal = flag.Bool("equal", false, "Generate structural Equal methods for complex types")
+var stringer = flag.Bool("stringer", false, "Generate String methods and a Dump helper for complex types")
+var enumHelpers = flag.Bool("enum-helpers", false, "Generate String, Parse, IsValid and AllValues helpers for enumerations")
+var async = flag.Bool("async", false, "Generate FooAsync variants of operations returning a result channel")
+var batch = flag.Bool("batch", false, "Generate CallBatch and FooBatch helpers for running many requests with bounded concurrency")
+var testServer = flag.Bool("test-server", false, "Generate httptest-based mock servers for the generated clients")
+var cachingClients = flag.Bool("caching-clients", false, "Generate a caching decorator per port type, keeping responses for per-operation TTLs in a pluggable Cache; implies -interfaces")
+var loggingClients = flag.Bool("logging-clients", false, "Generate a logging decorator per port type, logging the duration, payload sizes and faults of operation calls; implies -interfaces")
+var interfaces = flag.Bool("interfaces", false, "Generate an interface per port type, returned by the client constructors and implemented by an unexported struct")
+var vcr = flag.Bool("vcr", false, "Generate a record/replay VCRTransport for offline tests of the generated client")
+var examples = flag.Bool("examples", false, "Generate an examples_test.go with an Example per generated operation")
+var packageDoc = flag.Bool("package-doc", false, "Generate a doc.go with an overview of the services, their endpoints and operations")
+var operationNaming = flag.String("operation-naming", "", "Go template naming the client methods of operations from their .Port and .Operation, such as {{.Port}}{{title .Operation}}; title, trimPrefix, trimSuffix and replace are available")
+var goVersion = flag.String("go-version", "", "Go release the generated code targets, such as 1.17: generics are left out before 1.18, any replaces interface{} from 1.18 on")
+var headerFile = flag.String("header-file", "", "File whose content, such as a license banner or //go:build constraints, is placed above the package clause of every generated file")
+var openAPIFile = flag.String("openapi", "", "Also write an OpenAPI 3 document describing the WSDL to this file")
+var jsonSchemaFile = flag.String("jsonschema", "", "Also write a JSON Schema of the XSD types to this file")
+var protoFile = flag.String("proto", "", "Also write a proto3 file mirroring the XSD types and operations to this file")
+var graphFile = flag.String("graph", "", "Also write the dependency graph of the schemas and types to this file")
+var graphFormat = flag.String("graph-format", "dot", "Format of the -graph file: dot (Graphviz) or json")
+var compatFile = flag.String("compat", "", "YAML file mapping the former names of types and operations to the current ones, declaring the former names as deprecated aliases and wrapper methods")
+var snapshotFile = flag.String("snapshot", "", "Also write a snapshot of the operations and types of the WSDL to this file, to check drift against later")
+var checkDrift = flag.String("check-drift", "", "Instead of generating code, report the changes of the WSDL since the snapshot in this file, exiting with status 1 when it changed")
+var diffFrom = flag.String("diff", "", "Instead of generating code, list the changes of the WSDL since the previous version of it at this path or URL, exiting with status 1 when any is breaking")
+var workspace = flag.String("workspace", "", "Workspace file, such as "+gen.DefaultWorkspaceFile+", describing the services to generate in one run instead of a single WSDL")
+var omitEmpty = flag.String("omitempty", "all", "When to add omitempty to XML tags: all, optional (minOccurs=0) or never")
+
+// listFlag collects the values of a repeated flag.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
 
 func init() {
+	flag.Var(&headers, "header", "Header added to WSDL and XSD downloads, as \"Name: value\"; may be repeated")
+	flag.Var(&typeMappings, "type-map", "Go type of a built-in XSD type, as \"xsdType=GoType\" where GoType may be qualified by its import path, such as \"decimal=github.com/shopspring/decimal.Decimal\"; may be repeated")
+	flag.Var(&schemaLocations, "schema-location", "File path or URL of the schema of a namespace imported without a schema location, as \"namespace=location\"; may be repeated")
+	flag.Var(&schemaAllow, "schema-allow", "Host, such as \"*.example.com\", or URL prefix of the schemas that may be downloaded, failing the generation for others; may be repeated")
+	flag.Var(&schemaDeny, "schema-deny", "Host, such as \"www.w3.org\", or URL prefix of the schemas that are never downloaded; may be repeated")
+	flag.Var(&postCommands, "post-cmd", "Command run on the generated Go files once they are written, which are appended to its space-separated arguments, such as \"goimports -w\"; may be repeated, a failing command fails the generation")
+	flag.Var(&typeNameStrip, "type-name-strip", "Prefix stripped from the names of the generated types, such as ns1_ or ArrayOf; may be repeated, the first matching one is stripped")
+	flag.Var(&typeNameReplace, "type-name-replace", "Replacement in the names of the generated types, as \"regexp=replacement\" with $1 for submatches; may be repeated, applied in order after stripping")
+	flag.Var(&typeNsPrefixes, "type-ns-prefix", "Prefix of the type names of a namespace, as \"namespace=Prefix\" or \"namespace=\" for none; may be repeated, other namespaces get a derived prefix")
+
 	log.SetFlags(0)
 	log.SetOutput(os.Stdout)
 	log.SetPrefix("🍀  ")
@@ -90,6 +175,19 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *workspace != "" {
+		w, err := gen.LoadWorkspace(*workspace)
+		if err != nil {
+			log.Fatalln("Workspace cannot be read:", err)
+		}
+		if err := w.Generate(); err != nil {
+			log.Println("Error occurred: ", err)
+		} else {
+			log.Println("Done 👍")
+		}
+		return
+	}
+
 	if len(os.Args) < 2 {
 		flag.Usage()
 		os.Exit(0)
@@ -101,15 +199,116 @@ func main() {
 		log.Fatalln("Output file cannot be the same WSDL file")
 	}
 
+	netrcFile := ""
+	if *netrc {
+		netrcFile = gen.DefaultNetrcFile()
+	}
+
+	fileHeader := ""
+	if *headerFile != "" {
+		header, err := ioutil.ReadFile(*headerFile)
+		if err != nil {
+			log.Fatalln("Header file cannot be read:", err)
+		}
+		fileHeader = string(header)
+	}
+
 	generator := &gen.Generator{
 		WsdlPath:             wsdlPath,
 		Pkg:                  *pkg,
 		MakePublic:           *makePublic,
+		PrivateFields:        !*exportFields,
+		PrivateEnums:         !*exportEnums,
 		InsecureTLS:          *insecure,
 		Login:                *login,
 		Password:             *password,
+		NetrcFile:            netrcFile,
+		EnvCredentials:       *envAuth,
+		Proxy:                *proxy,
+		ProxyLogin:           *proxyLogin,
+		ProxyPassword:        *proxyPassword,
+		Headers:              headers,
+		RedirectAuth:         *redirectAuth,
+		Cache:                *cache,
+		MaxSchemas:           *maxSchemas,
+		MaxFileSize:          *maxFileSize,
+		MaxDownloadSize:      *maxDownloadSize,
+		SafeDownloads:        *safeDownloads,
+		RenderCache:          *renderCache,
 		IgnoreTypeNamespaces: *ignoreTypeNs,
+		TypeNsPrefixes:       typeNsPrefixes,
+		TypeNameStrip:        typeNameStrip,
+		TypeNameReplace:      typeNameReplace,
+		TypeNamePrefix:       *typeNamePrefix,
+		TypeNameSuffix:       *typeNameSuffix,
+		SchemaLocations:      schemaLocations,
+		SchemaAllowList:      schemaAllow,
+		SchemaDenyList:       schemaDeny,
+		TypeMappings:         typeMappings,
+		AnyType:              *anyType,
+		ElementTypeSuffix:    *elementSuffix,
 		OutFile:              *outFile,
+		GenerateBuilders:     *builders,
+		PointerHelpers:       *ptrHelpers,
+		Nullable:             *nullable,
+		FlattenArrays:        *flattenArrays,
+		UnwrapMessages:       *unwrapMessages,
+		StreamBase64:         *streamBase64,
+		OmitEmpty:            *omitEmpty,
+		OpenAPIFile:          *openAPIFile,
+		JSONSchemaFile:       *jsonSchemaFile,
+		ProtoFile:            *protoFile,
+		GraphFile:            *graphFile,
+		GraphFormat:          *graphFormat,
+		SnapshotFile:         *snapshotFile,
+		Clone:                *clone,
+		Equal:                *equal,
+		Stringer:             *stringer,
+		EnumHelpers:          *enumHelpers,
+		Async:                *async,
+		Batch:                *batch,
+		TestServer:           *testServer,
+		Interfaces:           *interfaces,
+		CachingClients:       *cachingClients,
+		LoggingClients:       *loggingClients,
+		VCR:                  *vcr,
+		Examples:             *examples,
+		PackageDoc:           *packageDoc,
+		FileHeader:           fileHeader,
+		GoVersion:            *goVersion,
+		OperationNaming:      *operationNaming,
+		CompatFile:           *compatFile,
+	}
+	for _, command := range postCommands {
+		generator.PostProcess = append(generator.PostProcess, gen.PostCommand(command))
+	}
+	if *progress {
+		generator.Progress = func(p gen.Progress) {
+			log.Printf("[PROGRESS] %s %s (%d schemas, %d types, %d operations)", p.Phase, p.Item, p.Schemas, p.Types, p.Operations)
+		}
+	}
+	if *diffFrom != "" {
+		diff, err := generator.Diff(*diffFrom)
+		if err != nil {
+			log.Fatalln("WSDLs cannot be compared:", err)
+		}
+		fmt.Print(diff)
+		if diff.Breaking() {
+			os.Exit(1)
+		}
+		return
+	}
+	if *checkDrift != "" {
+		diff, err := generator.CheckDrift(*checkDrift)
+		if err != nil {
+			log.Fatalln("Drift cannot be checked:", err)
+		}
+		if diff.Empty() {
+			log.Println("No drift since", *checkDrift)
+			return
+		}
+		fmt.Print(diff)
+		os.Exit(1)
 	}
 	if err := generator.Generate(); err != nil {
 		log.Println("Error occurred: ", err)
@@ -0,0 +1,231 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CompatMappings map the Go names of types and operations generated from a
+// previous version of a WSDL to the names generated from the current one, so
+// that code using the former keeps compiling while it migrates, such as:
+//
+//	types:
+//	  OrderInfo: Order
+//	operations:
+//	  FetchOrder: GetOrder
+//
+// Each type is declared as a deprecated alias of the type it maps to. Each
+// operation is declared as a deprecated method of the clients with the
+// method it maps to, calling it, and so are its Async and Batch variants
+// when those are generated. The client interfaces declare the deprecated
+// methods too.
+type CompatMappings struct {
+	Types      map[string]string `yaml:"types"`
+	Operations map[string]string `yaml:"operations"`
+}
+
+// LoadCompatMappings reads the compatibility mappings of the YAML file at
+// path.
+func LoadCompatMappings(path string) (*CompatMappings, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	node, err := parseYAML(data)
+	if err == nil {
+		m := new(CompatMappings)
+		if err = decodeYAML(reflect.ValueOf(m), node, ""); err == nil {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", path, err)
+}
+
+// SetCompatMappings makes the generated code declare the former names of its
+// types and operations, deprecated. Generation fails when a former name is
+// still generated or a current one is not.
+func (g *GoWSDL) SetCompatMappings(m *CompatMappings) {
+	g.compat = m
+}
+
+// compatSuffixes are the suffixes of the variants of the operation methods.
+var compatSuffixes = []string{"", "Async", "Batch"}
+
+// genCompat returns the declarations of the former names of the compat
+// mappings, given the generated types and operations, whose client
+// interfaces it returns with the deprecated methods added.
+func (g *GoWSDL) genCompat(types, operations []byte) (compat, ops []byte, err error) {
+	if g.compat == nil || len(g.compat.Types)+len(g.compat.Operations) == 0 {
+		return nil, operations, nil
+	}
+	// The package clause is prepended on the first line, so that the offsets
+	// of the operations are those of the source plus its length.
+	const clause = "package p;"
+	fset := token.NewFileSet()
+	typesFile, err := parser.ParseFile(fset, "types", clause+string(types), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	opsFile, err := parser.ParseFile(fset, "operations", clause+string(operations), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	declared := make(map[string]bool)
+	methods := make(map[string]*ast.FuncDecl)
+	var interfaces []*ast.InterfaceType
+	for _, file := range []*ast.File{typesFile, opsFile} {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*ast.TypeSpec); ok {
+						declared[spec.Name.Name] = true
+						if it, ok := spec.Type.(*ast.InterfaceType); ok && file == opsFile {
+							interfaces = append(interfaces, it)
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				// The methods of the types are not operations.
+				if decl.Recv != nil && file == opsFile {
+					methods[receiverType(fset, decl)+"."+decl.Name.Name] = decl
+				}
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	for _, old := range sortedKeys(g.compat.Types) {
+		name := g.compat.Types[old]
+		switch {
+		case declared[old]:
+			return nil, nil, fmt.Errorf("compatibility mappings: type %s is still generated", old)
+		case !declared[name]:
+			return nil, nil, fmt.Errorf("compatibility mappings: type %s of %s is not generated", name, old)
+		}
+		fmt.Fprintf(&b, "// %s is the former name of %s.\n//\n// Deprecated: Use %s instead.\ntype %s = %s\n\n", old, name, name, old, name)
+	}
+
+	// insertions holds the methods added to the interfaces by the offsets of
+	// their closing braces.
+	insertions := make(map[int]string)
+	for _, old := range sortedKeys(g.compat.Operations) {
+		name := g.compat.Operations[old]
+		found := false
+		for _, key := range sortedMethodKeys(methods) {
+			fn := methods[key]
+			recv := receiverType(fset, fn)
+			for _, suffix := range compatSuffixes {
+				if fn.Name.Name != name+suffix {
+					continue
+				}
+				if methods[recv+"."+old+suffix] != nil {
+					return nil, nil, fmt.Errorf("compatibility mappings: operation %s of %s is still generated", old+suffix, strings.TrimPrefix(recv, "*"))
+				}
+				found = true
+				writeCompatMethod(&b, fset, fn, old+suffix)
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("compatibility mappings: operation %s of %s is not generated", name, old)
+		}
+		for _, it := range interfaces {
+			for _, method := range it.Methods.List {
+				for _, suffix := range compatSuffixes {
+					if len(method.Names) != 1 || method.Names[0].Name != name+suffix {
+						continue
+					}
+					offset := fset.Position(it.Methods.Closing).Offset - len(clause)
+					insertions[offset] += fmt.Sprintf("\n// Deprecated: Use %s instead.\n%s%s\n", name+suffix, old+suffix,
+						strings.TrimPrefix(nodeString(fset, method.Type), "func"))
+				}
+			}
+		}
+	}
+
+	if len(insertions) > 0 {
+		offsets := make([]int, 0, len(insertions))
+		for offset := range insertions {
+			offsets = append(offsets, offset)
+		}
+		sort.Ints(offsets)
+		var patched bytes.Buffer
+		last := 0
+		for _, offset := range offsets {
+			patched.Write(operations[last:offset])
+			patched.WriteString(insertions[offset])
+			last = offset
+		}
+		patched.Write(operations[last:])
+		operations = patched.Bytes()
+	}
+	return b.Bytes(), operations, nil
+}
+
+// writeCompatMethod writes the deprecated method old of the receiver of fn,
+// calling fn with its arguments.
+func writeCompatMethod(b *bytes.Buffer, fset *token.FileSet, fn *ast.FuncDecl, old string) {
+	var args []string
+	for _, param := range fn.Type.Params.List {
+		for _, name := range param.Names {
+			arg := name.Name
+			if _, ok := param.Type.(*ast.Ellipsis); ok {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+	}
+	recv := fn.Recv.List[0]
+	call := fmt.Sprintf("%s.%s(%s)", recv.Names[0].Name, fn.Name.Name, strings.Join(args, ", "))
+	if fn.Type.Results != nil {
+		call = "return " + call
+	}
+	fmt.Fprintf(b, "// Deprecated: Use %s instead.\nfunc (%s %s) %s%s {\n%s\n}\n\n", fn.Name.Name,
+		recv.Names[0].Name, nodeString(fset, recv.Type), old,
+		strings.TrimPrefix(nodeString(fset, fn.Type), "func"), call)
+}
+
+// receiverType returns the receiver type of the method fn, such as *client.
+func receiverType(fset *token.FileSet, fn *ast.FuncDecl) string {
+	return nodeString(fset, fn.Recv.List[0].Type)
+}
+
+// nodeString prints node as Go source.
+func nodeString(fset *token.FileSet, node ast.Node) string {
+	var b bytes.Buffer
+	printer.Fprint(&b, fset, node)
+	return b.String()
+}
+
+// sortedKeys returns the keys of m, sorted.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedMethodKeys returns the keys of methods, sorted.
+func sortedMethodKeys(methods map[string]*ast.FuncDecl) []string {
+	keys := make([]string, 0, len(methods))
+	for k := range methods {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
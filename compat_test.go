@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"go/format"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompatMappings(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/simpleparts.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateInterfaces(true)
+	g.SetGenerateAsync(true)
+	g.SetCompatMappings(&CompatMappings{
+		Types:      map[string]string{"LookupRequest": "Lookup"},
+		Operations: map[string]string{"Find": "Lookup"},
+	})
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var code []byte
+	for _, section := range []string{"header", "types", "operations", "compat", "soap"} {
+		code = append(code, resp[section]...)
+	}
+	source, err := format.Source(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"// LookupRequest is the former name of Lookup.\n//\n// Deprecated: Use Lookup instead.\ntype LookupRequest = Lookup\n",
+		"\t// Deprecated: Use Lookup instead.\n\tFind(request *Lookup, opts ...CallOption) (CountryCode, error)\n",
+		"\t// Deprecated: Use LookupAsync instead.\n\tFindAsync(ctx context.Context, request *Lookup, opts ...CallOption) <-chan AsyncResult[CountryCode]\n",
+		"// Deprecated: Use Lookup instead.\nfunc (service *geoPortTypeClient) Find(request *Lookup, opts ...CallOption) (CountryCode, error) {\n\treturn service.Lookup(request, opts...)\n}\n",
+		"func (service *geoPortTypeClient) FindAsync(ctx context.Context, request *Lookup, opts ...CallOption) <-chan AsyncResult[CountryCode] {\n\treturn service.LookupAsync(ctx, request, opts...)\n}\n",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("missing %q in:\n%s", expected, source)
+		}
+	}
+}
+
+func TestCompatMappings_Invalid(t *testing.T) {
+	for _, test := range []struct {
+		mappings CompatMappings
+		err      string
+	}{
+		{
+			mappings: CompatMappings{Types: map[string]string{"Lookup": "City"}},
+			err:      "type Lookup is still generated",
+		},
+		{
+			mappings: CompatMappings{Types: map[string]string{"Town": "Village"}},
+			err:      "type Village of Town is not generated",
+		},
+		{
+			mappings: CompatMappings{Operations: map[string]string{"Echo": "Lookup"}},
+			err:      "operation Echo of geoPortTypeClient is still generated",
+		},
+		{
+			mappings: CompatMappings{Operations: map[string]string{"Find": "Search"}},
+			err:      "operation Search of Find is not generated",
+		},
+	} {
+		g, err := NewGoWSDL("fixtures/simpleparts.wsdl", "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.SetGenerateInterfaces(true)
+		g.SetCompatMappings(&test.mappings)
+		if _, err := g.Start(); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("got error %v, want %q", err, test.err)
+		}
+	}
+}
+
+func TestLoadCompatMappings(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "compat.yaml")
+	data := "types:\n  OrderInfo: Order\noperations:\n  FetchOrder: GetOrder # renamed in v2\n"
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadCompatMappings(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &CompatMappings{
+		Types:      map[string]string{"OrderInfo": "Order"},
+		Operations: map[string]string{"FetchOrder": "GetOrder"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %+v, want %+v", m, want)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("type:\n  OrderInfo: Order\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCompatMappings(file); err == nil || !strings.HasPrefix(err.Error(), file+": ") {
+		t.Errorf("got error %v, want the unknown key to be reported", err)
+	}
+}
@@ -0,0 +1,37 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+var docTmpl = `
+{{- with fileHeader}}{{.}}
+
+{{end -}}
+// Package {{.Pkg}} is the client of {{with .WSDL.Service}}the {{range $i, $service := .}}{{if $i}}, {{end}}{{$service.Name}}{{end}} {{if eq (len .) 1}}service{{else}}services{{end}}{{else}}a SOAP service{{end}}, generated by gowsdl.
+{{- $doc := summary .WSDL.Doc}}
+{{- with $doc}}
+//
+// {{.}}
+{{- end}}
+{{- range .WSDL.Service}}{{with summary .Doc}}{{if ne . $doc}}
+//
+// {{.}}
+{{- end}}{{end}}{{end}}
+{{- range .Clients}}
+{{- $binding := .Binding}}
+{{- $httpVerb := .HTTPVerb}}
+//
+// New{{.Name | makePublic}} creates clients{{with .Address}} of {{.}} by default{{end}}{{if $httpVerb}}, sending HTTP {{$httpVerb}} requests{{end}}.
+{{- if .PortType.Operations}} Their operations are:
+//
+{{- range .PortType.Operations}}
+{{- $generated := ne .Input.Message ""}}
+{{- if $httpVerb}}{{$op := httpOperation . $binding}}{{$generated = and (ne $op.Encoding "") $op.XMLOutput}}{{end}}
+{{- if $generated}}
+//   - {{operationName .}}{{with summary .Doc}}: {{.}}{{end}}
+{{- end}}{{end}}
+{{- end}}
+{{- end}}
+package {{.Pkg}}
+`
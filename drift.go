@@ -0,0 +1,260 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// snapshotVersion changes whenever the signatures recorded by snapshots do,
+// so that snapshots of other versions are not compared.
+const snapshotVersion = 1
+
+// A ContractSnapshot records the operations and types of a WSDL, so that
+// the changes of its contract can be told later, such as by pinning it next
+// to the code generated from the WSDL.
+type ContractSnapshot struct {
+	Version  int    `json:"version"`
+	Location string `json:"location"`
+	// Hash covers the operations and types, and tells whether the contract
+	// changed at all.
+	Hash string `json:"hash"`
+	// Operations holds the signatures of the operations, named
+	// PortType.Operation, listing the parts of their messages and their
+	// SOAP actions.
+	Operations map[string]string `json:"operations"`
+	// Types holds hashes of the definitions of the named types and global
+	// elements of the schemas, named {namespace}name and
+	// element {namespace}name. Documentation is left out.
+	Types map[string]string `json:"types"`
+}
+
+// A ContractDiff lists the operations and types added, removed and changed
+// from a snapshot to another, sorted by name.
+type ContractDiff struct {
+	AddedOperations   []string `json:"addedOperations,omitempty"`
+	RemovedOperations []string `json:"removedOperations,omitempty"`
+	ChangedOperations []string `json:"changedOperations,omitempty"`
+	AddedTypes        []string `json:"addedTypes,omitempty"`
+	RemovedTypes      []string `json:"removedTypes,omitempty"`
+	ChangedTypes      []string `json:"changedTypes,omitempty"`
+}
+
+// Empty reports whether the contract did not change.
+func (d *ContractDiff) Empty() bool {
+	return len(d.AddedOperations)+len(d.RemovedOperations)+len(d.ChangedOperations)+
+		len(d.AddedTypes)+len(d.RemovedTypes)+len(d.ChangedTypes) == 0
+}
+
+// String lists the changes one per line, marked with + when added, - when
+// removed and ~ when changed.
+func (d *ContractDiff) String() string {
+	var b strings.Builder
+	for _, changes := range []struct {
+		mark, kind string
+		names      []string
+	}{
+		{"+", "operation", d.AddedOperations},
+		{"-", "operation", d.RemovedOperations},
+		{"~", "operation", d.ChangedOperations},
+		{"+", "type", d.AddedTypes},
+		{"-", "type", d.RemovedTypes},
+		{"~", "type", d.ChangedTypes},
+	} {
+		for _, name := range changes.names {
+			fmt.Fprintf(&b, "%s %s %s\n", changes.mark, changes.kind, name)
+		}
+	}
+	return b.String()
+}
+
+// LoadSnapshot reads the snapshot written to path by WriteFile.
+func LoadSnapshot(path string) (*ContractSnapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := new(ContractSnapshot)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// WriteFile writes s to path as indented JSON, which is meant to be kept
+// under version control.
+func (s *ContractSnapshot) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Snapshot loads the WSDL and returns the snapshot of its contract.
+func (g *GoWSDL) Snapshot() (*ContractSnapshot, error) {
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	s := &ContractSnapshot{
+		Version:    snapshotVersion,
+		Location:   g.loc.String(),
+		Operations: make(map[string]string),
+		Types:      make(map[string]string),
+	}
+	for _, pt := range g.wsdl.PortTypes {
+		for _, op := range pt.Operations {
+			s.Operations[pt.Name+"."+op.Name] = g.operationSignature(pt, op)
+		}
+	}
+	for _, schema := range g.wsdl.Types.Schemas {
+		ns := "{" + schema.TargetNamespace + "}"
+		for _, ct := range schema.ComplexTypes {
+			s.Types[ns+ct.Name] = definitionHash(ct)
+		}
+		for _, st := range schema.SimpleType {
+			s.Types[ns+st.Name] = definitionHash(st)
+		}
+		for _, el := range schema.Elements {
+			s.Types["element "+ns+el.Name] = definitionHash(el)
+		}
+	}
+	s.Hash = s.hash()
+	return s, nil
+}
+
+// Drift loads the WSDL and returns the changes of its contract since the
+// snapshot.
+func (g *GoWSDL) Drift(snapshot *ContractSnapshot) (*ContractDiff, error) {
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot version %d is not supported, take the snapshot again", snapshot.Version)
+	}
+	live, err := g.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return CompareSnapshots(snapshot, live), nil
+}
+
+// CompareSnapshots returns the changes of the contract from the snapshot
+// before to the snapshot after.
+func CompareSnapshots(before, after *ContractSnapshot) *ContractDiff {
+	d := new(ContractDiff)
+	if before.Hash != "" && before.Hash == after.Hash {
+		return d
+	}
+	d.AddedOperations, d.RemovedOperations, d.ChangedOperations = compareEntries(before.Operations, after.Operations)
+	d.AddedTypes, d.RemovedTypes, d.ChangedTypes = compareEntries(before.Types, after.Types)
+	return d
+}
+
+// compareEntries returns the names of the entries added, removed and changed
+// from before to after.
+func compareEntries(before, after map[string]string) (added, removed, changed []string) {
+	for name, value := range after {
+		if previous, ok := before[name]; !ok {
+			added = append(added, name)
+		} else if previous != value {
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// hash returns the hash of the operations and types of s.
+func (s *ContractSnapshot) hash() string {
+	h := sha256.New()
+	for _, entries := range []map[string]string{s.Operations, s.Types} {
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(h, "%s\x00%s\n", name, entries[name])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// operationSignature describes the messages of op of pt and the SOAP
+// actions of its bindings.
+func (g *GoWSDL) operationSignature(pt *WSDLPortType, op *WSDLOperation) string {
+	sig := fmt.Sprintf("input(%s) output(%s)", g.messageParts(op.Input.Message), g.messageParts(op.Output.Message))
+	for _, fault := range op.Faults {
+		sig += fmt.Sprintf(" fault %s(%s)", fault.Name, g.messageParts(fault.Message))
+	}
+	for _, binding := range g.wsdl.Binding {
+		if localName(binding.Type) != pt.Name {
+			continue
+		}
+		for _, bop := range binding.Operations {
+			if bop.Name == op.Name && bop.SOAPOperation.SOAPAction != "" {
+				sig += fmt.Sprintf(" action %s=%s", binding.Name, bop.SOAPOperation.SOAPAction)
+			}
+		}
+	}
+	return sig
+}
+
+// messageParts describes the parts of message as name=element or name=type,
+// separated by commas.
+func (g *GoWSDL) messageParts(message string) string {
+	var parts []string
+	for _, msg := range g.index().messages[localName(message)] {
+		for _, part := range msg.Parts {
+			parts = append(parts, part.Name+"="+part.Element+part.Type)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// definitionHash returns the hash of the definition of a type or element,
+// without its documentation.
+func definitionHash(definition interface{}) string {
+	data, err := json.Marshal(definition)
+	if err == nil {
+		var tree interface{}
+		if err = json.Unmarshal(data, &tree); err == nil {
+			data, err = json.Marshal(withoutDocs(tree))
+		}
+	}
+	if err != nil {
+		// Definitions that cannot be hashed are told apart by their errors.
+		return err.Error()
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// withoutDocs returns the JSON tree without its Doc members.
+func withoutDocs(tree interface{}) interface{} {
+	switch tree := tree.(type) {
+	case map[string]interface{}:
+		delete(tree, "Doc")
+		for key, value := range tree {
+			tree[key] = withoutDocs(value)
+		}
+	case []interface{}:
+		for i, value := range tree {
+			tree[i] = withoutDocs(value)
+		}
+	}
+	return tree
+}
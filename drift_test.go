@@ -0,0 +1,160 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// driftWSDL writes fixtures/stock.wsdl to dir, changed by replacing the
+// old/new pairs of replacements.
+func driftWSDL(t *testing.T, dir string, replacements ...string) string {
+	data, err := ioutil.ReadFile("fixtures/stock.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsdl := strings.NewReplacer(replacements...).Replace(string(data))
+	file := filepath.Join(dir, "stock.wsdl")
+	if err := ioutil.WriteFile(file, []byte(wsdl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestDrift(t *testing.T) {
+	pinned, err := NewGoWSDL("fixtures/stock.wsdl", "stock", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := pinned.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op := snapshot.Operations["StockQuotePortType.GetLastTradePrice"]; !strings.Contains(op, "action StockQuoteSoapBinding=http://example.com/GetLastTradePrice") {
+		t.Errorf("got signature %q, want it to list the SOAP action", op)
+	}
+
+	tests := []struct {
+		name         string
+		replacements []string
+		want         ContractDiff
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name: "documentation",
+			replacements: []string{
+				`<element name="TradePriceRequest">`,
+				`<element name="TradePriceRequest"><annotation><documentation>The ticker</documentation></annotation>`,
+			},
+		},
+		{
+			name:         "changed type",
+			replacements: []string{`type="float"`, `type="double"`},
+			want:         ContractDiff{ChangedTypes: []string{"element {http://example.com/stockquote.xsd}TradePrice"}},
+		},
+		{
+			name: "changed action",
+			replacements: []string{
+				`soapAction="http://example.com/GetLastTradePrice"`,
+				`soapAction="http://example.com/v2/GetLastTradePrice"`,
+			},
+			want: ContractDiff{ChangedOperations: []string{"StockQuotePortType.GetLastTradePrice"}},
+		},
+		{
+			name: "added and removed",
+			replacements: []string{
+				`<operation name="GetLastTradePrice">
+			<input message`,
+				`<operation name="GetTradePrice">
+			<input message`,
+				`</schema>`,
+				`<simpleType name="Symbol"><restriction base="string"/></simpleType></schema>`,
+			},
+			want: ContractDiff{
+				AddedOperations:   []string{"StockQuotePortType.GetTradePrice"},
+				RemovedOperations: []string{"StockQuotePortType.GetLastTradePrice"},
+				AddedTypes:        []string{"{http://example.com/stockquote.xsd}Symbol"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			live, err := NewGoWSDL(driftWSDL(t, t.TempDir(), test.replacements...), "stock", false, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diff, err := live.Drift(snapshot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*diff, test.want) {
+				t.Errorf("got diff %+v, want %+v", *diff, test.want)
+			}
+			if diff.Empty() != reflect.DeepEqual(test.want, ContractDiff{}) {
+				t.Errorf("got Empty() %v for diff %+v", diff.Empty(), *diff)
+			}
+		})
+	}
+}
+
+func TestContractDiff_String(t *testing.T) {
+	diff := ContractDiff{
+		AddedOperations: []string{"Port.Create"},
+		RemovedTypes:    []string{"{urn:x}Old"},
+		ChangedTypes:    []string{"element {urn:x}Order"},
+	}
+	want := "+ operation Port.Create\n- type {urn:x}Old\n~ type element {urn:x}Order\n"
+	if got := diff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGenerator_CheckDrift(t *testing.T) {
+	dir := t.TempDir()
+	snapshotFile := filepath.Join(dir, "stock.snapshot.json")
+	r := &Generator{
+		WsdlPath:     "fixtures/stock.wsdl",
+		Pkg:          "stock",
+		MakePublic:   true,
+		GraphFormat:  string(GraphDOT),
+		OutFile:      filepath.Join(dir, "stock", "stock.go"),
+		SnapshotFile: snapshotFile,
+	}
+	if err := r.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.WsdlPath = driftWSDL(t, dir, `type="float"`, `type="double"`)
+	diff, err := r.CheckDrift(snapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "~ type element {http://example.com/stockquote.xsd}TradePrice\n"; diff.String() != want {
+		t.Errorf("got diff %q, want %q", diff.String(), want)
+	}
+
+	data, err := ioutil.ReadFile(snapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := strings.Replace(string(data), `"version": 1`, `"version": 0`, 1)
+	if err := ioutil.WriteFile(snapshotFile, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CheckDrift(snapshotFile); err == nil || !strings.Contains(err.Error(), "snapshot version 0") {
+		t.Errorf("got error %v, want the snapshot version to be rejected", err)
+	}
+
+	if _, err := r.CheckDrift(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("got error %v, want a missing snapshot file", err)
+	}
+}
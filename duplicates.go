@@ -0,0 +1,251 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const defaultElementTypeSuffix = "Element"
+
+// SetElementTypeSuffix sets the suffix given to the Go type of a global
+// element whose name is taken by another type even after prefixing it with
+// its namespace. It defaults to "Element".
+func (g *GoWSDL) SetElementTypeSuffix(suffix string) {
+	if suffix == "" {
+		suffix = defaultElementTypeSuffix
+	}
+	g.elementTypeSuffix = suffix
+}
+
+// goNameKey returns the key under which name collides with other Go
+// declarations.
+func (g *GoWSDL) goNameKey(name string) string {
+	return g.typeName(name)
+}
+
+// disambiguateElements names the Go types of global elements declaring an
+// anonymous complex type after the type name transforms, apart from the
+// named types and from each other. Named types keep their names, as do the
+// first elements of each name. The others get the prefix of their
+// namespace, as configured for the types or derived from the namespace,
+// then the element type suffix, then a number, until the name is free.
+// Element references are mapped to the new names.
+func (g *GoWSDL) disambiguateElements() {
+	g.elementTypeNames = make(map[*XSDElement]string)
+	g.elementRefTypes = make(map[*XSDElement]string)
+
+	taken := make(map[string]bool)
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, st := range schema.SimpleType {
+			taken[g.goNameKey(st.Name)] = true
+		}
+		for _, ct := range schema.ComplexTypes {
+			taken[g.goNameKey(ct.Name)] = true
+		}
+	}
+
+	suffix := g.elementTypeSuffix
+	if suffix == "" {
+		suffix = defaultElementTypeSuffix
+	}
+	renamed := make(map[xml.Name]string)
+	for _, schema := range g.wsdl.Types.Schemas {
+		prefix, ok := g.typeNsPrefixes[schema.TargetNamespace]
+		if !ok && schema.TargetNamespace != "" {
+			prefix = namespacePrefix(schema.TargetNamespace)
+		}
+		for _, el := range schema.Elements {
+			if el.Type != "" || el.ComplexType == nil {
+				continue
+			}
+			base := g.typeNames.apply("", el.Name)
+			name := base
+			prefixed := g.typeNames.apply(prefix, el.Name)
+			candidates := []string{prefixed, name + suffix, prefixed + suffix}
+			for i := 0; taken[g.goNameKey(name)]; i++ {
+				if i < len(candidates) {
+					name = candidates[i]
+				} else {
+					name = prefixed + suffix + strconv.Itoa(i-len(candidates)+2)
+				}
+			}
+			taken[g.goNameKey(name)] = true
+			if name == el.Name {
+				continue
+			}
+
+			if name != base {
+				log.Printf("[INFO] Element %s of namespace %s is generated as type %s", el.Name, schema.TargetNamespace, name)
+			}
+			g.elementTypeNames[el] = name
+			renamed[xml.Name{Space: schema.TargetNamespace, Local: el.Name}] = name
+		}
+	}
+	if len(renamed) == 0 {
+		return
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		r := &typeRenamer{schema: schema, wsdl: g.wsdl, refs: renamed, refTypes: g.elementRefTypes}
+		for _, ct := range schema.ComplexTypes {
+			r.complexType(ct)
+		}
+		r.elements(schema.Elements)
+	}
+}
+
+// testServerMembers are the members of the generated test servers, which
+// embed *httptest.Server and declare a field for each operation.
+var testServerMembers = []string{
+	"Server", "URL", "Listener", "EnableHTTP2", "TLS", "Config", "Start", "StartTLS",
+	"Close", "CloseClientConnections", "Certificate", "Client", "NewClient", "serveSOAP",
+}
+
+// operationNameData is passed to the template naming the client methods of
+// operations.
+type operationNameData struct {
+	// Port is the name of the port type declaring the operation.
+	Port string
+	// Operation is the name of the operation.
+	Operation string
+}
+
+// operationNamingFuncs are the functions of operation naming templates.
+var operationNamingFuncs = template.FuncMap{
+	"title":      makePublic,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replace":    strings.ReplaceAll,
+}
+
+// SetOperationNaming sets the text/template naming the client methods of
+// operations, executed with the Port and Operation names, such as
+// "{{.Port}}{{title .Operation}}" or "{{trimSuffix .Port "Soap"}}_{{.Operation}}".
+// The title, trimPrefix, trimSuffix and replace functions wrap their
+// counterparts of the strings package, and the functions registered with
+// RegisterTemplateFunc are available too. The characters identifiers cannot
+// hold are dropped from the result. An empty naming names the methods after
+// the operations.
+func (g *GoWSDL) SetOperationNaming(naming string) error {
+	g.operationNaming = nil
+	if naming == "" {
+		return nil
+	}
+	tmpl, err := template.New("operation").Funcs(operationNamingFuncs).Funcs(g.customFuncs).
+		Option("missingkey=error").Parse(naming)
+	if err != nil {
+		return fmt.Errorf("invalid operation naming: %v", err)
+	}
+	if err := tmpl.Execute(ioutil.Discard, operationNameData{Port: "Port", Operation: "Operation"}); err != nil {
+		return fmt.Errorf("invalid operation naming: %v", err)
+	}
+	g.operationNaming = tmpl
+	return nil
+}
+
+// methodName returns the name of the client method of the operation named
+// name of pt, as configured by the operation naming.
+func (g *GoWSDL) methodName(pt *WSDLPortType, name string) string {
+	if g.operationNaming == nil {
+		return name
+	}
+	var b strings.Builder
+	if err := g.operationNaming.Execute(&b, operationNameData{Port: pt.Name, Operation: name}); err != nil || b.Len() == 0 {
+		log.Printf("[WARN] Operation naming failed for operation %s of port type %s: %v", name, pt.Name, err)
+		return name
+	}
+	return b.String()
+}
+
+// clientMembers returns the members of the generated client of a port type
+// and of its test server and decorators, by name, with the type declaring them. The client
+// methods of operations must not take their names.
+func (g *GoWSDL) clientMembers(pt *WSDLPortType) map[string]string {
+	members := map[string]string{"AddHeader": "client", "SetHeader": "client", "client": "client"}
+	if g.generateTestServer {
+		for _, name := range testServerMembers {
+			members[name] = "test server"
+		}
+	}
+	if g.generateCaching {
+		members["Cache"], members["TTL"] = "caching client", "caching client"
+	}
+	if g.generateLogging {
+		members["Logger"] = "logging client"
+	}
+	for _, op := range pt.Operations {
+		if g.generateAsync {
+			members[g.goNameKey(g.methodName(pt, op.Name))+"Async"] = "client"
+		}
+		if g.generateBatch {
+			members[g.goNameKey(g.methodName(pt, op.Name))+"Batch"] = "client"
+		}
+	}
+	return members
+}
+
+// disambiguateOperations names the client methods of operations, after the
+// operations or as configured by the operation naming. Methods sharing their
+// name with a member of the generated client or test server get an Operation
+// suffix. Overloaded operations, which share their name with another
+// operation of the same port type, are named after their input, or else with
+// a number.
+func (g *GoWSDL) disambiguateOperations() {
+	g.operationNames = make(map[*WSDLOperation]string)
+	for _, pt := range g.wsdl.PortTypes {
+		members := g.clientMembers(pt)
+		names := make(map[*WSDLOperation]string, len(pt.Operations))
+		taken := make(map[string]bool)
+		for _, op := range pt.Operations {
+			names[op] = g.methodName(pt, op.Name)
+			taken[g.goNameKey(names[op])] = true
+		}
+		free := func(name string) bool {
+			key := g.goNameKey(name)
+			return name != "" && !taken[key] && members[key] == ""
+		}
+
+		seen := make(map[string]bool)
+		for _, op := range pt.Operations {
+			base := names[op]
+			key := g.goNameKey(base)
+			member, collides := members[key]
+			if !seen[key] && !collides {
+				seen[key] = true
+				if base != op.Name {
+					g.operationNames[op] = base
+				}
+				continue
+			}
+
+			var name string
+			if collides {
+				name = base + "Operation"
+				for i := 2; !free(name); i++ {
+					name = base + "Operation" + strconv.Itoa(i)
+				}
+				log.Printf("[WARN] Operation %s of port type %s collides with %s of the generated %s and is generated as %s",
+					op.Name, pt.Name, key, member, name)
+			} else {
+				if op.Input.Name != "" {
+					name = g.methodName(pt, op.Input.Name)
+				}
+				for i := 2; !free(name); i++ {
+					name = base + strconv.Itoa(i)
+				}
+				log.Printf("[INFO] Operation %s of port type %s is generated as %s", op.Name, pt.Name, name)
+			}
+			taken[g.goNameKey(name)] = true
+			g.operationNames[op] = name
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDuplicateNamesDisambiguated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/duplicates.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetElementTypeSuffix("Msg")
+	g.SetTypeNamespacePrefixes(map[string]string{"urn:example:orders": ""})
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"type Order struct {",
+		"type OrderMsg struct {",
+		"XMLName xml.Name `xml:\"urn:example:orders Order\"`",
+		"type Status struct {",
+		"type StatusMsg struct {",
+		"XMLName xml.Name `xml:\"urn:example:orders Status\"`",
+		"Status *Status `xml:\"Status,omitempty\"`",
+		"func (service *OrdersPort) Place(request *OrderMsg, opts ...CallOption) (*StatusMsg, error) {",
+		"func (service *OrdersPort) Find(request *FindById, opts ...CallOption) (*StatusMsg, error) {",
+		"func (service *OrdersPort) FindByName(request *FindByName, opts ...CallOption) (*StatusMsg, error) {",
+		"func (service *BillingPort) Place(request *OrderMsg, opts ...CallOption) (*Status, error) {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+var examplesTmpl = `
+{{with fileHeader}}{{.}}
+
+{{end}}package {{.Pkg}}
+
+import (
+	"log"
+)
+
+{{range .Clients}}{{if not .HTTPVerb}}
+	{{$portType := .Name | makePublic}}
+	{{range .PortType.Operations}}
+		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
+		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+		{{$name := operationName .}}
+		{{if and (ne .Input.Message "") (not (messageValue .Input.Message)) (or (eq .Output.Message "") (ne $responseType ""))}}
+		func {{exampleName $portType $name}}() {
+			// An empty URL selects the endpoint declared by the WSDL.
+			client := New{{$portType}}("", false, nil)
+			{{if ne $requestType ""}}
+			request := &{{$requestType}}{ {{range exampleFields .Input.Message}}
+				{{if .Value}}{{.Name}}: {{.Value}},{{else}}// {{.Name}}: {{.Type}}{{end}}{{end}}
+			}
+			{{end}}
+			{{if eq .Output.Message ""}}err{{else}}response, err{{end}} := client.{{$name}}({{if ne $requestType ""}}request{{end}})
+			if err != nil {
+				if fault, ok := err.(*SOAPFault); ok {
+					log.Println("fault:", fault.Code, fault.String)
+					return
+				}
+				log.Println(err)
+				return
+			}{{if ne .Output.Message ""}}
+			log.Println(response){{end}}
+		}
+		{{end}}
+	{{end}}
+{{end}}{{end}}
+`
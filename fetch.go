@@ -0,0 +1,120 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// A Fetcher reads the WSDLs and schemas at the URLs of a scheme gowsdl does
+// not download itself, such as s3:// or git://, so that documents kept in
+// artifact repositories can be generated from directly.
+type Fetcher interface {
+	// Fetch opens the document at loc, a URL of the scheme the fetcher is
+	// registered for. The document is closed once it is read.
+	Fetch(loc *Location) (io.ReadCloser, error)
+}
+
+// FetcherFunc adapts a function to a Fetcher.
+type FetcherFunc func(loc *Location) (io.ReadCloser, error)
+
+// Fetch calls f(loc).
+func (f FetcherFunc) Fetch(loc *Location) (io.ReadCloser, error) {
+	return f(loc)
+}
+
+// A Resolver is a Fetcher registered for every generator with
+// RegisterResolver.
+type Resolver = Fetcher
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = make(map[string]Resolver)
+)
+
+// RegisterResolver makes every generator read the WSDLs and schemas at the
+// URLs of scheme with resolver, unless a fetcher is registered for scheme
+// with RegisterFetcher. Packages reading artifact stores, such as s3:// or
+// gs:// buckets or classpath-like bundles, typically register their
+// resolvers from their init functions, as database/sql drivers do. The
+// documents read by resolvers are handled as those read by fetchers.
+func RegisterResolver(scheme string, resolver Resolver) error {
+	scheme, err := checkFetcher(scheme, resolver)
+	if err != nil {
+		return err
+	}
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[scheme] = resolver
+	return nil
+}
+
+// RegisterFetcher reads the WSDL and schemas at the URLs of scheme with
+// fetcher. The schema locations of the documents it reads are resolved
+// against their URLs, as those of downloaded documents are. The download
+// limits and the schema allow and deny lists apply to the documents read by
+// fetchers, and safe downloads allow schemas at their URLs, but credentials,
+// proxies, headers and the download cache do not. Registering http or https
+// replaces the downloads of gowsdl.
+func (g *GoWSDL) RegisterFetcher(scheme string, fetcher Fetcher) error {
+	scheme, err := checkFetcher(scheme, fetcher)
+	if err != nil {
+		return err
+	}
+	if g.fetchers == nil {
+		g.fetchers = make(map[string]Fetcher)
+	}
+	g.fetchers[scheme] = fetcher
+	return nil
+}
+
+// checkFetcher returns scheme in lower case, or an error when fetcher cannot
+// be registered for it.
+func checkFetcher(scheme string, fetcher Fetcher) (string, error) {
+	if !validScheme(scheme) {
+		return "", fmt.Errorf("invalid URL scheme %q", scheme)
+	}
+	scheme = strings.ToLower(scheme)
+	if scheme == "file" {
+		return "", errors.New("file URLs are read as files, not by fetchers")
+	}
+	if fetcher == nil {
+		return "", fmt.Errorf("no fetcher for the %s scheme", scheme)
+	}
+	return scheme, nil
+}
+
+// fetcher returns the fetcher or resolver registered for the scheme of loc,
+// or nil when gowsdl reads loc itself.
+func (g *GoWSDL) fetcher(loc *Location) Fetcher {
+	if !loc.IsURL() {
+		return nil
+	}
+	scheme := strings.ToLower(loc.u.Scheme)
+	if fetcher, ok := g.fetchers[scheme]; ok {
+		return fetcher
+	}
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	return resolvers[scheme]
+}
+
+// validScheme reports whether scheme is a URL scheme as RFC 3986 defines
+// them: a letter followed by letters, digits, +, - or dots.
+func validScheme(scheme string) bool {
+	for i, r := range scheme {
+		switch {
+		case 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z':
+		case i > 0 && ('0' <= r && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return scheme != ""
+}
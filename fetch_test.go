@@ -0,0 +1,147 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const fetchedWSDL = `<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"
+             xmlns:soap="http://schemas.xmlsoap.org/wsdl/soap/"
+             xmlns:xs="http://www.w3.org/2001/XMLSchema"
+             xmlns:tns="http://example.org/orders"
+             targetNamespace="http://example.org/orders">
+  <types>
+    <xs:schema targetNamespace="http://example.org/orders">
+      <xs:import namespace="http://example.org/orders/types" schemaLocation="../types/orders.xsd"/>
+    </xs:schema>
+  </types>
+</definitions>
+`
+
+const fetchedSchema = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="http://example.org/orders/types">
+  <xs:complexType name="Order">
+    <xs:sequence>
+      <xs:element name="id" type="xs:long"/>
+    </xs:sequence>
+  </xs:complexType>
+</xs:schema>
+`
+
+func TestRegisterFetcher(t *testing.T) {
+	documents := map[string]string{
+		"s3://artifacts/wsdl/orders.wsdl": fetchedWSDL,
+		"s3://artifacts/types/orders.xsd": fetchedSchema,
+	}
+	var fetched []string
+	fetcher := FetcherFunc(func(loc *Location) (io.ReadCloser, error) {
+		fetched = append(fetched, loc.String())
+		doc, ok := documents[loc.URL().String()]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", loc)
+		}
+		return ioutil.NopCloser(strings.NewReader(doc)), nil
+	})
+
+	g, err := NewGoWSDL("s3://artifacts/wsdl/orders.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scheme := range []string{"", "3s", "s 3", "file", "FILE"} {
+		if err := g.RegisterFetcher(scheme, fetcher); err == nil {
+			t.Errorf("scheme %q should be rejected", scheme)
+		}
+	}
+	if err := g.RegisterFetcher("S3", nil); err == nil {
+		t.Error("a nil fetcher should be rejected")
+	}
+	if err := g.RegisterFetcher("S3", fetcher); err != nil {
+		t.Fatal(err)
+	}
+	g.SetSafeDownloads(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resp["types"]), "type Order struct") {
+		t.Errorf("missing the fetched schema type in:\n%s", resp["types"])
+	}
+	expected := []string{"s3://artifacts/wsdl/orders.wsdl", "s3://artifacts/types/orders.xsd"}
+	if strings.Join(fetched, " ") != strings.Join(expected, " ") {
+		t.Errorf("got fetched %v, want %v", fetched, expected)
+	}
+
+	// The download limits apply to fetched documents.
+	g, err = NewGoWSDL("s3://artifacts/wsdl/orders.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RegisterFetcher("s3", fetcher); err != nil {
+		t.Fatal(err)
+	}
+	g.SetDownloadLimits(DownloadLimits{FileSize: int64(len(fetchedWSDL)) - 1})
+	if _, err := g.Start(); err == nil || !strings.Contains(err.Error(), "larger than the limit") {
+		t.Errorf("got error %v, want the file size limit exceeded", err)
+	}
+}
+
+func TestRegisterResolver(t *testing.T) {
+	bundle := map[string]string{
+		"bundle:wsdl/orders.wsdl": fetchedWSDL,
+		"bundle:types/orders.xsd": fetchedSchema,
+	}
+	resolver := FetcherFunc(func(loc *Location) (io.ReadCloser, error) {
+		doc, ok := bundle[loc.String()]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", loc)
+		}
+		return ioutil.NopCloser(strings.NewReader(doc)), nil
+	})
+	if err := RegisterResolver("file", resolver); err == nil {
+		t.Error("file should be rejected")
+	}
+	if err := RegisterResolver("bundle", resolver); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		resolversMu.Lock()
+		delete(resolvers, "bundle")
+		resolversMu.Unlock()
+	}()
+
+	g, err := NewGoWSDL("bundle:wsdl/orders.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resp["types"]), "type Order struct") {
+		t.Errorf("missing the resolved schema type in:\n%s", resp["types"])
+	}
+
+	// Fetchers of a generator take precedence over resolvers.
+	g, err = NewGoWSDL("bundle:wsdl/orders.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unavailable := FetcherFunc(func(loc *Location) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("%s is unavailable", loc)
+	})
+	if err := g.RegisterFetcher("bundle", unavailable); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Start(); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("got error %v, want the error of the fetcher", err)
+	}
+}
@@ -1,11 +1,13 @@
 package gowsdl
 
 import (
-	"bytes"
-	"go/format"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"regexp"
+	"strings"
 )
 
 type Generator struct {
@@ -13,23 +15,139 @@ type Generator struct {
 	Pkg                  string
 	InsecureTLS          bool
 	MakePublic           bool
+	PrivateFields        bool
+	PrivateEnums         bool
 	Login                string
 	Password             string
+	NetrcFile            string
+	EnvCredentials       bool
+	Negotiate            NegotiateProvider
+	Proxy                string
+	ProxyLogin           string
+	ProxyPassword        string
+	Headers              []string
+	RedirectAuth         string
+	Cache                bool
+	MaxSchemas           int
+	MaxFileSize          int64
+	MaxDownloadSize      int64
+	SafeDownloads        bool
+	Progress             func(Progress)
+	Fetchers             map[string]Fetcher
+	RenderCache          string
 	IgnoreTypeNamespaces bool
+	TypeNsPrefixes       []string
+	TypeNameStrip        []string
+	TypeNameReplace      []string
+	TypeNamePrefix       string
+	TypeNameSuffix       string
+	SchemaLocations      []string
+	SchemaAllowList      []string
+	SchemaDenyList       []string
+	TypeMappings         []string
+	AnyType              string
+	ElementTypeSuffix    string
 	OutFile              string
+	GenerateBuilders     bool
+	PointerHelpers       bool
+	Nullable             bool
+	FlattenArrays        bool
+	UnwrapMessages       bool
+	StreamBase64         bool
+	OmitEmpty            string
+	OpenAPIFile          string
+	JSONSchemaFile       string
+	ProtoFile            string
+	GraphFile            string
+	GraphFormat          string
+	SnapshotFile         string
+	Clone                bool
+	Equal                bool
+	Stringer             bool
+	EnumHelpers          bool
+	Async                bool
+	Batch                bool
+	TestServer           bool
+	Interfaces           bool
+	CachingClients       bool
+	LoggingClients       bool
+	VCR                  bool
+	Examples             bool
+	PackageDoc           bool
+	FileHeader           string
+	GoVersion            string
+	OperationNaming      string
+	TemplateFuncs        map[string]interface{}
+	CompatFile           string
+	PostProcess          []PostProcessFunc
+	docs                 *documentCache
 }
 
 func (r *Generator) Generate() (err error) {
-	// load wsdl
-	goWsdl, err := NewGoWSDL(r.WsdlPath, r.Pkg, r.InsecureTLS, r.MakePublic)
+	goWsdl, err := r.newGoWSDL()
 	if err != nil {
-		log.Println("[ERROR] WSDL has not been loaded: ", err)
 		return
 	}
-	if len(r.Login) > 0 && len(r.Password) > 0 {
-		goWsdl.SetBasicAuth(r.Login, r.Password)
+
+	if r.OpenAPIFile != "" {
+		var doc []byte
+		if doc, err = goWsdl.OpenAPI(); err != nil {
+			log.Println("[ERROR] OpenAPI document has not been generated: ", err)
+			return
+		}
+		if err = ioutil.WriteFile(r.OpenAPIFile, doc, 0644); err != nil {
+			log.Println("[ERROR] OpenAPI file has not been created: ", err)
+			return
+		}
+	}
+
+	if r.JSONSchemaFile != "" {
+		var doc []byte
+		if doc, err = goWsdl.JSONSchema(); err != nil {
+			log.Println("[ERROR] JSON Schema has not been generated: ", err)
+			return
+		}
+		if err = ioutil.WriteFile(r.JSONSchemaFile, doc, 0644); err != nil {
+			log.Println("[ERROR] JSON Schema file has not been created: ", err)
+			return
+		}
+	}
+
+	if r.ProtoFile != "" {
+		var doc []byte
+		if doc, err = goWsdl.Proto(); err != nil {
+			log.Println("[ERROR] Proto file has not been generated: ", err)
+			return
+		}
+		if err = ioutil.WriteFile(r.ProtoFile, doc, 0644); err != nil {
+			log.Println("[ERROR] Proto file has not been created: ", err)
+			return
+		}
+	}
+
+	if r.GraphFile != "" {
+		var doc []byte
+		if doc, err = goWsdl.TypeGraph(GraphFormat(r.GraphFormat)); err != nil {
+			log.Println("[ERROR] Type graph has not been generated: ", err)
+			return
+		}
+		if err = ioutil.WriteFile(r.GraphFile, doc, 0644); err != nil {
+			log.Println("[ERROR] Type graph file has not been created: ", err)
+			return
+		}
+	}
+
+	if r.SnapshotFile != "" {
+		var snapshot *ContractSnapshot
+		if snapshot, err = goWsdl.Snapshot(); err != nil {
+			log.Println("[ERROR] Contract snapshot has not been taken: ", err)
+			return
+		}
+		if err = snapshot.WriteFile(r.SnapshotFile); err != nil {
+			log.Println("[ERROR] Contract snapshot file has not been created: ", err)
+			return
+		}
 	}
-	goWsdl.SetIgnoreTypeNamespaces(r.IgnoreTypeNamespaces)
 
 	// generate code
 	goCode, err := goWsdl.Start()
@@ -43,28 +161,250 @@ func (r *Generator) Generate() (err error) {
 		return
 	}
 
-	file, err := os.Create(r.OutFile)
+	// go fmt the generated code
+	goWsdl.reportProgress(PhaseFormat, r.OutFile)
+	err = writeFormattedSections(r.OutFile, goCode["header"], goCode["types"], goCode["operations"], goCode["compat"], goCode["soap"])
 	if err != nil {
 		log.Println("[ERROR] Output file has not been created: ", err)
 		return
 	}
-	defer file.Close()
 
-	data := new(bytes.Buffer)
-	data.Write(goCode["header"])
-	data.Write(goCode["types"])
-	data.Write(goCode["operations"])
-	data.Write(goCode["soap"])
+	files := []string{r.OutFile}
+	if len(goCode["examples"]) > 0 {
+		examplesFile := path.Join(path.Dir(r.OutFile), "examples_test.go")
+		goWsdl.reportProgress(PhaseFormat, examplesFile)
+		err = writeFormattedSections(examplesFile, goCode["examples"])
+		if err != nil {
+			log.Println("[ERROR] Examples file has not been created: ", err)
+			return
+		}
+		files = append(files, examplesFile)
+	}
 
-	// go fmt the generated code
-	source, err := format.Source(data.Bytes())
-	if err != nil {
-		file.Write(data.Bytes())
-		log.Println("[WARN] Code formatting failed: ", err)
+	if len(goCode["doc"]) > 0 {
+		docFile := path.Join(path.Dir(r.OutFile), "doc.go")
+		goWsdl.reportProgress(PhaseFormat, docFile)
+		err = writeFormattedSections(docFile, goCode["doc"])
+		if err != nil {
+			log.Println("[ERROR] Package doc file has not been created: ", err)
+			return
+		}
+		files = append(files, docFile)
+	}
+
+	if err = postProcess(r.PostProcess, files); err != nil {
+		log.Println("[ERROR] Post-processing failed: ", err)
 		return
 	}
 
-	file.Write(source)
+	return
+}
 
+// CheckDrift returns the changes of the contract of the WSDL since the
+// snapshot pinned in snapshotFile, without generating code.
+func (r *Generator) CheckDrift(snapshotFile string) (*ContractDiff, error) {
+	snapshot, err := LoadSnapshot(snapshotFile)
+	if err != nil {
+		return nil, err
+	}
+	goWsdl, err := r.newGoWSDL()
+	if err != nil {
+		return nil, err
+	}
+	return goWsdl.Drift(snapshot)
+}
+
+// Diff returns the changes of the WSDL since the version of it at
+// beforePath, which is loaded with the same options.
+func (r *Generator) Diff(beforePath string) (*WSDLDiff, error) {
+	after, err := r.newGoWSDL()
+	if err != nil {
+		return nil, err
+	}
+	previous := *r
+	previous.WsdlPath = beforePath
+	before, err := previous.newGoWSDL()
+	if err != nil {
+		return nil, err
+	}
+	return DiffWSDL(before, after)
+}
+
+// newGoWSDL returns the GoWSDL generating with the options of r, logging why
+// it cannot.
+func (r *Generator) newGoWSDL() (goWsdl *GoWSDL, err error) {
+	// load wsdl
+	goWsdl, err = NewGoWSDL(r.WsdlPath, r.Pkg, r.InsecureTLS, r.MakePublic)
+	if err != nil {
+		log.Println("[ERROR] WSDL has not been loaded: ", err)
+		return
+	}
+	if len(r.Login) > 0 && len(r.Password) > 0 {
+		goWsdl.SetBasicAuth(r.Login, r.Password)
+	}
+	goWsdl.SetEnvCredentials(r.EnvCredentials)
+	goWsdl.SetNegotiateProvider(r.Negotiate)
+	if err = goWsdl.SetProxy(r.Proxy); err != nil {
+		log.Println("[ERROR] Invalid download options: ", err)
+		return
+	}
+	if len(r.ProxyLogin) > 0 {
+		goWsdl.SetProxyAuth(r.ProxyLogin, r.ProxyPassword)
+	}
+	if err = goWsdl.SetNetrcFile(r.NetrcFile); err != nil {
+		log.Println("[ERROR] Invalid download options: ", err)
+		return
+	}
+	for _, header := range r.Headers {
+		i := strings.Index(header, ":")
+		if i <= 0 {
+			err = fmt.Errorf("invalid header %q, expected Name: value", header)
+			log.Println("[ERROR] Invalid download options: ", err)
+			return
+		}
+		goWsdl.AddDownloadHeader(strings.TrimSpace(header[:i]), strings.TrimSpace(header[i+1:]))
+	}
+	goWsdl.SetDownloadCache(r.Cache)
+	goWsdl.sharedDocs = r.docs
+	goWsdl.SetSafeDownloads(r.SafeDownloads)
+	goWsdl.SetProgressFunc(r.Progress)
+	for scheme, fetcher := range r.Fetchers {
+		if err = goWsdl.RegisterFetcher(scheme, fetcher); err != nil {
+			log.Println("[ERROR] Invalid download options: ", err)
+			return
+		}
+	}
+	if err = goWsdl.SetRenderCache(r.RenderCache); err != nil {
+		log.Println("[ERROR] Invalid render cache: ", err)
+		return
+	}
+	goWsdl.SetDownloadLimits(DownloadLimits{Schemas: r.MaxSchemas, FileSize: r.MaxFileSize, TotalSize: r.MaxDownloadSize})
+	if err = goWsdl.SetRedirectAuthPolicy(RedirectAuthPolicy(r.RedirectAuth)); err != nil {
+		log.Println("[ERROR] Invalid download options: ", err)
+		return
+	}
+	if len(r.SchemaLocations) > 0 {
+		locations := make(map[string]string, len(r.SchemaLocations))
+		for _, mapping := range r.SchemaLocations {
+			i := strings.Index(mapping, "=")
+			if i <= 0 {
+				err = fmt.Errorf("invalid schema location %q, expected namespace=location", mapping)
+				log.Println("[ERROR] Invalid download options: ", err)
+				return
+			}
+			locations[mapping[:i]] = mapping[i+1:]
+		}
+		if err = goWsdl.SetSchemaLocations(locations); err != nil {
+			log.Println("[ERROR] Invalid download options: ", err)
+			return
+		}
+	}
+	goWsdl.SetSchemaAllowList(r.SchemaAllowList)
+	goWsdl.SetSchemaDenyList(r.SchemaDenyList)
+	goWsdl.SetIgnoreTypeNamespaces(r.IgnoreTypeNamespaces)
+	if len(r.TypeNsPrefixes) > 0 {
+		prefixes := make(map[string]string, len(r.TypeNsPrefixes))
+		for _, mapping := range r.TypeNsPrefixes {
+			i := strings.LastIndex(mapping, "=")
+			if i <= 0 {
+				err = fmt.Errorf("invalid type namespace prefix %q, expected namespace=prefix", mapping)
+				log.Println("[ERROR] Invalid type options: ", err)
+				return
+			}
+			prefixes[mapping[:i]] = mapping[i+1:]
+		}
+		goWsdl.SetTypeNamespacePrefixes(prefixes)
+	}
+	if len(r.TypeNameStrip)+len(r.TypeNameReplace) > 0 || r.TypeNamePrefix != "" || r.TypeNameSuffix != "" {
+		transforms := TypeNameTransforms{StripPrefixes: r.TypeNameStrip, Prefix: r.TypeNamePrefix, Suffix: r.TypeNameSuffix}
+		for _, replace := range r.TypeNameReplace {
+			i := strings.LastIndex(replace, "=")
+			if i <= 0 {
+				err = fmt.Errorf("invalid type name replacement %q, expected regexp=replacement", replace)
+				log.Println("[ERROR] Invalid type options: ", err)
+				return
+			}
+			var pattern *regexp.Regexp
+			if pattern, err = regexp.Compile(replace[:i]); err != nil {
+				log.Println("[ERROR] Invalid type options: ", err)
+				return
+			}
+			transforms.Replacements = append(transforms.Replacements, TypeNameReplacement{Pattern: pattern, Replacement: replace[i+1:]})
+		}
+		goWsdl.SetTypeNameTransforms(transforms)
+	}
+	if len(r.TypeMappings) > 0 {
+		mappings := make(map[string]string, len(r.TypeMappings))
+		for _, mapping := range r.TypeMappings {
+			i := strings.Index(mapping, "=")
+			if i <= 0 {
+				err = fmt.Errorf("invalid type mapping %q, expected xsdType=GoType", mapping)
+				log.Println("[ERROR] Invalid type options: ", err)
+				return
+			}
+			mappings[mapping[:i]] = mapping[i+1:]
+		}
+		if err = goWsdl.SetTypeMappings(mappings); err != nil {
+			log.Println("[ERROR] Invalid type options: ", err)
+			return
+		}
+	}
+	if err = goWsdl.SetAnyTypeMapping(AnyTypeMapping(r.AnyType)); err != nil {
+		log.Println("[ERROR] Invalid type options: ", err)
+		return
+	}
+	goWsdl.SetElementTypeSuffix(r.ElementTypeSuffix)
+	goWsdl.SetExportFields(!r.PrivateFields)
+	goWsdl.SetExportEnums(r.MakePublic && !r.PrivateEnums)
+	goWsdl.SetGenerateBuilders(r.GenerateBuilders)
+	goWsdl.SetGeneratePointerHelpers(r.PointerHelpers)
+	goWsdl.SetGenerateNullable(r.Nullable)
+	goWsdl.SetFlattenArrays(r.FlattenArrays)
+	goWsdl.SetUnwrapMessages(r.UnwrapMessages)
+	goWsdl.SetStreamBase64(r.StreamBase64)
+	goWsdl.SetGenerateClone(r.Clone)
+	goWsdl.SetGenerateEqual(r.Equal)
+	goWsdl.SetGenerateStringer(r.Stringer)
+	goWsdl.SetGenerateEnumHelpers(r.EnumHelpers)
+	goWsdl.SetGenerateAsync(r.Async)
+	goWsdl.SetGenerateBatch(r.Batch)
+	goWsdl.SetGenerateTestServer(r.TestServer)
+	goWsdl.SetGenerateInterfaces(r.Interfaces)
+	goWsdl.SetGenerateCachingClients(r.CachingClients)
+	goWsdl.SetGenerateLoggingClients(r.LoggingClients)
+	goWsdl.SetGenerateVCR(r.VCR)
+	goWsdl.SetGenerateExamples(r.Examples)
+	goWsdl.SetGeneratePackageDoc(r.PackageDoc)
+	goWsdl.SetFileHeader(r.FileHeader)
+	if err = goWsdl.SetOmitEmptyPolicy(OmitEmptyPolicy(r.OmitEmpty)); err != nil {
+		log.Println("[ERROR] Invalid generation options: ", err)
+		return
+	}
+	if err = goWsdl.SetGoVersion(r.GoVersion); err != nil {
+		log.Println("[ERROR] Invalid generation options: ", err)
+		return
+	}
+	for name, fn := range r.TemplateFuncs {
+		if err = goWsdl.RegisterTemplateFunc(name, fn); err != nil {
+			log.Println("[ERROR] Invalid generation options: ", err)
+			return
+		}
+	}
+	if err = goWsdl.SetOperationNaming(r.OperationNaming); err != nil {
+		log.Println("[ERROR] Invalid generation options: ", err)
+		return
+	}
+	if r.CompatFile != "" {
+		var mappings *CompatMappings
+		if mappings, err = LoadCompatMappings(r.CompatFile); err != nil {
+			log.Println("[ERROR] Compatibility mappings have not been loaded: ", err)
+			return
+		}
+		goWsdl.SetCompatMappings(mappings)
+	}
+	if err = goWsdl.ValidateTemplates(); err != nil {
+		log.Println("[ERROR] Invalid templates: ", err)
+		return
+	}
 	return
 }
@@ -0,0 +1,96 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+const (
+	// minGoVersion is the minor version of the oldest Go release the
+	// generated code compiles with.
+	minGoVersion = 13
+	// genericsGoVersion is the minor version of the Go release introducing
+	// generics and the any alias.
+	genericsGoVersion = 18
+)
+
+// SetGoVersion sets the Go release the generated code targets, such as
+// "1.17" or "go1.21.3". Generics are left out before Go 1.18: the async and
+// batch methods, the pointer helpers and the Nullable fields are not
+// generated then, and simple typed messages use anonymous structs. From Go
+// 1.18 on, any replaces interface{}. The default, an empty version, targets
+// the latest release but keeps interface{}.
+func (g *GoWSDL) SetGoVersion(version string) error {
+	minor, err := parseGoVersion(version)
+	if err != nil {
+		return err
+	}
+	g.goVersion = minor
+	return nil
+}
+
+// parseGoVersion returns the minor version of a Go release, or 0 for an
+// empty version.
+func parseGoVersion(version string) (int, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "go")
+	if version == "" {
+		return 0, nil
+	}
+	parts := strings.Split(version, ".")
+	minor := -1
+	if len(parts) >= 2 && parts[0] == "1" {
+		if n, err := strconv.Atoi(parts[1]); err == nil {
+			minor = n
+		}
+	}
+	if minor < 0 {
+		return 0, fmt.Errorf("invalid Go version %q, expected 1.N", version)
+	}
+	if minor < minGoVersion {
+		return 0, fmt.Errorf("Go version %s is not supported, the generated code requires Go 1.%d or later", version, minGoVersion)
+	}
+	return minor, nil
+}
+
+// goVersionAtLeast reports whether the generated code may use the features
+// of Go 1.minor.
+func (g *GoWSDL) goVersionAtLeast(minor int) bool {
+	return g.goVersion == 0 || g.goVersion >= minor
+}
+
+// emptyInterface returns the spelling of the empty interface in the
+// generated code.
+func (g *GoWSDL) emptyInterface() string {
+	if g.goVersion >= genericsGoVersion {
+		return "any"
+	}
+	return "interface{}"
+}
+
+// applyGoVersion disables the generation options that need a newer Go
+// release than the targeted one.
+func (g *GoWSDL) applyGoVersion() {
+	if g.goVersionAtLeast(genericsGoVersion) {
+		return
+	}
+	for _, option := range []struct {
+		name    string
+		enabled *bool
+	}{
+		{"async methods", &g.generateAsync},
+		{"batch methods", &g.generateBatch},
+		{"pointer helpers", &g.generatePtrHelpers},
+		{"nullable fields", &g.generateNullable},
+	} {
+		if *option.enabled {
+			log.Printf("[WARN] The %s need generics, which Go 1.%d lacks, and are not generated", option.name, g.goVersion)
+			*option.enabled = false
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+)
+
+func TestParseGoVersion(t *testing.T) {
+	for version, expected := range map[string]int{
+		"":          0,
+		"1.17":      17,
+		"go1.21.3":  21,
+		" 1.13 ":    13,
+		"1.22rc1":   -1,
+		"2.0":       -1,
+		"1.12":      -1,
+		"latest":    -1,
+		"go1.18.10": 18,
+	} {
+		minor, err := parseGoVersion(version)
+		if expected < 0 {
+			if err == nil {
+				t.Errorf("%q should be rejected", version)
+			}
+			continue
+		}
+		if err != nil || minor != expected {
+			t.Errorf("%q: got %d, %v, want %d", version, minor, err, expected)
+		}
+	}
+}
+
+func TestGoVersion(t *testing.T) {
+	generate := func(version string) string {
+		g, err := NewGoWSDL("fixtures/simpleparts.wsdl", "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.SetGoVersion(version); err != nil {
+			t.Fatal(err)
+		}
+		g.SetGenerateAsync(true)
+		g.SetGeneratePointerHelpers(true)
+
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		source, err := format.Source(append(append(append(resp["header"], resp["types"]...), resp["operations"]...), resp["soap"]...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(source)
+	}
+
+	code := generate("1.17")
+	for _, expected := range []string{
+		"response := new(struct {\n\t\tXMLName xml.Name\n\t\tValue   string `xml:\",chardata\"`\n\t})",
+		"func (s *SOAPClient) AddHeader(header interface{}) {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q for Go 1.17 in:\n%s", expected, code)
+		}
+	}
+	for _, unexpected := range []string{"xmlValue", "AsyncResult", "func Ptr[", "[T any]"} {
+		if strings.Contains(code, unexpected) {
+			t.Errorf("%q needs generics, which Go 1.17 lacks", unexpected)
+		}
+	}
+
+	code = generate("1.18")
+	for _, expected := range []string{
+		"response := new(xmlValue[string])",
+		"type AsyncResult[T any] struct {",
+		"func (s *SOAPClient) AddHeader(header any) {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q for Go 1.18 in:\n%s", expected, code)
+		}
+	}
+	if strings.Contains(code, "interface{}") {
+		t.Error("any should replace interface{} from Go 1.18 on")
+	}
+}
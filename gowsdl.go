@@ -5,37 +5,136 @@
 package gowsdl
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/tls"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"go/token"
+	"io"
 	"io/ioutil"
 	"log"
+	"mime"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
+	"unicode"
 )
 
-const maxRecursion uint8 = 100
+const maxRecursion = 100
+
+// OmitEmptyPolicy controls when ",omitempty" is added to generated XML tags.
+type OmitEmptyPolicy string
+
+const (
+	// OmitEmptyAll adds ",omitempty" to every element and attribute.
+	OmitEmptyAll OmitEmptyPolicy = "all"
+	// OmitEmptyOptional adds ",omitempty" only to elements with minOccurs="0"
+	// and to attributes not declared use="required".
+	OmitEmptyOptional OmitEmptyPolicy = "optional"
+	// OmitEmptyNever never adds ",omitempty", so zero values are always sent.
+	OmitEmptyNever OmitEmptyPolicy = "never"
+)
+
+// RedirectAuthPolicy controls whether basic auth credentials and download
+// headers are sent again when a WSDL or XSD download is redirected.
+type RedirectAuthPolicy string
+
+const (
+	// RedirectAuthSameHost re-sends them to redirect targets on the same
+	// host, unless the redirect downgrades HTTPS to HTTP.
+	RedirectAuthSameHost RedirectAuthPolicy = "same-host"
+	// RedirectAuthAlways re-sends them to any redirect target.
+	RedirectAuthAlways RedirectAuthPolicy = "always"
+	// RedirectAuthNever only sends them with the first request.
+	RedirectAuthNever RedirectAuthPolicy = "never"
+)
 
 // GoWSDL defines the struct for WSDL generator.
 type GoWSDL struct {
-	loc                   *Location
-	pkg                   string
-	ignoreTLS             bool
-	ignoreTypeNs          bool
-	auth                  *basicAuth
-	exportAllTypes        bool
-	wsdl                  *WSDL
-	resolvedXSDExternals  map[string]bool
-	currentRecursionLevel uint8
-	tmplFuncs             *tmplFunctions
+	loc                  *Location
+	pkg                  string
+	ignoreTLS            bool
+	ignoreTypeNs         bool
+	typeNsPrefixes       map[string]string
+	typeNames            *TypeNameTransforms
+	typeXMLNames         map[*XSDComplexType]string
+	elementTypeSuffix    string
+	elementTypeNames     map[*XSDElement]string
+	elementRefTypes      map[*XSDElement]string
+	operationNames       map[*WSDLOperation]string
+	operationNaming      *template.Template
+	auth                 *basicAuth
+	hostAuth             hostCredentials
+	negotiate            NegotiateProvider
+	proxy                *url.URL
+	proxyAuth            *url.Userinfo
+	downloadHeaders      http.Header
+	redirectAuth         RedirectAuthPolicy
+	cache                *downloadCache
+	exportTypes          bool
+	exportFields         bool
+	exportEnums          bool
+	generateBuilders     bool
+	generatePtrHelpers   bool
+	generateNullable     bool
+	flattenArrays        bool
+	unwrapMessages       bool
+	streamBase64         bool
+	usesHexBinary        atomic.Bool
+	usesWhiteSpace       atomic.Bool
+	usesIDs              atomic.Bool
+	usesLists            atomic.Bool
+	typeMappings         map[string]typeMapping
+	anyType              AnyTypeMapping
+	usesRawXML           atomic.Bool
+	typeImports          map[string]bool
+	typeImportsMu        sync.Mutex
+	omitEmpty            OmitEmptyPolicy
+	generateClone        bool
+	generateEqual        bool
+	generateStringer     bool
+	generateEnumHelpers  bool
+	generateAsync        bool
+	generateBatch        bool
+	generateTestServer   bool
+	generateInterfaces   bool
+	generateCaching      bool
+	generateLogging      bool
+	generateVCR          bool
+	generateExamples     bool
+	generatePackageDoc   bool
+	fileHeader           string
+	goVersion            int
+	wsdl                 *WSDL
+	resolvedXSDExternals map[string]bool
+	schemaLocations      map[string]*Location
+	schemaAllowList      []string
+	schemaDenyList       []string
+	downloadLimits       DownloadLimits
+	safeDownloads        bool
+	progressFunc         func(Progress)
+	progressMu           sync.Mutex
+	readSchemas          int
+	readBytes            int64
+	renderCache          string
+	renderKeys           *renderKeys
+	wsdlIndex            *wsdlIndex
+	indexOnce            sync.Once
+	tmplFuncs            *tmplFunctions
+	customFuncs          template.FuncMap
+	fetchers             map[string]Fetcher
+	compat               *CompatMappings
+	sharedDocs           *documentCache
+	templates            *templateSet
 }
 
 var cacheDir = filepath.Join(os.TempDir(), "gowsdl-cache")
@@ -54,93 +153,594 @@ func dialTimeout(network, addr string) (net.Conn, error) {
 	return net.DialTimeout(network, addr, timeout)
 }
 
-func downloadFile(url string, ignoreTLS bool, auth *basicAuth) ([]byte, error) {
+// downloadOptions configure the requests downloading WSDL and XSD files.
+type downloadOptions struct {
+	ignoreTLS    bool
+	auth         *basicAuth
+	hostAuth     hostCredentials
+	negotiate    NegotiateProvider
+	proxy        *url.URL
+	headers      http.Header
+	redirectAuth RedirectAuthPolicy
+	cache        *downloadCache
+	maxSize      int64
+	safe         bool
+}
+
+// downloadFile downloads rawURL, following redirects, and returns the data
+// with the URL it was eventually read from.
+func downloadFile(rawURL string, opts downloadOptions) ([]byte, *url.URL, error) {
+	body, u, err := openDownload(rawURL, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, u, nil
+}
+
+// openDownload requests rawURL, following redirects, and returns the body
+// with the URL it is eventually read from. The body is checked against the
+// size limit and cached as it is read, so that large documents are not held
+// in memory.
+func openDownload(rawURL string, opts downloadOptions) (io.ReadCloser, *url.URL, error) {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: ignoreTLS,
+			InsecureSkipVerify: opts.ignoreTLS,
 		},
 		Dial: dialTimeout,
 	}
-	client := &http.Client{Transport: tr}
+	if opts.proxy != nil {
+		tr.Proxy = http.ProxyURL(opts.proxy)
+	}
+	if opts.safe {
+		tr.Dial = safeDial(opts.proxy)
+	}
+	client := &http.Client{
+		Transport: tr,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if opts.safe {
+				if err := checkSafeScheme(via[len(via)-1].URL, req.URL); err != nil {
+					return err
+				}
+				if opts.proxy != nil {
+					if err := checkSafeHost(req.URL); err != nil {
+						return err
+					}
+				}
+			}
+			log.Println("[INFO] Following redirect to", req.URL)
+			// Headers of the first request are copied, less Authorization
+			// on other hosts; set them again according to the policy.
+			req.Header.Del("Authorization")
+			for key := range opts.headers {
+				req.Header.Del(key)
+			}
+			if opts.resendAuth(via[0].URL, req.URL) {
+				return opts.setHeaders(req)
+			}
+			return nil
+		},
+	}
 
-	req, _ := http.NewRequest("GET", url, nil)
-	if auth != nil {
-		req.SetBasicAuth(auth.Login, auth.Password)
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.safe && opts.proxy != nil {
+		if err := checkSafeHost(req.URL); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := opts.setHeaders(req); err != nil {
+		return nil, nil, err
+	}
+	var (
+		cached     *cacheEntry
+		cachedData []byte
+	)
+	if opts.cache != nil {
+		if cached, cachedData = opts.cache.load(rawURL); cached != nil {
+			cached.setValidators(req)
+		}
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		log.Println("[INFO] Not modified, using the cached", "file", rawURL)
+		return ioutil.NopCloser(bytes.NewReader(cachedData)), resp.Request.URL, nil
+	}
+	// The beginning of the body tells error pages apart before the rest is
+	// read.
+	br := bufio.NewReaderSize(resp.Body, 4096)
+	head, _ := br.Peek(4096)
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("received response code %d", resp.StatusCode)
+		resp.Body.Close()
+		return nil, nil, downloadError(rawURL, resp, head, "unexpected response")
+	}
+	if !looksLikeXML(resp.Header.Get("Content-Type"), head) {
+		resp.Body.Close()
+		return nil, nil, downloadError(rawURL, resp, head, "response is not an XML document, a login or proxy page may have been returned")
+	}
+	body := &downloadBody{r: br, body: resp.Body, rawURL: rawURL, maxSize: opts.maxSize}
+	if opts.cache != nil {
+		if body.cache, err = opts.cache.writer(rawURL, resp); err != nil {
+			log.Println("[WARN] Download of", rawURL, "will not be cached:", err)
+		}
 	}
 
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	return body, resp.Request.URL, nil
+}
+
+// downloadBody is the body of a download, which fails once it exceeds the
+// size limit and is cached once read to the end.
+type downloadBody struct {
+	r       io.Reader
+	body    io.Closer
+	cache   *cacheWriter
+	rawURL  string
+	maxSize int64
+	read    int64
+}
+
+func (b *downloadBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.maxSize > 0 && b.read > b.maxSize {
+		return n, fmt.Errorf("%s is larger than the limit of %d bytes", b.rawURL, b.maxSize)
+	}
+	if b.cache != nil && n > 0 {
+		if _, werr := b.cache.Write(p[:n]); werr != nil {
+			log.Println("[WARN] Download of", b.rawURL, "has not been cached:", werr)
+			b.cache.discard()
+			b.cache = nil
+		}
+	}
+	if err == io.EOF && b.cache != nil {
+		if cerr := b.cache.commit(); cerr != nil {
+			log.Println("[WARN] Download of", b.rawURL, "has not been cached:", cerr)
+		}
+		b.cache = nil
+	}
+	return n, err
+}
+
+// Close closes the body, dropping it from the cache unless it was read to
+// the end.
+func (b *downloadBody) Close() error {
+	if b.cache != nil {
+		b.cache.discard()
+		b.cache = nil
+	}
+	return b.body.Close()
+}
+
+func (o downloadOptions) setHeaders(req *http.Request) error {
+	for key, values := range o.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if o.negotiate != nil {
+		token, err := o.negotiate.NegotiateToken(req.URL.Hostname())
+		if err != nil {
+			return fmt.Errorf("negotiating with %s: %w", req.URL.Host, err)
+		}
+		req.Header.Set("Authorization", "Negotiate "+token)
+		return nil
+	}
+	auth := o.auth
+	if auth == nil {
+		auth = o.hostAuth.lookup(req.URL.Host)
+	}
+	if auth != nil {
+		req.SetBasicAuth(auth.Login, auth.Password)
+	}
+	return nil
+}
+
+// resendAuth reports whether credentials sent to from may be sent to the
+// redirect target to.
+func (o downloadOptions) resendAuth(from, to *url.URL) bool {
+	switch o.redirectAuth {
+	case RedirectAuthAlways:
+		return true
+	case RedirectAuthNever:
+		return false
+	}
+	return from.Host == to.Host && !(from.Scheme == "https" && to.Scheme == "http")
+}
+
+// looksLikeXML reports whether a downloaded document may be XML: HTML
+// content types and bodies not starting with a tag, or starting with an
+// html one, are rejected.
+func looksLikeXML(contentType string, body []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+		(mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+		return false
+	}
+	body = bytes.TrimLeft(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(body) > 14 {
+		body = body[:14]
 	}
+	prefix := strings.ToLower(string(body))
+	return strings.HasPrefix(prefix, "<") &&
+		!strings.HasPrefix(prefix, "<!doctype html") && !strings.HasPrefix(prefix, "<html")
+}
 
-	return data, nil
+// downloadError describes a failed download with its status, content type
+// and the beginning of the body.
+func downloadError(url string, resp *http.Response, body []byte, reason string) error {
+	snippet := []rune(strings.Join(strings.Fields(string(body)), " "))
+	if len(snippet) > 200 {
+		snippet = append(snippet[:200], '…')
+	}
+	return fmt.Errorf("downloading %s: %s (status %q, content type %q): %s",
+		url, reason, resp.Status, resp.Header.Get("Content-Type"), string(snippet))
 }
 
-// NewGoWSDL initializes WSDL generator.
+// NewGoWSDL initializes WSDL generator. exportAllTypes exports the names of
+// the generated types and enumeration constants; struct fields are always
+// exported unless SetExportFields disables it, as encoding/xml ignores
+// unexported fields.
 func NewGoWSDL(file, pkg string, ignoreTLS bool, exportAllTypes bool) (*GoWSDL, error) {
 	file = strings.TrimSpace(file)
 	if file == "" {
 		return nil, errors.New("WSDL file is required to generate Go proxy")
 	}
 
-	pkg = strings.TrimSpace(pkg)
-	if pkg == "" {
-		pkg = "myservice"
-	}
+	pkg = packageName(pkg)
 
 	r, err := ParseLocation(file)
 	if err != nil {
 		return nil, err
 	}
 
+	parsed, err := parseTemplates()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := parsed.clone()
+	if err != nil {
+		return nil, err
+	}
+
 	return &GoWSDL{
-		loc:            r,
-		pkg:            pkg,
-		ignoreTLS:      ignoreTLS,
-		exportAllTypes: exportAllTypes,
+		templates:    templates,
+		loc:          r,
+		pkg:          pkg,
+		ignoreTLS:    ignoreTLS,
+		exportTypes:  exportAllTypes,
+		exportFields: true,
+		exportEnums:  exportAllTypes,
+		omitEmpty:    OmitEmptyAll,
+		anyType:      AnyTypeInterface,
+		redirectAuth: RedirectAuthSameHost,
 	}, nil
 }
 
+// packageName turns pkg into a valid Go package name, dropping the characters
+// identifiers cannot hold and renaming keywords and predeclared identifiers.
+func packageName(pkg string) string {
+	pkg = strings.TrimSpace(pkg)
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, pkg)
+	switch {
+	case name == "":
+		name = "myservice"
+	case unicode.IsDigit([]rune(name)[0]):
+		name = "pkg" + name
+	case token.IsKeyword(name) || predeclared[name]:
+		name += "_"
+	}
+	if pkg != "" && name != pkg {
+		log.Printf("[WARN] Package %q is generated as package %s", pkg, name)
+	}
+	return name
+}
+
 func (g *GoWSDL) SetBasicAuth(login, password string) {
 	g.auth = &basicAuth{Login: login, Password: password}
 }
 
+// AddDownloadHeader adds a header to the requests downloading the WSDL and
+// its schemas.
+func (g *GoWSDL) AddDownloadHeader(key, value string) {
+	if g.downloadHeaders == nil {
+		g.downloadHeaders = make(http.Header)
+	}
+	g.downloadHeaders.Add(key, value)
+}
+
+// SetSchemaLocations maps namespaces to the file path or URL of their
+// schema, which is loaded for the imports of a namespace without a schema
+// location unless a schema of the namespace is loaded already. Relative
+// file paths are relative to the working directory.
+func (g *GoWSDL) SetSchemaLocations(locations map[string]string) error {
+	g.schemaLocations = make(map[string]*Location, len(locations))
+	for namespace, location := range locations {
+		loc, err := ParseLocation(location)
+		if err != nil {
+			return fmt.Errorf("invalid schema location %q of %s: %v", location, namespace, err)
+		}
+		g.schemaLocations[namespace] = loc
+	}
+	return nil
+}
+
+// SetDownloadCache enables caching downloaded WSDL and XSD files, which are
+// then revalidated with conditional requests.
+func (g *GoWSDL) SetDownloadCache(enabled bool) {
+	g.cache = nil
+	if enabled {
+		g.cache = &downloadCache{dir: cacheDir}
+	}
+}
+
+// DownloadLimits bounds the documents read to generate code, so that large
+// or endless import graphs fail with an error. Zero values are unlimited.
+type DownloadLimits struct {
+	// Schemas is the number of external schemas that may be read, bundled
+	// ones aside.
+	Schemas int
+	// FileSize is the size in bytes of each WSDL or schema.
+	FileSize int64
+	// TotalSize is the size in bytes of the WSDL and the schemas together.
+	TotalSize int64
+}
+
+// SetDownloadLimits bounds the number and sizes of the WSDL and schemas read
+// from files or downloaded.
+func (g *GoWSDL) SetDownloadLimits(limits DownloadLimits) {
+	g.downloadLimits = limits
+}
+
+// SetRedirectAuthPolicy sets when basic auth credentials and download
+// headers are sent again to the target of a redirected download.
+func (g *GoWSDL) SetRedirectAuthPolicy(policy RedirectAuthPolicy) error {
+	switch policy {
+	case RedirectAuthSameHost, RedirectAuthAlways, RedirectAuthNever:
+		g.redirectAuth = policy
+		return nil
+	case "":
+		g.redirectAuth = RedirectAuthSameHost
+		return nil
+	}
+	return fmt.Errorf("unknown redirect auth policy %q", policy)
+}
+
+// SetProxy makes downloads go through the forward proxy at proxyURL, which
+// tunnels https ones with CONNECT. An empty proxyURL disables the proxy.
+func (g *GoWSDL) SetProxy(proxyURL string) error {
+	if proxyURL == "" {
+		g.proxy = nil
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q, expected scheme://host:port", proxyURL)
+	}
+	g.proxy = u
+	return nil
+}
+
+// SetProxyAuth sets the basic auth credentials sent to the proxy of
+// SetProxy as Proxy-Authorization, in place of those of the proxy URL. They
+// are kept apart from those of SetBasicAuth, which only reach the server.
+func (g *GoWSDL) SetProxyAuth(login, password string) {
+	g.proxyAuth = url.UserPassword(login, password)
+}
+
 func (g *GoWSDL) SetIgnoreTypeNamespaces(ignore bool) {
 	g.ignoreTypeNs = ignore
 }
 
+// SetExportTypes exports the names of the generated types and client methods,
+// or keeps them as declared in the WSDL.
+func (g *GoWSDL) SetExportTypes(export bool) {
+	g.exportTypes = export
+}
+
+// SetExportFields exports the names of the generated struct fields, or keeps
+// them as declared in the WSDL. encoding/xml ignores unexported fields.
+func (g *GoWSDL) SetExportFields(export bool) {
+	g.exportFields = export
+}
+
+// SetExportEnums exports the names of the generated enumeration constants,
+// or keeps them as declared in the WSDL.
+func (g *GoWSDL) SetExportEnums(export bool) {
+	g.exportEnums = export
+}
+
+// SetGenerateBuilders enables generation of fluent builders for complex types.
+func (g *GoWSDL) SetGenerateBuilders(generate bool) {
+	g.generateBuilders = generate
+}
+
+// SetGeneratePointerHelpers enables generation of the Ptr and Deref helpers.
+func (g *GoWSDL) SetGeneratePointerHelpers(generate bool) {
+	g.generatePtrHelpers = generate
+}
+
+// SetGenerateNullable makes nillable elements of built-in types use the
+// generic Nullable wrapper instead of plain values.
+func (g *GoWSDL) SetGenerateNullable(generate bool) {
+	g.generateNullable = generate
+}
+
+// SetFlattenArrays makes the elements of ArrayOf wrapper types, complex
+// types holding nothing but a repeated element, slices of the type of that
+// element with nested XML tags, such as Lines []*Line `xml:"lines>Line"`.
+// The wrapper types are still generated for their other uses.
+func (g *GoWSDL) SetFlattenArrays(flatten bool) {
+	g.flattenArrays = flatten
+}
+
+// SetUnwrapMessages makes the operations whose request or response element
+// holds a single element take or return the value of that element, such as
+// GetUser(id string, opts ...CallOption) (*User, error), instead of the
+// wrapper. The wrappers are still sent and received.
+func (g *GoWSDL) SetUnwrapMessages(unwrap bool) {
+	g.unwrapMessages = unwrap
+}
+
+// SetStreamBase64 makes elements of type base64Binary use the Base64Stream
+// type, encoded from an io.Reader as they are marshaled and decoded as they
+// are read, instead of byte slices holding their whole decoded content.
+func (g *GoWSDL) SetStreamBase64(stream bool) {
+	g.streamBase64 = stream
+}
+
+// SetGenerateClone enables generation of deep-copy Clone methods.
+func (g *GoWSDL) SetGenerateClone(generate bool) {
+	g.generateClone = generate
+}
+
+// SetGenerateEqual enables generation of structural Equal methods.
+func (g *GoWSDL) SetGenerateEqual(generate bool) {
+	g.generateEqual = generate
+}
+
+// SetGenerateStringer enables generation of String methods and the Dump
+// helper for complex types.
+func (g *GoWSDL) SetGenerateStringer(generate bool) {
+	g.generateStringer = generate
+}
+
+// SetGenerateEnumHelpers enables generation of String, IsValid, ParseX and
+// AllXValues helpers for enumerations.
+func (g *GoWSDL) SetGenerateEnumHelpers(generate bool) {
+	g.generateEnumHelpers = generate
+}
+
+// SetGenerateAsync enables generation of FooAsync variants of operations
+// that run the call in a goroutine and deliver an AsyncResult on a channel.
+func (g *GoWSDL) SetGenerateAsync(generate bool) {
+	g.generateAsync = generate
+}
+
+// SetGenerateBatch enables generation of the CallBatch helper and FooBatch
+// operation variants that run many requests with bounded concurrency.
+func (g *GoWSDL) SetGenerateBatch(generate bool) {
+	g.generateBatch = generate
+}
+
+// SetGenerateInterfaces makes the client of each port type an interface,
+// returned by its constructors and implemented by an unexported struct, so
+// that callers can substitute mocks or decorators.
+func (g *GoWSDL) SetGenerateInterfaces(generate bool) {
+	g.generateInterfaces = generate
+}
+
+// SetGenerateCachingClients enables generation of a CachingFoo decorator per
+// port type interface, caching the responses of the operations given a TTL
+// in a pluggable Cache. It enables the interfaces of SetGenerateInterfaces.
+func (g *GoWSDL) SetGenerateCachingClients(generate bool) {
+	g.generateCaching = generate
+	if generate {
+		g.generateInterfaces = true
+	}
+}
+
+// SetGenerateLoggingClients enables generation of a LoggingFoo decorator per
+// port type interface, logging the duration, payload sizes and faults of the
+// operation calls. It enables the interfaces of SetGenerateInterfaces.
+func (g *GoWSDL) SetGenerateLoggingClients(generate bool) {
+	g.generateLogging = generate
+	if generate {
+		g.generateInterfaces = true
+	}
+}
+
+// SetGenerateTestServer enables generation of httptest-based FooTestServer
+// types that serve the operations of each client from handler funcs.
+func (g *GoWSDL) SetGenerateTestServer(generate bool) {
+	g.generateTestServer = generate
+}
+
+// SetGenerateVCR enables generation of VCRTransport, an http.RoundTripper
+// that records SOAP exchanges to files and replays them in tests.
+func (g *GoWSDL) SetGenerateVCR(generate bool) {
+	g.generateVCR = generate
+}
+
+// SetGenerateExamples enables generation of an examples_test.go file with an
+// Example function per generated operation.
+func (g *GoWSDL) SetGenerateExamples(generate bool) {
+	g.generateExamples = generate
+}
+
+// SetGeneratePackageDoc enables generation of a doc.go file documenting the
+// package with an overview of the services: their endpoints and operations,
+// summarized from the documentation of the WSDL.
+func (g *GoWSDL) SetGeneratePackageDoc(generate bool) {
+	g.generatePackageDoc = generate
+}
+
+// SetFileHeader sets the comments placed above the package clause of every
+// generated file, such as a license banner, //go:build constraints or
+// linter directives. Lines not starting with // are turned into comments.
+func (g *GoWSDL) SetFileHeader(header string) {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(header, "\r\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line != "" && !strings.HasPrefix(line, "//") {
+			line = "// " + line
+		}
+		lines = append(lines, line)
+	}
+	g.fileHeader = strings.Join(lines, "\n")
+}
+
+// SetOmitEmptyPolicy controls when ",omitempty" is added to generated XML tags.
+func (g *GoWSDL) SetOmitEmptyPolicy(policy OmitEmptyPolicy) error {
+	switch policy {
+	case OmitEmptyAll, OmitEmptyOptional, OmitEmptyNever:
+		g.omitEmpty = policy
+		return nil
+	case "":
+		g.omitEmpty = OmitEmptyAll
+		return nil
+	}
+	return fmt.Errorf("unknown omitempty policy %q", policy)
+}
+
 // Start initiates the code generation process by starting two goroutines: one
-// to generate types and another one to generate operations.
+// to generate types and another one to generate operations. With a render
+// cache, they are generated one after the other instead.
 func (g *GoWSDL) Start() (map[string][]byte, error) {
 	gocode := make(map[string][]byte)
 
-	err := g.unmarshal()
+	g.applyGoVersion()
+	err := g.load()
 	if err != nil {
 		return nil, err
 	}
 
-	g.refineRawWsdlData()
-
-	// Process WSDL nodes
-	for _, schema := range g.wsdl.Types.Schemas {
-		newTraverser(schema, g.wsdl.Types.Schemas).traverse()
-	}
-
-	g.tmplFuncs = createTmplFunctions(g)
+	g.reportPolicies()
+	g.reportShadowedFields()
 
+	g.renderKeys = g.newRenderKeys()
 	var wg sync.WaitGroup
 
 	wg.Add(1)
-	go func() {
+	genTypes := func() {
 		defer wg.Done()
 		var err error
 
@@ -148,7 +748,12 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 		if err != nil {
 			log.Println("genTypes", "error", err)
 		}
-	}()
+	}
+	if g.renderKeys != nil {
+		genTypes()
+	} else {
+		go genTypes()
+	}
 
 	wg.Add(1)
 	go func() {
@@ -163,6 +768,11 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 
 	wg.Wait()
 
+	gocode["compat"], gocode["operations"], err = g.genCompat(gocode["types"], gocode["operations"])
+	if err != nil {
+		return nil, err
+	}
+
 	gocode["header"], err = g.genHeader()
 	if err != nil {
 		log.Println(err)
@@ -173,68 +783,247 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 		log.Println(err)
 	}
 
+	if g.generateExamples {
+		gocode["examples"], err = g.genExamples()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	if g.generatePackageDoc {
+		gocode["doc"], err = g.genPackageDoc()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
 	return gocode, nil
 }
 
-func (g *GoWSDL) fetchFile(loc *Location) (data []byte, err error) {
+// load reads and prepares the WSDL unless that was done already.
+func (g *GoWSDL) load() error {
+	if g.wsdl != nil {
+		return nil
+	}
+
+	err := g.unmarshal()
+	if err != nil {
+		return err
+	}
+	g.prepare()
+	return nil
+}
+
+// prepare refines the unmarshaled WSDL, names its types and operations and
+// resolves the references between its schemas.
+func (g *GoWSDL) prepare() {
+	g.refineRawWsdlData()
+	g.disambiguateElements()
+	g.disambiguateOperations()
+
+	// Process WSDL nodes
+	g.reportProgress(PhaseTraverse, "")
+	for _, schema := range g.wsdl.Types.Schemas {
+		newTraverser(schema, g.wsdl.Types.Schemas).traverse()
+	}
+
+	g.tmplFuncs = createTmplFunctions(g)
+	g.templates.bind(g.tmplFuncs.funcMap)
+	g.templates.bind(g.customFuncs)
+}
+
+// fetchFile reads loc and returns its data with the location it was read
+// from, which differs from loc after redirects.
+func (g *GoWSDL) fetchFile(loc *Location) (data []byte, resolved *Location, err error) {
+	r, resolved, err := g.openFile(loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	if data, err = ioutil.ReadAll(r); err != nil {
+		return nil, nil, err
+	}
+	return data, resolved, nil
+}
+
+// openFile opens loc for reading and returns it with the location it is read
+// from, which differs from loc after redirects. Reading fails once the size
+// limits are exceeded.
+func (g *GoWSDL) openFile(loc *Location) (r io.ReadCloser, resolved *Location, err error) {
+	g.reportProgress(PhaseFetch, loc.String())
+	// Files and downloads are limited in size already.
+	var maxSize int64
 	if loc.f != "" {
 		log.Println("[INFO] Reading", "file", loc.f)
-		data, err = ioutil.ReadFile(loc.f)
+		if max := g.downloadLimits.FileSize; max > 0 {
+			if info, err := os.Stat(loc.f); err == nil && info.Size() > max {
+				return nil, nil, fmt.Errorf("%s is larger than the limit of %d bytes", loc.f, max)
+			}
+		}
+		if r, err = os.Open(loc.f); err != nil {
+			return nil, nil, err
+		}
+		resolved = loc
+	} else if fetcher := g.fetcher(loc); fetcher != nil {
+		log.Println("[INFO] Fetching", "file", loc.u.String())
+		if r, err = fetcher.Fetch(loc); err != nil {
+			return nil, nil, err
+		}
+		resolved = loc
+		maxSize = g.downloadLimits.FileSize
 	} else {
-		log.Println("[INFO] Downloading", "file", loc.u.String())
-		data, err = downloadFile(loc.u.String(), g.ignoreTLS, g.auth)
+		opts := g.downloadOptions(g.cache)
+		key := documentKey(loc.u.String(), opts)
+		if doc, ok := g.sharedDocs.get(key); ok {
+			log.Println("[INFO] Reading the downloaded copy of", "file", loc.u.String())
+			r = ioutil.NopCloser(bytes.NewReader(doc.data))
+			resolved = &Location{u: doc.resolved}
+			maxSize = g.downloadLimits.FileSize
+		} else {
+			log.Println("[INFO] Downloading", "file", loc.u.String())
+			var u *url.URL
+			if r, u, err = openDownload(loc.u.String(), opts); err != nil {
+				return nil, nil, err
+			}
+			r = g.sharedDocs.tee(key, r, u)
+			resolved = &Location{u: u}
+		}
 	}
-	return
+	return &countingReader{ReadCloser: r, g: g, loc: loc, maxSize: maxSize}, resolved, nil
+}
+
+// countingReader adds the bytes read from a WSDL or schema to those read
+// in total, failing once they exceed the limit or once the document exceeds
+// maxSize, if any.
+type countingReader struct {
+	io.ReadCloser
+	g       *GoWSDL
+	loc     *Location
+	read    int64
+	maxSize int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if r.maxSize > 0 && r.read > r.maxSize {
+		return n, fmt.Errorf("%s is larger than the limit of %d bytes", r.loc, r.maxSize)
+	}
+	r.g.readBytes += int64(n)
+	if max := r.g.downloadLimits.TotalSize; max > 0 && r.g.readBytes > max {
+		return n, fmt.Errorf("the WSDL and its schemas are larger than the limit of %d bytes in total, exceeded by %s", max, r.loc)
+	}
+	return n, err
+}
+
+func (g *GoWSDL) downloadOptions(cache *downloadCache) downloadOptions {
+	proxy := g.proxy
+	if proxy != nil && g.proxyAuth != nil {
+		withAuth := *proxy
+		withAuth.User = g.proxyAuth
+		proxy = &withAuth
+	}
+	return downloadOptions{
+		ignoreTLS:    g.ignoreTLS,
+		auth:         g.auth,
+		hostAuth:     g.hostAuth,
+		negotiate:    g.negotiate,
+		proxy:        proxy,
+		headers:      g.downloadHeaders,
+		redirectAuth: g.redirectAuth,
+		cache:        cache,
+		maxSize:      g.downloadLimits.FileSize,
+		safe:         g.safeDownloads,
+	}
+}
+
+// UpstreamChanged reports whether the WSDL at the generator's URL differs
+// from the copy cached when it was last downloaded, revalidating the copy
+// without updating it. It reports true when no copy is cached, and requires
+// the download cache.
+func (g *GoWSDL) UpstreamChanged() (bool, error) {
+	if g.cache == nil {
+		return false, errors.New("the download cache is not enabled")
+	}
+	if !g.loc.IsURL() {
+		return false, fmt.Errorf("%s is not a URL", g.loc)
+	}
+	rawURL := g.loc.u.String()
+	_, cachedData := g.cache.load(rawURL)
+	if cachedData == nil {
+		return true, nil
+	}
+	data, _, err := downloadFile(rawURL, g.downloadOptions(&downloadCache{dir: g.cache.dir, readOnly: true}))
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(data, cachedData), nil
 }
 
 func (g *GoWSDL) unmarshal() error {
-	data, err := g.fetchFile(g.loc)
+	g.readSchemas, g.readBytes = 0, 0
+	// Relative schema locations are resolved against the redirect target.
+	data, base, err := g.fetchFile(g.loc)
 	if err != nil {
 		return err
 	}
 
+	g.reportProgress(PhaseParse, base.String())
 	g.wsdl = new(WSDL)
 	if err = xml.Unmarshal(data, g.wsdl); err != nil {
 		return err
 	}
 
-	g.resolvedXSDExternals = make(map[string]bool, maxRecursion)
+	g.resolvedXSDExternals = make(map[string]bool)
 	for _, schema := range g.wsdl.Types.Schemas {
-		if err = g.resolveXSDExternals(schema, g.loc); err != nil {
+		if err = g.resolveXSDExternals(schema, base, nil); err != nil {
 			return err
 		}
 	}
+	g.warnUnresolvedImports()
 
 	return nil
 }
 
-func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
-	if schema == nil || loc == nil {
-		return nil
+// warnUnresolvedImports warns about the imports without a schema location
+// whose namespace is declared by none of the inline or downloaded schemas.
+func (g *GoWSDL) warnUnresolvedImports() {
+	namespaces := make(map[string]bool)
+	for _, schema := range g.wsdl.Types.Schemas {
+		namespaces[schema.TargetNamespace] = true
+	}
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, impt := range schema.Imports {
+			if impt.SchemaLocation == "" && !namespaces[impt.Namespace] {
+				log.Printf("[WARN] Don't know where to find XSD for %s", impt.Namespace)
+				namespaces[impt.Namespace] = true
+			}
+		}
 	}
+}
 
-	g.currentRecursionLevel++
-	if g.currentRecursionLevel > maxRecursion {
+// resolveXSDExternals downloads the schemas imported or included by schema,
+// recursively. path holds the locations of the schemas being resolved on
+// the way from the WSDL down to schema, so that an import cycle is reported
+// instead of followed.
+func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location, path []string) error {
+	if schema == nil || loc == nil {
 		return nil
 	}
 
 	currentSchemaKey := loc.String()
-	if g.resolvedXSDExternals[currentSchemaKey] {
-		return nil
+	if len(path) >= maxRecursion {
+		return fmt.Errorf("schema imports nested deeper than %d levels at %s", maxRecursion, currentSchemaKey)
 	}
-	g.resolvedXSDExternals[currentSchemaKey] = true
+	path = append(path[:len(path):len(path)], currentSchemaKey)
 
 	log.Printf("[INFO] Resolving external XSDs for Schema %s", currentSchemaKey)
+	g.reportProgress(PhaseResolveImports, currentSchemaKey)
 
-	handleExternalSchema := func(base *Location, schemaLoc string) error {
-		var (
-			newSchema    *XSDSchema
-			newSchemaLoc *Location
-			err          error
-		)
-		if newSchema, newSchemaLoc, err = g.downloadSchemaIfRequired(loc, schemaLoc); err == nil && newSchema != nil {
+	handleSchema := func(newSchema *XSDSchema, newSchemaLoc *Location, err error) error {
+		if err == nil && newSchema != nil {
 			g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, newSchema)
-			err = g.resolveXSDExternals(newSchema, newSchemaLoc)
+			err = g.resolveXSDExternals(newSchema, newSchemaLoc, path)
 		}
 		return err
 	}
@@ -245,10 +1034,15 @@ func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
 			break
 		}
 		if impt.SchemaLocation == "" {
-			log.Printf("[WARN] Don't know where to find XSD for %s", impt.Namespace)
+			// Namespaces loaded by no schema are taken from the known
+			// locations, or reported by warnUnresolvedImports.
+			if registered := g.schemaLocations[impt.Namespace]; registered != nil && !g.namespaceLoaded(impt.Namespace) {
+				log.Printf("[INFO] Loading XSD for %s from its known location %s", impt.Namespace, registered)
+				err = handleSchema(g.loadSchemaIfRequired(registered, impt.Namespace, path))
+			}
 			continue
 		}
-		err = handleExternalSchema(loc, impt.SchemaLocation)
+		err = handleSchema(g.downloadSchemaIfRequired(loc, impt.SchemaLocation, impt.Namespace, path))
 	}
 	for _, incl := range schema.Includes {
 		if err != nil {
@@ -257,30 +1051,100 @@ func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
 		if incl.SchemaLocation == "" {
 			continue
 		}
-		err = handleExternalSchema(loc, incl.SchemaLocation)
+		err = handleSchema(g.downloadSchemaIfRequired(loc, incl.SchemaLocation, schema.TargetNamespace, path))
 	}
 	return err
 }
 
+// namespaceLoaded reports whether a loaded schema targets namespace.
+func (g *GoWSDL) namespaceLoaded(namespace string) bool {
+	for _, schema := range g.wsdl.Types.Schemas {
+		if schema.TargetNamespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// importCycle returns an error listing the cycle when key is already on
+// the import path.
+func importCycle(path []string, key string) error {
+	for i, p := range path {
+		if p == key {
+			return fmt.Errorf("schema import cycle: %s", strings.Join(append(path[i:len(path):len(path)], key), " -> "))
+		}
+	}
+	return nil
+}
+
 func (g *GoWSDL) downloadSchemaIfRequired(base *Location,
-	locationRef string) (newSchema *XSDSchema,
+	locationRef, namespace string, path []string) (newSchema *XSDSchema,
 	newSchemaLoc *Location,
 	err error) {
 	if newSchemaLoc, err = base.Parse(locationRef); err != nil {
 		return
 	}
+	if _, bundled := bundledSchema(newSchemaLoc); g.safeDownloads && !bundled && g.fetcher(newSchemaLoc) == nil {
+		if err = checkSafeSchemaLocation(base, newSchemaLoc); err != nil {
+			return nil, nil, err
+		}
+	}
+	return g.loadSchemaIfRequired(newSchemaLoc, namespace, path)
+}
+
+// loadSchemaIfRequired reads the schema of namespace at loc unless it was
+// read already or may not be downloaded.
+func (g *GoWSDL) loadSchemaIfRequired(loc *Location, namespace string, path []string) (newSchema *XSDSchema,
+	newSchemaLoc *Location,
+	err error) {
+	newSchemaLoc = loc
 	schemaKey := newSchemaLoc.String()
+	if err = importCycle(path, schemaKey); err != nil {
+		return
+	}
 	if g.resolvedXSDExternals[schemaKey] {
 		return
 	}
+	g.resolvedXSDExternals[schemaKey] = true
+
+	if data, ok := bundledSchema(newSchemaLoc); ok {
+		log.Printf("[INFO] Reading the bundled copy of %s", schemaKey)
+		newSchema, err = decodeSchema(bytes.NewReader(data), schemaKey)
+		return
+	}
+
+	allowed, err := g.schemaDownloadAllowed(newSchemaLoc, namespace)
+	if !allowed {
+		return nil, nil, err
+	}
+	g.readSchemas++
+	if max := g.downloadLimits.Schemas; max > 0 && g.readSchemas > max {
+		return nil, nil, fmt.Errorf("more than %d external schemas are imported, %s exceeds the limit", max, schemaKey)
+	}
 
-	var data []byte
-	if data, err = g.fetchFile(newSchemaLoc); err != nil {
+	var (
+		r        io.ReadCloser
+		resolved *Location
+	)
+	if r, resolved, err = g.openFile(newSchemaLoc); err != nil {
 		return
 	}
+	defer r.Close()
+	if resolved.String() != schemaKey {
+		// Redirected: the schema is resolved under its final location.
+		newSchemaLoc = resolved
+		schemaKey = resolved.String()
+		if err = importCycle(path, schemaKey); err != nil {
+			return
+		}
+		if g.resolvedXSDExternals[schemaKey] {
+			return nil, nil, nil
+		}
+		g.resolvedXSDExternals[schemaKey] = true
+	}
 
-	newSchema = new(XSDSchema)
-	if err = xml.Unmarshal(data, newSchema); err != nil {
+	g.reportProgress(PhaseParse, schemaKey)
+	if newSchema, err = decodeSchema(r, schemaKey); err != nil {
 		return
 	}
 
@@ -289,14 +1153,141 @@ func (g *GoWSDL) downloadSchemaIfRequired(base *Location,
 	return
 }
 
+// decodeSchema decodes the schema read from r as it is read, failing as soon
+// as the document at loc turns out not to be a well-formed schema. The rest
+// of r is read too, so that the whole document is counted and cached.
+func decodeSchema(r io.Reader, loc string) (*XSDSchema, error) {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "schema" {
+			return nil, fmt.Errorf("%s is not an XML schema, its root element is %s", loc, start.Name.Local)
+		}
+		schema := new(XSDSchema)
+		if err := d.DecodeElement(schema, &start); err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(ioutil.Discard, r); err != nil {
+			return nil, err
+		}
+		return schema, nil
+	}
+}
+
 func (g *GoWSDL) refineRawWsdlData() {
-	g.wsdl.refine(g.ignoreTypeNs)
+	if len(g.typeNsPrefixes) > 0 || g.typeNames != nil {
+		// Types are told apart by their prefixed names.
+		g.typeXMLNames = g.renameTypes()
+	}
+	g.wsdl.refine(g.ignoreTypeNs || len(g.typeNsPrefixes) > 0)
+}
+
+// templateSet holds the templates of the generated code.
+type templateSet struct {
+	types      *template.Template
+	operations *template.Template
+	header     *template.Template
+	examples   *template.Template
+	doc        *template.Template
+	soap       *template.Template
+}
+
+var (
+	parsedTemplatesOnce sync.Once
+	parsedTemplates     *templateSet
+	parsedTemplatesErr  error
+)
+
+// parseTemplates parses the templates of the generated code once, with
+// stand-ins of the template functions, which generators replace with their
+// own in their copies.
+func parseTemplates() (*templateSet, error) {
+	parsedTemplatesOnce.Do(func() {
+		funcs := createTmplFunctions(&GoWSDL{wsdl: new(WSDL)}).funcMap
+		set := new(templateSet)
+		for _, t := range []struct {
+			tmpl       **template.Template
+			name, text string
+		}{
+			{&set.types, "types", typesTmpl},
+			{&set.operations, "operations", opsTmpl},
+			{&set.header, "header", headerTmpl},
+			{&set.examples, "examples", examplesTmpl},
+			{&set.doc, "doc", docTmpl},
+			{&set.soap, "soapclient", soapTmpl},
+		} {
+			tmpl, err := template.New(t.name).Funcs(funcs).Parse(t.text)
+			if err != nil {
+				parsedTemplatesErr = fmt.Errorf("parsing the %s template: %w", t.name, err)
+				return
+			}
+			*t.tmpl = tmpl
+		}
+		parsedTemplates = set
+	})
+	return parsedTemplates, parsedTemplatesErr
+}
+
+// clone returns a copy of the templates, whose functions may be replaced.
+func (s *templateSet) clone() (*templateSet, error) {
+	c := new(templateSet)
+	for _, t := range []struct {
+		from *template.Template
+		to   **template.Template
+	}{
+		{s.types, &c.types},
+		{s.operations, &c.operations},
+		{s.header, &c.header},
+		{s.examples, &c.examples},
+		{s.doc, &c.doc},
+		{s.soap, &c.soap},
+	} {
+		tmpl, err := t.from.Clone()
+		if err != nil {
+			return nil, err
+		}
+		*t.to = tmpl
+	}
+	return c, nil
+}
+
+// bind makes the templates call funcs.
+func (s *templateSet) bind(funcs template.FuncMap) {
+	for _, tmpl := range []*template.Template{s.types, s.operations, s.header, s.examples, s.doc, s.soap} {
+		tmpl.Funcs(funcs)
+	}
+}
+
+// ValidateTemplates reports errors in the templates the generation executes:
+// those of the generated code, parsed once and reused by every generator,
+// and the operation naming, executed with sample names. It lets callers
+// check them up front, without loading a WSDL.
+func (g *GoWSDL) ValidateTemplates() error {
+	if _, err := parseTemplates(); err != nil {
+		return err
+	}
+	if g.operationNaming != nil {
+		if err := g.operationNaming.Execute(ioutil.Discard, operationNameData{Port: "Port", Operation: "Operation"}); err != nil {
+			return fmt.Errorf("invalid operation naming: %v", err)
+		}
+	}
+	return nil
 }
 
 func (g *GoWSDL) genTypes() ([]byte, error) {
+	g.reportProgress(PhaseRender, "types")
 	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("types").
-		Funcs(g.tmplFuncs.funcMap).Parse(typesTmpl))
+	tmpl := g.templates.types
+	if g.renderKeys != nil {
+		return g.genCachedTypes(tmpl)
+	}
 	err := tmpl.Execute(data, g.wsdl.Types)
 	if err != nil {
 		return nil, err
@@ -305,11 +1296,237 @@ func (g *GoWSDL) genTypes() ([]byte, error) {
 	return data.Bytes(), nil
 }
 
+// portClient describes a generated client type: the operations of a port
+// type as exposed through one binding at one default address.
+type portClient struct {
+	Name     string
+	Address  string
+	Binding  string
+	PortType *WSDLPortType
+	// Policy holds the WS-Policy requirements of the binding, if any.
+	Policy *policyRequirements
+	// HTTPVerb is GET or POST for ports bound with http:binding, whose
+	// clients send plain HTTP requests instead of SOAP envelopes.
+	HTTPVerb string
+}
+
+// portClients returns the client types to generate. A WSDL exposing several
+// SOAP ports gets one client per port, named after it, so that each client
+// carries only its own operations and endpoint. Otherwise clients are named
+// after their port types. HTTP GET/POST ports get a client named after the
+// port. Port types no port refers to still get a client without a default
+// address.
+func (g *GoWSDL) portClients() []*portClient {
+	portTypes := make(map[string]*WSDLPortType)
+	for _, pt := range g.wsdl.PortTypes {
+		portTypes[pt.Name] = pt
+	}
+	bindings := make(map[string]*WSDLBinding)
+	for _, b := range g.wsdl.Binding {
+		bindings[b.Name] = b
+	}
+
+	var clients []*portClient
+	for _, service := range g.wsdl.Service {
+		for _, port := range service.Ports {
+			binding := bindings[localName(port.Binding)]
+			if binding == nil || binding.SOAPBinding.Transport == "" {
+				continue
+			}
+			pt := portTypes[localName(binding.Type)]
+			if pt == nil {
+				continue
+			}
+			clients = append(clients, &portClient{
+				Name:     port.Name,
+				Address:  port.SOAPAddress.Location,
+				Binding:  binding.Name,
+				PortType: pt,
+				Policy:   g.wsdl.bindingPolicy(binding),
+			})
+		}
+	}
+	if len(clients) == 1 {
+		clients[0].Name = clients[0].PortType.Name
+	}
+
+	for _, service := range g.wsdl.Service {
+		for _, port := range service.Ports {
+			binding := bindings[localName(port.Binding)]
+			if binding == nil || binding.HTTPBinding.Verb == "" {
+				continue
+			}
+			pt := portTypes[localName(binding.Type)]
+			if pt == nil {
+				continue
+			}
+			clients = append(clients, &portClient{
+				Name:     port.Name,
+				Address:  port.HTTPAddress.Location,
+				Binding:  binding.Name,
+				PortType: pt,
+				HTTPVerb: strings.ToUpper(binding.HTTPBinding.Verb),
+			})
+		}
+	}
+
+	for _, pt := range g.wsdl.PortTypes {
+		bound := false
+		for _, c := range clients {
+			if c.PortType == pt {
+				bound = true
+				break
+			}
+		}
+		if bound {
+			continue
+		}
+		client := &portClient{Name: pt.Name, PortType: pt}
+		for _, b := range g.wsdl.Binding {
+			if localName(b.Type) == pt.Name {
+				client.Binding = b.Name
+				client.Policy = g.wsdl.bindingPolicy(b)
+				break
+			}
+		}
+		clients = append(clients, client)
+	}
+
+	return clients
+}
+
+// reportPolicies logs the WS-Policy requirements of every client and the
+// assertions generated clients cannot meet.
+func (g *GoWSDL) reportPolicies() {
+	for _, client := range g.portClients() {
+		if client.Policy == nil {
+			continue
+		}
+		if len(client.Policy.Names()) > 0 {
+			log.Printf("[INFO] Policy of binding %s requires %s; see %sPolicyOptions",
+				client.Binding, client.Policy, makePublic(client.Name))
+		}
+		if len(client.Policy.Unsupported) > 0 {
+			log.Printf("[WARN] Policy of binding %s has unsupported assertions: %s",
+				client.Binding, strings.Join(client.Policy.Unsupported, ", "))
+		}
+	}
+}
+
+// reportShadowedFields warns about the elements and attributes of complex
+// types extending another complex type that take the Go field name of one
+// of its base type. The base type is embedded in the generated struct, whose
+// own field hides the embedded one: encoding/xml marshals it once, in the
+// place of the extension, which reorders the content of the base type.
+func (g *GoWSDL) reportShadowedFields() {
+	complexTypes := make(map[string]*XSDComplexType)
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			if _, ok := complexTypes[ct.Name]; !ok {
+				complexTypes[ct.Name] = ct
+			}
+		}
+	}
+
+	// fields returns the Go field names of the content of ct and of its
+	// base types, by the type declaring them.
+	var fields func(ct *XSDComplexType, depth int) map[string]string
+	fields = func(ct *XSDComplexType, depth int) map[string]string {
+		names := make(map[string]string)
+		ext := ct.ComplexContent.Extension
+		if base := complexTypes[localName(ext.Base)]; base != nil && depth < maxRecursion {
+			names = fields(base, depth+1)
+		}
+		for _, el := range append(append(ct.SequenceElements(), ct.Choice...), ct.All...) {
+			names[g.elementFieldName(el)] = ct.Name
+		}
+		for i := range ext.Sequence {
+			names[g.elementFieldName(&ext.Sequence[i])] = ct.Name
+		}
+		for _, attrs := range [][]*XSDAttribute{ct.Attributes, ext.Attributes} {
+			for _, attr := range attrs {
+				names[g.fieldName(attr.Name)] = ct.Name
+			}
+		}
+		return names
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			ext := ct.ComplexContent.Extension
+			base := complexTypes[localName(ext.Base)]
+			if base == nil {
+				continue
+			}
+			inherited := fields(base, 0)
+			shadowed := func(name string) {
+				if owner, ok := inherited[name]; ok {
+					log.Printf("[WARN] Field %s of type %s hides the field of its base type %s: "+
+						"only the field of %s is marshaled, in the place of its own content", name, ct.Name, owner, ct.Name)
+				}
+			}
+			for i := range ext.Sequence {
+				shadowed(g.elementFieldName(&ext.Sequence[i]))
+			}
+			for _, attr := range ext.Attributes {
+				shadowed(g.fieldName(attr.Name))
+			}
+		}
+	}
+}
+
+// elementFieldName returns the Go field name of el, before reserved words
+// are replaced.
+func (g *GoWSDL) elementFieldName(el *XSDElement) string {
+	if el.Ref != "" {
+		return g.fieldName(localName(el.Ref))
+	}
+	return g.fieldName(el.Name)
+}
+
+// typeName returns the Go type name of a type or element name. Unexported
+// names are kept apart from the receivers of the generated methods, and all
+// names from the types declared for built-in XSD types.
+func (g *GoWSDL) typeName(name string) string {
+	name = exportIdentifier(name, g.exportTypes)
+	if receiverNames[name] || builtinTypeNames[name] {
+		name += "_"
+	}
+	return name
+}
+
+// fieldName returns the Go field name of an element or attribute name.
+func (g *GoWSDL) fieldName(name string) string {
+	return exportIdentifier(name, g.exportFields)
+}
+
+// localName strips the namespace prefix off a QName.
+func localName(qname string) string {
+	if i := strings.IndexByte(qname, ':'); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
 func (g *GoWSDL) genOperations() ([]byte, error) {
+	g.reportProgress(PhaseRender, "operations")
+	return g.cachedRender(g.renderKeys.operations(g.wsdl.Types.Schemas), func() ([]byte, error) {
+		data := new(bytes.Buffer)
+		tmpl := g.templates.operations
+		err := tmpl.Execute(data, g.portClients())
+		if err != nil {
+			return nil, err
+		}
+
+		return data.Bytes(), nil
+	})
+}
+
+func (g *GoWSDL) genHeader() ([]byte, error) {
+	g.reportProgress(PhaseRender, "header")
 	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("operations").
-		Funcs(g.tmplFuncs.funcMap).Parse(opsTmpl))
-	err := tmpl.Execute(data, g.wsdl.PortTypes)
+	tmpl := g.templates.header
+	err := tmpl.Execute(data, g.pkg)
 	if err != nil {
 		return nil, err
 	}
@@ -317,11 +1534,33 @@ func (g *GoWSDL) genOperations() ([]byte, error) {
 	return data.Bytes(), nil
 }
 
-func (g *GoWSDL) genHeader() ([]byte, error) {
+func (g *GoWSDL) genExamples() ([]byte, error) {
+	g.reportProgress(PhaseRender, "examples")
 	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("header").
-		Funcs(g.tmplFuncs.funcMap).Parse(headerTmpl))
-	err := tmpl.Execute(data, g.pkg)
+	tmpl := g.templates.examples
+	err := tmpl.Execute(data, struct {
+		Pkg     string
+		Clients []*portClient
+	}{g.pkg, g.portClients()})
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(data.Bytes(), []byte("func Example")) {
+		return nil, nil
+	}
+
+	return data.Bytes(), nil
+}
+
+func (g *GoWSDL) genPackageDoc() ([]byte, error) {
+	g.reportProgress(PhaseRender, "doc")
+	data := new(bytes.Buffer)
+	tmpl := g.templates.doc
+	err := tmpl.Execute(data, struct {
+		Pkg     string
+		WSDL    *WSDL
+		Clients []*portClient
+	}{g.pkg, g.wsdl, g.portClients()})
 	if err != nil {
 		return nil, err
 	}
@@ -330,8 +1569,9 @@ func (g *GoWSDL) genHeader() ([]byte, error) {
 }
 
 func (g *GoWSDL) genSOAPClient() ([]byte, error) {
+	g.reportProgress(PhaseRender, "soap")
 	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("soapclient").Parse(soapTmpl))
+	tmpl := g.templates.soap
 	err := tmpl.Execute(data, g.pkg)
 	if err != nil {
 		return nil, err
@@ -8,14 +8,26 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/importer"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"text/template"
 )
 
 func TestElementGenerationDoesntCommentOutStructProperty(t *testing.T) {
@@ -188,3 +200,2662 @@ func getTypeDeclaration(resp map[string][]byte, name string) (string, error) {
 	}
 	return buf.String(), nil
 }
+
+func TestBuildersGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateBuilders(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := string(resp["types"])
+	if !strings.Contains(types, "func NewGetInfoBuilder() *GetInfoBuilder") {
+		t.Error("builder constructor should be generated for GetInfo")
+	}
+	if !strings.Contains(types, "func (b *GetInfoBuilder) Id(v string) *GetInfoBuilder") {
+		t.Error("builder setter should be generated for GetInfo.Id")
+	}
+}
+
+func TestPointerHelpersGenerated(t *testing.T) {
+	generate := func(enabled bool, version string) map[string][]byte {
+		g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.SetGeneratePointerHelpers(enabled)
+		if err := g.SetGoVersion(version); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	for _, test := range []struct {
+		enabled bool
+		version string
+	}{
+		{false, ""},
+		{true, "1.17"},
+	} {
+		code := typeCheck(t, generate(test.enabled, test.version))
+		for _, unexpected := range []string{"func Ptr[", "func Deref["} {
+			if strings.Contains(code, unexpected) {
+				t.Errorf("%s should not be generated with the helpers enabled %v for Go %q", unexpected, test.enabled, test.version)
+			}
+		}
+	}
+
+	resp := generate(true, "1.18")
+	typeCheck(t, resp)
+	runGenerated(t, resp, `package myservice
+
+import "testing"
+
+func TestPointerHelpers(t *testing.T) {
+	id := Ptr("EUR")
+	if *id != "EUR" {
+		t.Errorf("got %q, want EUR", *id)
+	}
+	*id = "HUF"
+	if got := Deref(id, "USD"); got != "HUF" {
+		t.Errorf("got %q, want HUF", got)
+	}
+	var missing *int
+	if got := Deref(missing, 7); got != 7 {
+		t.Errorf("got %d for nil, want the default 7", got)
+	}
+}
+`, nil)
+}
+
+func TestNullableNillableElements(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/ferry.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateNullable(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resp["types"]), "PublishDate Nullable[time.Time] `xml:\"PublishDate\"`") {
+		t.Error("nillable PublishDate element should be generated as Nullable[time.Time]")
+	}
+
+	g, err = NewGoWSDL("fixtures/prices.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateNullable(true)
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"Discount *Nullable[float64] `xml:\"Discount\"`",
+		"Tax Nullable[float64] `xml:\"Tax\"`",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+	runGenerated(t, resp, `package myservice
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestNullable(t *testing.T) {
+	discount := NewNullable(2.5)
+	for _, test := range []struct {
+		price    Price
+		expected []string
+		missing  []string
+	}{
+		{Price{Invoice: 1}, []string{"<Tax", "nil=\"true\"></Tax>"}, []string{"<Discount"}},
+		{Price{Discount: &Nullable[float64]{}, Tax: NewNullable(0.2)}, []string{"nil=\"true\"></Discount>", "<Tax>0.2</Tax>"}, nil},
+		{Price{Discount: &discount}, []string{"<Discount>2.5</Discount>"}, nil},
+	} {
+		data, err := xml.Marshal(test.price)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range test.expected {
+			if !strings.Contains(string(data), expected) {
+				t.Errorf("missing %s in %s", expected, data)
+			}
+		}
+		for _, missing := range test.missing {
+			if strings.Contains(string(data), missing) {
+				t.Errorf("unexpected %s in %s", missing, data)
+			}
+		}
+
+		var price Price
+		if err := xml.Unmarshal(data, &price); err != nil {
+			t.Fatal(err)
+		}
+		if (price.Discount == nil) != (test.price.Discount == nil) ||
+			price.Discount != nil && *price.Discount != *test.price.Discount {
+			t.Errorf("%s: got Discount %v, want %v", data, price.Discount, test.price.Discount)
+		}
+		if price.Tax != test.price.Tax {
+			t.Errorf("%s: got Tax %v, want %v", data, price.Tax, test.price.Tax)
+		}
+	}
+}
+`, nil)
+}
+
+func TestStreamBase64Elements(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetStreamBase64(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		`"encoding/base64"`,
+		"Content *Base64Stream `xml:\"Content,omitempty\"`",
+		"Thumbnails []*Base64Stream `xml:\"Thumbnails,omitempty\"`",
+		"Checksum []byte `xml:\"checksum,attr,omitempty\"`",
+		"func (b *Base64Stream) MarshalXML(e *xml.Encoder, start xml.StartElement) error",
+		"func (b *Base64Stream) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+func TestFlattenArrays(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/typename.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetFlattenArrays(true)
+	g.SetGenerateBuilders(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"Lines []*Line `xml:\"lines>Line,omitempty\"`",
+		"func (b *Ns1_OrderBuilder) Lines(v []*Line) *Ns1_OrderBuilder {",
+		// The wrapper type is still generated.
+		"type ArrayOfLine struct {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+// generatedImports imports the packages of the generated code for
+// typeCheck, keeping those imported once for later tests.
+var generatedImports struct {
+	sync.Mutex
+	fset     *token.FileSet
+	importer types.Importer
+}
+
+// generatedSource returns the formatted source of the client package
+// generated into resp, as the generator writes it.
+func generatedSource(t *testing.T, resp map[string][]byte) []byte {
+	t.Helper()
+	var source []byte
+	for _, section := range []string{"header", "types", "operations", "compat", "soap"} {
+		source = append(append(source, resp[section]...), '\n')
+	}
+	source, err := format.Source(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+// typeCheck type-checks the client package generated into resp and returns
+// its formatted source.
+func typeCheck(t *testing.T, resp map[string][]byte) string {
+	t.Helper()
+	source := generatedSource(t, resp)
+	generatedImports.Lock()
+	defer generatedImports.Unlock()
+	if generatedImports.importer == nil {
+		generatedImports.fset = token.NewFileSet()
+		generatedImports.importer = importer.ForCompiler(generatedImports.fset, "source", nil)
+	}
+	fset := generatedImports.fset
+	file, err := parser.ParseFile(fset, "myservice.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: generatedImports.importer}
+	if _, err := conf.Check(file.Name.Name, fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("%v in:\n%s", err, source)
+	}
+	return string(source)
+}
+
+// runGenerated runs test, the source of a test file of the client package
+// generated into resp, with go test in a temporary module. files adds the
+// packages the generated code imports to the module, by path; a go.mod among
+// them replaces the one of the module.
+func runGenerated(t *testing.T, resp map[string][]byte, test string, files map[string]string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("the generated code is not run in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the generated code cannot be run:", err)
+	}
+
+	dir := t.TempDir()
+	module := map[string]string{
+		"go.mod":            "module example.com/myservice\n\ngo 1.21\n",
+		"myservice.go":      string(generatedSource(t, resp)),
+		"myservice_test.go": test,
+	}
+	for name, content := range files {
+		module[name] = content
+	}
+	for name, content := range module {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(goTool, "test", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+}
+
+func TestUnwrapMessages(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetUnwrapMessages(true)
+	g.SetGenerateAsync(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"// sending the Id of a [GetInfo] request and returning the GetInfoResult of the [GetInfoResponse] response.\n",
+		"func (service *MNBArfolyamServiceType) GetInfoSoap(request string, opts ...CallOption) (string, error) {\n" +
+			"\tresponse := new(GetInfoResponse)\n" +
+			"\terr := service.client.call(context.Background(), \"GetInfoSoap\", GetInfoSoapAction, &GetInfo{Id: request}, response, nil, opts...)\n" +
+			"\treturn response.GetInfoResult, err\n}\n",
+		"ch <- AsyncResult[string]{Response: &response.GetInfoResult}",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+
+	// Wrapped requests and responses take the names of unexported types.
+	for _, file := range []string{"fixtures/multitypes.wsdl", "fixtures/exports.wsdl", "fixtures/usda-awdb.wsdl"} {
+		g, err := NewGoWSDL(file, "myservice", false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.SetUnwrapMessages(true)
+
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := typeCheck(t, resp)
+		if file == "fixtures/exports.wsdl" && !strings.Contains(code, "&addHeader{Text: request}") {
+			t.Errorf("%s: the request should be wrapped in the unexported addHeader", file)
+		}
+	}
+}
+
+func TestHexBinaryType(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		`"encoding/hex"`,
+		"type HexBinary []byte",
+		"func (h *HexBinary) UnmarshalText(text []byte) error",
+		"type Digest HexBinary",
+		"func (v *Digest) UnmarshalText(text []byte) error {\n\treturn (*HexBinary)(v).UnmarshalText(text)",
+		"Signature HexBinary `xml:\"Signature,omitempty\"`",
+		"Fingerprint HexBinary `xml:\"fingerprint,attr,omitempty\"`",
+		"type HexBinary_ struct {",
+		"Checksums *HexBinary_ `xml:\"Checksums,omitempty\"`",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+
+	g, err = NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(resp["soap"]), "HexBinary") || strings.Contains(string(resp["header"]), "encoding/hex") {
+		t.Error("HexBinary should only be generated when used")
+	}
+}
+
+func TestWhiteSpaceFacet(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"func (v *Category) UnmarshalText(text []byte) error {\n\t*v = Category(collapseWhiteSpace(string(text)))",
+		"func (v *Title) UnmarshalText(text []byte) error {\n\t*v = Title(replaceWhiteSpace(string(text)))",
+		"func (v *Reference) UnmarshalText(text []byte) error {\n\t*v = Reference(collapseWhiteSpace(string(text)))",
+		"func replaceWhiteSpace(s string) string",
+		"func collapseWhiteSpace(s string) string",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	if strings.Contains(code, "func (v *Comment) UnmarshalText") {
+		t.Error("preserved values should be unmarshaled as they are")
+	}
+
+	g, err = NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(resp["soap"]), "WhiteSpace") {
+		t.Error("the white space helpers should only be generated when used")
+	}
+}
+
+func TestIDRefTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/idref.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		`"reflect"`,
+		"type ID string",
+		"type IDRef struct",
+		"type IDRefs []IDRef",
+		"func ResolveIDRefs(v interface{}) error",
+		"Buddy IDRef `xml:\"Buddy,omitempty\"`",
+		"Id ID `xml:\"id,attr,omitempty\"`",
+		"Manager IDRef `xml:\"manager,attr,omitempty\"`",
+		"Mentors IDRefs `xml:\"mentors,attr,omitempty\"`",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+
+	g, err = NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(resp["soap"]), "ResolveIDRefs") {
+		t.Error("the ID types should only be generated when used")
+	}
+}
+
+func TestListTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		`"strconv"`,
+		"type Categories []Category",
+		"type PageSizes []int32",
+		"func (v *PageSizes) UnmarshalText(text []byte) error",
+		"func validLabelsItem(s string) bool {\n\tswitch s {\n\tcase \"draft\", \"final\":",
+		"func validCategoriesItem(s string) bool",
+		"func parseListItem(s string, v interface{}) error",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	if strings.Contains(code, "validPageSizesItem") {
+		t.Error("only enumerated items should be validated")
+	}
+}
+
+func TestAttributeUse(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateBuilders(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"Owner string `xml:\"owner,attr,omitempty\"`",
+		"Kind Category `xml:\"kind,attr,omitempty\"`",
+		"Visibility *string `xml:\"visibility,attr,omitempty\"`",
+		"Revision *int32 `xml:\"revision,attr,omitempty\"`",
+		"Reference *Reference `xml:\"reference,attr,omitempty\"`",
+		"Source string `xml:\"source,attr,omitempty\"`",
+		"func (b *DocumentBuilder) Kind(v Category) *DocumentBuilder",
+		"func (t *Document) GetVisibility() string {\n\tif t == nil || t.Visibility == nil {\n\t\treturn \"private\"",
+		"func (t *Document) GetRevision() int32 {\n\tif t == nil || t.Revision == nil {\n\t\treturn 1\n",
+		"func (t *Document) GetArchived() bool {\n\tif t == nil || t.Archived == nil {\n\t\treturn false\n",
+		"func (t *Document) GetFormat() Title {\n\tif t == nil || t.Format == nil {\n\t\treturn \"PDF\"",
+		"func (t *Document) Validate() error {",
+		"if t.Owner == \"\" {\n\t\tmissing = append(missing, \"owner\")",
+		"if t.Kind == \"\" {\n\t\tmissing = append(missing, \"kind\")",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	if strings.Contains(code, "func (t *Download) Validate") || strings.Contains(code, "GetSource") {
+		t.Error("only required attributes and attributes with defaults should get methods")
+	}
+}
+
+func TestMultipleTypesSections(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/multitypes.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := new(bytes.Buffer)
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(g.wsdl.Types.Schemas); n != 2 {
+		t.Errorf("got %d schemas, want the 2 of both types sections", n)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{"type Money struct {", "Total *Money `xml:\"total,omitempty\"`"} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+	if strings.Contains(logs.String(), "Don't know where to find XSD") {
+		t.Errorf("the namespace-only import should resolve against the second types section, got:\n%s", logs)
+	}
+}
+
+func TestSchemaLocations(t *testing.T) {
+	for _, registered := range []bool{false, true} {
+		g, err := NewGoWSDL("fixtures/schemalocations.wsdl", "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if registered {
+			if err := g.SetSchemaLocations(map[string]string{"http://example.org/common": "fixtures/schemalocations-common.xsd"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		logs := new(bytes.Buffer)
+		log.SetOutput(logs)
+		resp, err := g.Start()
+		log.SetOutput(os.Stderr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		warned := strings.Contains(logs.String(), "[WARN] Don't know where to find XSD for http://example.org/common")
+		generated := bytes.Contains(resp["types"], []byte("type Money struct {"))
+		if warned == registered || generated != registered {
+			t.Errorf("registered %v: got warning %v and Money %v, logs:\n%s", registered, warned, generated, logs)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/schemalocations.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetSchemaLocations(map[string]string{"http://example.org/common": "fixtures/schemalocations-common.xsd"}); err != nil {
+		t.Fatal(err)
+	}
+	var reports []Progress
+	g.SetProgressFunc(func(p Progress) {
+		reports = append(reports, p)
+	})
+
+	if _, err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	var phases []string
+	for _, p := range reports {
+		phases = append(phases, string(p.Phase)+" "+filepath.Base(p.Item))
+	}
+	expected := []string{
+		"fetch schemalocations.wsdl",
+		"parse schemalocations.wsdl",
+		"resolve imports schemalocations.wsdl",
+		"fetch schemalocations-common.xsd",
+		"parse schemalocations-common.xsd",
+		"resolve imports schemalocations-common.xsd",
+		"traverse .",
+	}
+	if len(phases) < len(expected) || strings.Join(phases[:len(expected)], "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got phases:\n%s", strings.Join(phases, "\n"))
+	}
+	last := reports[len(reports)-1]
+	if last.Phase != PhaseRender || last.Schemas != 2 || last.Types != 1 || last.Operations != 1 {
+		t.Errorf("got last report %+v", last)
+	}
+}
+
+func TestSequenceOrder(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/documents.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := new(bytes.Buffer)
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	review := code[strings.Index(code, "type Review struct"):]
+	review = review[:strings.Index(review, "}")]
+	last := 0
+	for _, field := range []string{"Reviewer string", "Approved bool", "Rejected string", "Comment string"} {
+		i := strings.Index(review, field)
+		if i < last {
+			t.Fatalf("the elements of nested choices should keep their place in the sequence:\n%s", review)
+		}
+		last = i
+	}
+	if !strings.Contains(logs.String(), "[WARN] Field Note of type Attachment hides the field of its base type Annotated") {
+		t.Errorf("shadowed base fields should be reported, got:\n%s", logs)
+	}
+	if strings.Contains(logs.String(), "Field FileName") {
+		t.Error("fields missing from the base type should not be reported")
+	}
+}
+
+func TestExportControls(t *testing.T) {
+	generate := func(exportTypes bool, configure func(g *GoWSDL)) string {
+		g, err := NewGoWSDL("fixtures/exports.wsdl", "myservice", false, exportTypes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configure(g)
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return typeCheck(t, resp)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		types     bool
+		configure func(g *GoWSDL)
+		expected  []string
+	}{
+		{
+			name:      "exported",
+			types:     true,
+			configure: func(g *GoWSDL) {},
+			expected: []string{
+				"type Account struct",
+				"Number string `xml:\"number,omitempty\"`",
+				"Note *Note `xml:\"note,omitempty\"`",
+				"Holder struct {\n\t\tName string",
+				"Owner string `xml:\"owner,attr,omitempty\"`",
+				"StatusActive Status = \"active\"",
+				"func (service *AccountsPortType) GetAccount(",
+			},
+		},
+		{
+			name:      "unexported types keep exported fields",
+			types:     false,
+			configure: func(g *GoWSDL) {},
+			expected: []string{
+				"type account struct",
+				"Status *status `xml:\"status,omitempty\"`",
+				"Note *note `xml:\"note,omitempty\"`",
+				"Holder struct {\n\t\tName string",
+				"Owner string `xml:\"owner,attr,omitempty\"`",
+				"statusactive status = \"active\"",
+				"func (service *accountsPortType) getAccount(",
+			},
+		},
+		{
+			name:  "exported enums of unexported types",
+			types: false,
+			configure: func(g *GoWSDL) {
+				g.SetExportEnums(true)
+			},
+			expected: []string{"type status string", "StatusActive status = \"active\""},
+		},
+		{
+			name:  "fields as declared",
+			types: true,
+			configure: func(g *GoWSDL) {
+				g.SetExportFields(false)
+				g.SetExportEnums(false)
+			},
+			expected: []string{
+				"type Account struct",
+				"number string `xml:\"number,omitempty\"`",
+				"note *Note `xml:\"note,omitempty\"`",
+				"holder struct {\n\t\tname string",
+				"owner string `xml:\"owner,attr,omitempty\"`",
+				"statusactive Status = \"active\"",
+			},
+		},
+	} {
+		code := generate(tc.types, tc.configure)
+		for _, expected := range tc.expected {
+			if !strings.Contains(code, expected) {
+				t.Errorf("%s: missing %q in:\n%s", tc.name, expected, code)
+			}
+		}
+	}
+}
+
+func TestReservedIdentifiers(t *testing.T) {
+	for pkg, expected := range map[string]string{
+		"myservice":   "myservice",
+		" my-service": "myservice",
+		"2024api":     "pkg2024api",
+		"type":        "type_",
+		"string":      "string_",
+		"":            "myservice",
+	} {
+		if name := packageName(pkg); name != expected {
+			t.Errorf("package %q: got %s, want %s", pkg, name, expected)
+		}
+	}
+
+	g, err := NewGoWSDL("fixtures/exports.wsdl", "func", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"package func_",
+		"type error_ string",
+		"errornew error_ = \"new\"",
+		"type t_ struct",
+		"Failure *error_ `xml:\"failure,omitempty\"`",
+		"type time_ struct",
+		"At *time_ `xml:\"at,omitempty\"`",
+		"func (service *accountsPortType) AddHeader(header interface{})",
+		"func (service *accountsPortType) AddHeaderOperation(request *addHeader,",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+
+	// The bundled SOAP encoding schema declares a base64 type, which must
+	// not collide with the encoding/base64 import of streamed elements.
+	g, err = NewGoWSDL("fixtures/bundled.wsdl", "myservice", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetStreamBase64(true)
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := typeCheck(t, resp); !strings.Contains(code, "type base64_ ") {
+		t.Errorf("base64 should be generated as base64_ in:\n%s", code)
+	}
+}
+
+func TestClientMemberCollisions(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/exports.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateTestServer(true)
+
+	logs := new(bytes.Buffer)
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"func (service *AccountsPortType) AddHeaderOperation(request *AddHeader,",
+		"func (service *AccountsPortType) NewClientOperation(request *NewClient,",
+		"NewClientOperation func(ctx context.Context, request *NewClient)",
+		"func (s *AccountsPortTypeTestServer) NewClient(opts ...ClientOption) *AccountsPortType",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+	for _, expected := range []string{
+		"[WARN] Operation AddHeader of port type accountsPortType collides with AddHeader of the generated client and is generated as AddHeaderOperation",
+		"[WARN] Operation NewClient of port type accountsPortType collides with NewClient of the generated test server and is generated as NewClientOperation",
+	} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("missing report %q in:\n%s", expected, logs)
+		}
+	}
+
+	g.SetGenerateTestServer(false)
+	g.disambiguateOperations()
+	for op, name := range g.operationNames {
+		if op.Name == "NewClient" {
+			t.Errorf("NewClient should keep its name without a test server, got %s", name)
+		}
+	}
+}
+
+func TestOperationNaming(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/exports.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, naming := range []string{"{{.Port", "{{.Service}}{{.Operation}}"} {
+		if err := g.SetOperationNaming(naming); err == nil {
+			t.Errorf("%s should be rejected", naming)
+		}
+	}
+	if err := g.SetOperationNaming(`{{trimSuffix .Port "PortType" | title}}{{title .Operation}}`); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"func (service *AccountsPortType) AccountsGetAccount(request *GetAccount,",
+		"func (service *AccountsPortType) AccountsAddHeader(request *AddHeader,",
+		"const AccountsGetAccountAction = \"urn:getAccount\"",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/exports.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ValidateTemplates(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetOperationNaming("{{.Port}}{{.Operation}}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ValidateTemplates(); err != nil {
+		t.Fatal(err)
+	}
+	g.operationNaming = template.Must(template.New("operation").Parse("{{.Service}}"))
+	if err := g.ValidateTemplates(); err == nil || !strings.Contains(err.Error(), "invalid operation naming") {
+		t.Errorf("got error %v", err)
+	}
+
+	// Generators copy the templates parsed once and keep theirs across
+	// generations.
+	h, err := NewGoWSDL("fixtures/exports.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.templates.types == parsed.types || h.templates.types == g.templates.types {
+		t.Error("generators should not share templates")
+	}
+	types := h.templates.types
+	first, err := h.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := h.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.templates.types != types || !bytes.Equal(first["types"], second["types"]) {
+		t.Error("templates should be reused across generations")
+	}
+}
+
+func TestClientInterfaces(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/simpleparts.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateInterfaces(true)
+	g.SetGenerateAsync(true)
+	g.SetGenerateTestServer(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"type GeoPortType interface {\n\tAddHeader(header interface{})\n\tSetHeader(header interface{})\n",
+		"\tEcho(request string, opts ...CallOption) (string, error)\n",
+		"\tLookupAsync(ctx context.Context, request *Lookup, opts ...CallOption) <-chan AsyncResult[CountryCode]\n",
+		"type geoPortTypeClient struct {",
+		"var _ GeoPortType = (*geoPortTypeClient)(nil)",
+		"func NewGeoPortType(url string, tls bool, auth CredentialsProvider, opts ...ClientOption) GeoPortType {",
+		"return &geoPortTypeClient{",
+		"func (service *geoPortTypeClient) Echo(request string, opts ...CallOption) (string, error) {",
+		"func (s *GeoPortTypeTestServer) NewClient(opts ...ClientOption) GeoPortType {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+	if n := strings.Count(code, "const EchoAction ="); n != 1 {
+		t.Errorf("the SOAP action constants should be declared once, got %d", n)
+	}
+}
+
+func TestCachingClients(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/simpleparts.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateCachingClients(true)
+	g.SetGenerateLoggingClients(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"type GeoPortType interface {",
+		"type Cache interface {",
+		"func NewMemoryCache() *MemoryCache {",
+		"type CachingGeoPortType struct {\n\tGeoPortType\n\tCache Cache\n\tTTL   map[string]time.Duration\n}",
+		"func NewCachingGeoPortType(client GeoPortType, cache Cache, ttl map[string]time.Duration) *CachingGeoPortType {",
+		"func (c *CachingGeoPortType) Lookup(request *Lookup, opts ...CallOption) (CountryCode, error) {\n\tttl, ok := c.TTL[\"Lookup\"]\n" +
+			"\tif !ok || c.Cache == nil || len(opts) > 0 {",
+		"key, err := cacheKey(\"Lookup\", soapHeaders(c.GeoPortType), request)",
+		"func (service *geoPortTypeClient) soapHeaders() []interface{} {\n\treturn service.client.headers\n}",
+		"func (c *LoggingGeoPortType) soapHeaders() []interface{} {\n\treturn soapHeaders(c.GeoPortType)\n}",
+		"if response, ok := value.(CountryCode); ok {",
+		"\t\"sync\"\n",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestLoggingClients(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/simpleparts.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateLoggingClients(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"type GeoPortType interface {",
+		"type Logger interface {",
+		"type LoggingGeoPortType struct {\n\tGeoPortType\n\tLogger Logger\n}",
+		"func NewLoggingGeoPortType(client GeoPortType, logger Logger) *LoggingGeoPortType {",
+		"func (c *LoggingGeoPortType) Lookup(request *Lookup, opts ...CallOption) (CountryCode, error) {\n\tstart := time.Now()",
+		"logCall(c.Logger, \"Lookup\", time.Since(start), request, response, err)",
+		"if fault, ok := err.(*SOAPFault); ok {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestOperationDocs(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateInterfaces(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	doc := "// GetInfoSoap calls the GetInfoSoap operation of the MNBArfolyamServiceType port type with SOAPAction [GetInfoSoapAction],\n" +
+		"// sending a [GetInfo] request and returning the [GetInfoResponse] response.\n"
+	for _, expected := range []string{
+		"// Messages of MNBArfolyamServiceType.GetInfoSoap.\nvar (\n\t_ *GetInfo\n\t_ *GetInfoResponse\n)",
+		doc + "func (service *mNBArfolyamServiceTypeClient) GetInfoSoap(",
+		strings.Replace(doc, "// ", "\t// ", -1) + "\tGetInfoSoap(",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestOmitEmptyPolicy(t *testing.T) {
+	tests := []struct {
+		policy   OmitEmptyPolicy
+		expected string
+	}{
+		{OmitEmptyAll, "`xml:\"Id,omitempty\"`"},
+		{OmitEmptyOptional, "`xml:\"Id\"`"},
+		{OmitEmptyNever, "`xml:\"Id\"`"},
+	}
+	for _, test := range tests {
+		g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = g.SetOmitEmptyPolicy(test.policy); err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := getTypeDeclaration(resp, "GetInfo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(actual, test.expected) {
+			t.Errorf("policy %s: got %s want tag %s", test.policy, actual, test.expected)
+		}
+	}
+
+	g, _ := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err := g.SetOmitEmptyPolicy("sometimes"); err == nil {
+		t.Error("unknown policy should be rejected")
+	}
+}
+
+func TestCloneMethodsGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateClone(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resp["types"]), "func (t *GetInfo) Clone() *GetInfo") {
+		t.Error("Clone method should be generated for GetInfo")
+	}
+	if !strings.Contains(string(resp["header"]), `"reflect"`) {
+		t.Error("reflect should be imported when Clone methods are generated")
+	}
+}
+
+func TestSOAPEncodedArrays(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/soapenc.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source(append(resp["header"], resp["types"]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := string(source)
+	for _, expected := range []string{
+		"type ArrayOfString []string\n",
+		"type ArrayOfUser []*User\n",
+		"e.EncodeElement(item, xml.StartElement{Name: xml.Name{Local: \"user\"}})",
+		"var item *User\n\t\t\tt.Name.Local = \"User\"\n",
+		"// gowsdl: unsupported <xs:restriction base=\"soapenc:Array\"/>",
+	} {
+		if !strings.Contains(types, expected) {
+			t.Errorf("missing %q in generated types", expected)
+		}
+	}
+}
+
+// decimalModule is a stand-in for github.com/shopspring/decimal, whose
+// Decimal keeps its state in unexported fields.
+var decimalModule = map[string]string{
+	"go.mod": "module example.com/myservice\n\ngo 1.21\n\n" +
+		"require github.com/shopspring/decimal v1.0.0\n\n" +
+		"replace github.com/shopspring/decimal => ./decimal\n",
+	"decimal/go.mod": "module github.com/shopspring/decimal\n\ngo 1.21\n",
+	"decimal/decimal.go": `package decimal
+
+import "strconv"
+
+type Decimal struct {
+	value int64
+	exp   int32
+}
+
+func NewFromInt(value int64) Decimal {
+	return Decimal{value: value}
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.value == other.value && d.exp == other.exp
+}
+
+func (d Decimal) String() string {
+	return strconv.FormatInt(d.value, 10)
+}
+`,
+}
+
+func TestEqualGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/prices.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateEqual(true)
+	if err := g.SetTypeMappings(map[string]string{"double": "github.com/shopspring/decimal.Decimal"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestEqual(t *testing.T) {
+	due := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	price := &Price{Invoice: decimal.NewFromInt(1), Due: due}
+	for _, test := range []struct {
+		other *Price
+		equal bool
+	}{
+		{&Price{Invoice: decimal.NewFromInt(1), Due: due.In(time.FixedZone("CET", 3600))}, true},
+		{&Price{XMLName: xml.Name{Local: "Price"}, Invoice: decimal.NewFromInt(1), Due: due, Notes: []string{}}, true},
+		{&Price{Invoice: decimal.NewFromInt(999), Due: due}, false},
+		{&Price{Invoice: decimal.NewFromInt(1)}, false},
+		{&Price{Invoice: decimal.NewFromInt(1), Due: due, Notes: []string{"net"}}, false},
+		{nil, false},
+	} {
+		if equal := price.Equal(test.other); equal != test.equal {
+			t.Errorf("Equal(%+v): got %v, want %v", test.other, equal, test.equal)
+		}
+	}
+}
+`, decimalModule)
+
+	// Unexported fields are compared too.
+	g, err = NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateEqual(true)
+	g.SetExportFields(false)
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	a, b := &ResponseStatus{responseCode: "a"}, &ResponseStatus{responseCode: "b"}
+	if a.Equal(b) {
+		t.Error("statuses with different codes should differ")
+	}
+	if !a.Equal(&ResponseStatus{responseCode: "a"}) {
+		t.Error("statuses with the same code should be equal")
+	}
+}
+`, nil)
+}
+
+func TestStringerGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateStringer(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := string(resp["types"])
+	if !strings.Contains(types, "func (t *GetInfo) String() string") {
+		t.Error("String method should be generated for GetInfo")
+	}
+	if !strings.Contains(types, "func Dump(v interface{}) string") {
+		t.Error("Dump helper should be generated")
+	}
+
+	g, err = NewGoWSDL("fixtures/prices.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateStringer(true)
+	if err := g.SetTypeMappings(map[string]string{"double": "github.com/shopspring/decimal.Decimal"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDump(t *testing.T) {
+	price := &Price{
+		Invoice: decimal.NewFromInt(12),
+		Due:     time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Notes:   []string{"net"},
+	}
+	expected := "Price{Invoice: 12, Due: 2024-03-01T12:00:00Z, Notes: [\"net\"]}"
+	if dump := price.String(); dump != expected {
+		t.Errorf("got %s, want %s", dump, expected)
+	}
+}
+`, decimalModule)
+
+	// Unexported fields are dumped too.
+	g, err = NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateStringer(true)
+	g.SetExportFields(false)
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import "testing"
+
+func TestDump(t *testing.T) {
+	status := &ResponseStatus{responseCode: "Successful"}
+	if dump, expected := status.String(), "ResponseStatus{responseCode: \"Successful\"}"; dump != expected {
+		t.Errorf("got %s, want %s", dump, expected)
+	}
+}
+`, nil)
+}
+
+func TestEnumHelpersGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/chromedata.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateEnumHelpers(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := string(resp["types"])
+	for _, expected := range []string{
+		"func AllDriveTrainValues() []DriveTrain",
+		"func ParseDriveTrain(s string) (DriveTrain, error)",
+		"func (v DriveTrain) IsValid() bool",
+		"func (v DriveTrain) String() string",
+	} {
+		if !strings.Contains(types, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+func TestUnsupportedConstructsCommented(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/unsupported.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source([]byte(string(resp["header"]) + string(resp["types"]) + string(resp["operations"])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`// gowsdl: unsupported <xs:pattern value="[A-Z]{3}"/>`,
+		`// gowsdl: unsupported <xs:maxLength value="3"/>`,
+		`// gowsdl: unsupported <xs:minExclusive value="0"/>`,
+		`// gowsdl: unsupported <xs:fractionDigits value="2"/>`,
+		`// gowsdl: unsupported <xs:union memberTypes="tns:Code tns:Amount"/>`,
+		`// gowsdl: unsupported <xs:any namespace="##other" processContents="lax" minOccurs="0" maxOccurs="unbounded"/>`,
+		`// gowsdl: unsupported <xs:attributeGroup ref="tns:Audit"/>`,
+		`// gowsdl: unsupported <xs:anyAttribute namespace="##any" processContents="skip"/>`,
+		`// gowsdl: unsupported message SubmitSoapOut without parts was ignored`,
+		`// gowsdl: unsupported operation Submit without a response type was skipped`,
+		"type CodeList []Code",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+func TestClientPerPort(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/multiport.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source([]byte(string(resp["header"]) + string(resp["types"]) + string(resp["operations"])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := string(source)
+	for _, expected := range []string{
+		"func NewOrdersPort(url string, tls bool, auth CredentialsProvider, opts ...ClientOption) *OrdersPort",
+		`url = "http://orders.example.org/soap"`,
+		"func (service *OrdersPort) PlaceOrder(request *PlaceOrder, opts ...CallOption) (*PlaceOrderResponse, error)",
+		`const PlaceOrderAction = "urn:PlaceOrder"`,
+		`service.client.call(context.Background(), "PlaceOrder", PlaceOrderAction, request, response, nil, opts...)`,
+		"func NewBillingPort(url string, tls bool, auth CredentialsProvider, opts ...ClientOption) *BillingPort",
+		`url = "http://billing.example.org/soap"`,
+		"func (service *BillingPort) GetInvoice(request *GetInvoice, opts ...CallOption) (*GetInvoiceResponse, error)",
+	} {
+		if !strings.Contains(ops, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	for _, unexpected := range []string{
+		"func (service *BillingPort) PlaceOrder",
+		"func (service *OrdersPort) GetInvoice",
+		"OrdersPortType",
+	} {
+		if strings.Contains(ops, unexpected) {
+			t.Errorf("%s should not be generated", unexpected)
+		}
+	}
+}
+
+func TestOneWayOperations(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/oneway.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source([]byte(string(resp["header"]) + string(resp["types"]) + string(resp["operations"])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"func (service *EventsPortType) LogEvent(request *LogEvent, opts ...CallOption) error",
+		`const LogEventAction = "urn:LogEvent"`,
+		`return service.client.call(context.Background(), "LogEvent", LogEventAction, request, nil, nil, opts...)`,
+		"// gowsdl: unsupported notification operation EventRaised was skipped",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+func TestOperationRegistry(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/duplicates.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source([]byte(string(resp["header"]) + string(resp["types"]) + string(resp["operations"])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`const OrdersPortPlaceAction = "urn:example:orders/Place"`,
+		`const BillingPortPlaceAction = "urn:example:billing/Place"`,
+		`const FindByNameAction = "urn:example:orders/Find"`,
+		`service.client.call(context.Background(), "Place", OrdersPortPlaceAction, request, response, nil, opts...)`,
+		"var Operations = map[string]OperationInfo{",
+		`"OrdersPort.Place": {`,
+		`"BillingPort.Place": {`,
+		"Action: BillingPortPlaceAction,",
+		`Output: xml.Name{Space: "urn:example:billing", Local: "Status"},`,
+		`"FindByName": {`,
+		`Input:  xml.Name{Space: "urn:example:orders", Local: "FindByName"},`,
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+func TestAsyncVariantsGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/oneway.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateAsync(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := string(resp["operations"])
+	if !strings.Contains(ops, "type AsyncResult[T any] struct") {
+		t.Error("AsyncResult should be generated")
+	}
+	if !strings.Contains(ops, "LogEventAsync (ctx context.Context, request *LogEvent, opts ...CallOption) <-chan error") {
+		t.Error("one-way operations should get an error channel")
+	}
+
+	g, err = NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateAsync(true)
+
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resp["operations"]), "GetInfoSoapAsync (ctx context.Context, request *GetInfo, opts ...CallOption) <-chan AsyncResult[GetInfoResponse]") {
+		t.Error("GetInfoSoapAsync should be generated")
+	}
+}
+
+func TestBatchHelpersGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateBatch(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := string(resp["operations"])
+	for _, expected := range []string{
+		"func CallBatch[Req, Resp any](ctx context.Context, requests []*Req, concurrency int,",
+		"GetInfoSoapBatch (ctx context.Context, requests []*GetInfo, concurrency int, opts ...CallOption) BatchResult[GetInfoResponse]",
+	} {
+		if !strings.Contains(ops, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	if !strings.Contains(string(resp["header"]), `"sync"`) {
+		t.Error("sync should be imported")
+	}
+}
+
+func TestTestServerGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateTestServer(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := string(resp["operations"])
+	for _, expected := range []string{
+		"type MNBArfolyamServiceTypeTestServer struct",
+		"GetInfoSoap func(ctx context.Context, request *GetInfo) (*GetInfoResponse, error)",
+		"func NewMNBArfolyamServiceTypeTestServer() *MNBArfolyamServiceTypeTestServer",
+		`case "GetInfo":`,
+	} {
+		if !strings.Contains(ops, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	if !strings.Contains(string(resp["header"]), `"net/http/httptest"`) {
+		t.Error("net/http/httptest should be imported")
+	}
+}
+
+func TestVCRTransportGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateVCR(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := string(resp["operations"])
+	for _, expected := range []string{
+		"type VCRTransport struct",
+		"func (t *VCRTransport) RoundTrip(req *http.Request) (*http.Response, error)",
+		"func soapBodyElement(data []byte) (string, error)",
+	} {
+		if !strings.Contains(ops, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	if !strings.Contains(string(resp["soap"]), "func WithTransport(transport http.RoundTripper) ClientOption") {
+		t.Error("WithTransport should be generated")
+	}
+}
+
+func TestHTTPHeaderOptionsGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	soap := string(resp["soap"])
+	for _, expected := range []string{
+		"func WithHTTPHeader(key, value string) ClientOption",
+		"type CallOption func(*http.Request)",
+		"func WithCallHeader(key, value string) CallOption",
+		"func WithRequestIDFunc(fn func(ctx context.Context) string) ClientOption",
+		`const RequestIDHeader = "X-Request-ID"`,
+		"addressingHeaders(s.url, soapAction, requestID)",
+		"func (s *SOAPClient) CallContext(ctx context.Context, soapAction string, request, response interface{}, opts ...CallOption) error",
+		"s.setHTTPHeaders(req, requestID, opts)",
+		"func WithResponseInfo(info *ResponseInfo) CallOption",
+		"recordResponse(req, res, sent)",
+	} {
+		if !strings.Contains(soap, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+	if !strings.Contains(string(resp["operations"]), "GetInfoSoap (request *GetInfo, opts ...CallOption) (*GetInfoResponse, error)") {
+		t.Error("operations should accept call options")
+	}
+}
+
+func TestTimeoutsGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"func WithTimeouts(timeouts Timeouts) ClientOption",
+		"dialer := &net.Dialer{Timeout: timeout, KeepAlive: s.timeouts.KeepAlive}",
+		"TLSHandshakeTimeout:   s.timeouts.TLSHandshake,",
+		"ResponseHeaderTimeout: s.timeouts.ResponseHeader,",
+		"ExpectContinueTimeout: s.timeouts.ExpectContinue,",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+func TestConnectionReuseGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConnectionReuse(t *testing.T) {
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte("<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\"><soap:Body>" +
+			"<GetInfoResponse xmlns=\"http://www.mnb.hu/webservices/\"><GetInfoResult>rates</GetInfoResult></GetInfoResponse>" +
+			"</soap:Body></soap:Envelope>"))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := NewMNBArfolyamServiceType(server.URL, false, nil)
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetInfoSoap(&GetInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&connections); n != 1 {
+		t.Errorf("got %d connections for 3 calls, want 1", n)
+	}
+}
+`, nil)
+}
+
+func TestOperationTimeoutsGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"func WithOperationTimeouts(timeouts map[string]time.Duration, fallback time.Duration) ClientOption",
+		"ctx, cancel := s.operationContext(ctx, operation)",
+		`service.client.call(context.Background(), "GetInfoSoap", GetInfoSoapAction, request, response, nil, opts...)`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+func TestEmptyResponsesGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"var ErrEmptyResponse = errors.New(",
+		"\tif len(bytes.TrimSpace(rawbody)) == 0 {\n",
+		"\t\tif response != nil {\n\t\t\treturn ErrEmptyResponse\n",
+		"\tif response == nil && res.StatusCode == http.StatusAccepted {\n",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+}
+
+func TestStatusFaultsGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"\tif res.StatusCode < 200 || res.StatusCode > 299 {\n\t\tlog.Println(string(rawbody))\n\t\treturn s.statusError(res, rawbody)\n",
+		"func (s *SOAPClient) statusError(res *http.Response, rawbody []byte) error {",
+		"type HTTPError struct {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+}
+
+func TestLenientResponsesGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLenientResponses(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		envelope string
+		result   string
+		warnings []string
+		fault    string
+	}{
+		{
+			name: "changed namespace",
+			envelope: "<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\"><soap:Body>" +
+				"<GetInfoResponse xmlns=\"http://www.mnb.hu/webservices/v2\"><GetInfoResult>rates</GetInfoResult></GetInfoResponse>" +
+				"</soap:Body></soap:Envelope>",
+			result: "rates",
+			warnings: []string{
+				"decoding element GetInfoResponse of namespace \"http://www.mnb.hu/webservices/v2\" as one of namespace \"http://www.mnb.hu/webservices/\"",
+			},
+		},
+		{
+			name: "unknown element and attribute",
+			envelope: "<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\"><soap:Body>" +
+				"<GetInfoResponse xmlns=\"http://www.mnb.hu/webservices/\" version=\"2\"><GetInfoResult>rates</GetInfoResult><Source>ecb</Source></GetInfoResponse>" +
+				"</soap:Body></soap:Envelope>",
+			result: "rates",
+			warnings: []string{
+				"ignoring attribute version of element GetInfoResponse",
+				"ignoring element Source",
+			},
+		},
+		{
+			name: "SOAP 1.2 envelope",
+			envelope: "<env:Envelope xmlns:env=\"http://www.w3.org/2003/05/soap-envelope\"><env:Body>" +
+				"<GetInfoResponse xmlns=\"http://www.mnb.hu/webservices/\"><GetInfoResult>rates</GetInfoResult></GetInfoResponse>" +
+				"</env:Body></env:Envelope>",
+			result: "rates",
+			warnings: []string{
+				"decoding element Envelope of namespace \"http://www.w3.org/2003/05/soap-envelope\" as one of namespace \"http://schemas.xmlsoap.org/soap/envelope/\"",
+				"decoding element Body of namespace \"http://www.w3.org/2003/05/soap-envelope\" as one of namespace \"http://schemas.xmlsoap.org/soap/envelope/\"",
+			},
+		},
+		{
+			name: "fault",
+			envelope: "<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\"><soap:Body>" +
+				"<soap:Fault><faultcode>soap:Server</faultcode><faultstring>unavailable</faultstring>" +
+				"<detail><Retry xmlns=\"urn:errors\" after=\"60\"/></detail></soap:Fault>" +
+				"</soap:Body></soap:Envelope>",
+			fault: "unavailable",
+		},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/xml")
+			w.Write([]byte(test.envelope))
+		}))
+		var warnings []string
+		client := NewMNBArfolyamServiceType(server.URL, false, nil, WithLenientResponses(func(message string) {
+			warnings = append(warnings, message)
+		}))
+		response, err := client.GetInfoSoap(&GetInfo{})
+		server.Close()
+
+		if test.fault != "" {
+			fault, ok := err.(*SOAPFault)
+			if !ok || fault.String != test.fault {
+				t.Errorf("%s: got error %v, want fault %s", test.name, err, test.fault)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if response.GetInfoResult != test.result {
+			t.Errorf("%s: got result %q, want %q", test.name, response.GetInfoResult, test.result)
+		}
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("%s: got warnings %q, want %q", test.name, warnings, test.warnings)
+		}
+	}
+}
+`, nil)
+}
+
+func TestNamespacePrefixesGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+type note struct {
+	XMLName xml.Name `+"`xml:\"urn:notes note\"`"+`
+	Lang    string   `+"`xml:\"http://www.w3.org/XML/1998/namespace lang,attr\"`"+`
+	Nil     bool     `+"`xml:\"http://www.w3.org/2001/XMLSchema-instance nil,attr\"`"+`
+	Author  string   `+"`xml:\"urn:people author\"`"+`
+	Text    string   `+"`xml:\"urn:notes text\"`"+`
+}
+
+func TestPrefixNamespaces(t *testing.T) {
+	for _, test := range []struct {
+		doc, expected string
+	}{
+		{
+			doc: "<a:order xmlns:a=\"urn:orders\"><a:item xmlns:a=\"urn:orders\" xmlns=\"urn:items\"><sku>1</sku></a:item></a:order>",
+			expected: "<a:order xmlns:a=\"urn:orders\" xmlns:ns1=\"urn:items\"><a:item><ns1:sku>1</ns1:sku></a:item></a:order>",
+		},
+		{
+			doc: "<Envelope xmlns=\"http://schemas.xmlsoap.org/soap/envelope/\"><Body><x xmlns=\"urn:x\" xmlns:y=\"urn:y\" y:id=\"1\"></x></Body></Envelope>",
+			expected: "<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\" xmlns:ns1=\"urn:x\" xmlns:y=\"urn:y\"><soap:Body><ns1:x y:id=\"1\"></ns1:x></soap:Body></soap:Envelope>",
+		},
+	} {
+		doc, err := prefixNamespaces([]byte(test.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(doc) != test.expected {
+			t.Errorf("got %s, want %s", doc, test.expected)
+		}
+	}
+
+	// The xml prefix is reserved for the XML namespace, which is never
+	// declared.
+	data, err := xml.Marshal(note{Lang: "en", Nil: true, Author: "ann", Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := prefixNamespaces(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "<ns1:note xmlns:ns1=\"urn:notes\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" xmlns:ns2=\"urn:people\" xml:lang=\"en\" xsi:nil=\"true\">" +
+		"<ns2:author>ann</ns2:author><ns1:text>hi</ns1:text></ns1:note>"
+	if string(doc) != expected {
+		t.Errorf("got %s, want %s", doc, expected)
+	}
+}
+`, nil)
+}
+
+func TestCanonicalXMLGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import "testing"
+
+func TestCanonicalXML(t *testing.T) {
+	for _, test := range []struct {
+		name, doc, expected string
+	}{
+		{
+			// Namespaces are declared on the elements using them.
+			name: "namespace pushdown",
+			doc: "<?xml version=\"1.0\"?><n0:local xmlns:n0=\"foo:bar\" xmlns:n3=\"ftp://example.org\">" +
+				"<n1:elem2 xmlns:n1=\"http://example.net\" xml:lang=\"en\"><n3:stuff xmlns:n3=\"ftp://example.org\"/></n1:elem2></n0:local>",
+			expected: "<n0:local xmlns:n0=\"foo:bar\"><n1:elem2 xmlns:n1=\"http://example.net\" xml:lang=\"en\">" +
+				"<n3:stuff xmlns:n3=\"ftp://example.org\"></n3:stuff></n1:elem2></n0:local>",
+		},
+		{
+			name:     "undeclared default namespace",
+			doc:      "<a xmlns=\"urn:a\"><b xmlns=\"\"><c/></b><d xmlns=\"urn:a\"/></a>",
+			expected: "<a xmlns=\"urn:a\"><b xmlns=\"\"><c></c></b><d></d></a>",
+		},
+		{
+			name:     "superfluous empty default namespace",
+			doc:      "<a><b xmlns=\"\"/></a>",
+			expected: "<a><b></b></a>",
+		},
+		{
+			// Unqualified attributes come first, then the others by
+			// namespace URI.
+			name: "attribute order",
+			doc: "<e5 xmlns=\"http://example.org\" xmlns:a=\"http://www.w3.org\" xmlns:b=\"http://www.ietf.org\" " +
+				"a:attr=\"out\" b:attr=\"sorted\" attr2=\"all\" attr=\"I'm\"/>",
+			expected: "<e5 xmlns=\"http://example.org\" xmlns:a=\"http://www.w3.org\" xmlns:b=\"http://www.ietf.org\" " +
+				"attr=\"I'm\" attr2=\"all\" b:attr=\"sorted\" a:attr=\"out\"></e5>",
+		},
+		{
+			name:     "escaping",
+			doc:      "<a b=\"1&#xD;&#xA;&#x9;&quot;&lt;&gt;2\">x&#xD;y &amp; &lt;z&gt; \"q\"</a>",
+			expected: "<a b=\"1&#xD;&#xA;&#x9;&quot;&lt;>2\">x&#xD;y &amp; &lt;z&gt; \"q\"</a>",
+		},
+	} {
+		doc, err := canonicalXML([]byte(test.doc))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if string(doc) != test.expected {
+			t.Errorf("%s: got %s, want %s", test.name, doc, test.expected)
+		}
+	}
+}
+`, nil)
+}
+
+func TestCredentialsProviderGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	soap := string(resp["soap"])
+	for _, expected := range []string{
+		"type CredentialsProvider interface {",
+		"func (a *BasicAuth) Credentials(ctx context.Context) (Credentials, error)",
+		"type CredentialsFunc func(ctx context.Context) (Credentials, error)",
+		"func NewSOAPClient(url string, insecureSkipVerify bool, auth CredentialsProvider, opts ...ClientOption) *SOAPClient",
+		`req.Header.Set("Authorization", "Bearer "+creds.Token)`,
+		"func WithNegotiate(provider NegotiateProvider) ClientOption",
+		`req.Header.Set("Authorization", "Negotiate "+token)`,
+		"func WithProxy(proxyURL *url.URL) ClientOption",
+		"func WithProxyAuth(username, password string) ClientOption",
+		"transport.Proxy = http.ProxyURL(&proxy)",
+		"func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption",
+		"func WithUnixSocket(path string) ClientOption",
+		"func WithContentType(contentType string) ClientOption",
+		"func WithActionInContentType() ClientOption",
+		"func WithStreamingRequests() ClientOption",
+		"req.Body = streamXML(envelope, s.indent)",
+		"func WithIndentedRequests(indent string) ClientOption",
+		`encoder.Indent("", s.indent)`,
+		`req.Header.Add("Content-Type", contentType+"; action=\""+soapAction+"\"")`,
+	} {
+		if !strings.Contains(soap, expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+}
+
+func TestExamplesGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateExamples(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source(resp["examples"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"package myservice",
+		"func ExampleMNBArfolyamServiceType_GetInfoSoap() {",
+		`Id: "",`,
+		"response, err := client.GetInfoSoap(request)",
+		"if fault, ok := err.(*SOAPFault); ok {",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("%s should be generated", expected)
+		}
+	}
+
+	if name := exampleName("WSF_x0020_ScheduleSoap", "GetAllAlerts"); name != "Example_wSFx0020ScheduleSoapGetAllAlerts" {
+		t.Errorf("unexpected example name %s", name)
+	}
+}
+
+func TestPackageDocGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/httpbinding.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGeneratePackageDoc(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source(resp["doc"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `// Package myservice is the client of the QuotesService service, generated by gowsdl.
+//
+// NewQuotesHttpGet creates clients of http://quotes.example.org/api by default, sending HTTP GET requests. Their operations are:
+//
+//   - GetQuote
+//   - QuoteByPath
+//
+// NewQuotesHttpPost creates clients of http://quotes.example.org/api by default, sending HTTP POST requests. Their operations are:
+//
+//   - GetQuote
+package myservice
+`
+	if string(source) != expected {
+		t.Errorf("got\n%s\nwant\n%s", source, expected)
+	}
+
+	if s := summary("  Retrieves all\n\tpublished alerts. Results are cached.  "); s != "Retrieves all published alerts." {
+		t.Errorf("unexpected summary %q", s)
+	}
+}
+
+func TestFileHeader(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateExamples(true)
+	g.SetFileHeader("Copyright 2024 Example Corp.\r\n\n//go:build integration\n//nolint:all\n")
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "// Copyright 2024 Example Corp.\n\n//go:build integration\n\n//nolint:all\n\npackage myservice\n"
+	for _, file := range []string{"header", "examples"} {
+		source, err := format.Source(resp[file])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(source), expected) {
+			t.Errorf("the %s should start with the file header, got:\n%s", file, source)
+		}
+	}
+}
+
+func TestPolicyOptionsGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/policy.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"// gowsdl: unsupported WS-Policy assertion OptimizedMimeSerialization\ntype PaymentsPortType struct",
+		"func PaymentsPortTypePolicyOptions(username, password string) []ClientOption {\n\treturn []ClientOption{\n" +
+			"\t\tWithRequireTLS(),\n\t\tWithUsernameToken(username, password),\n\t\tWithAddressing(),\n\t}\n}",
+		"func WithAddressing() ClientOption",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+
+	g, err = NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(resp["operations"]), "PolicyOptions") {
+		t.Error("PolicyOptions should only be generated for bindings with a policy")
+	}
+}
+
+func TestHTTPBindingClientsGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/httpbinding.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"func (service *QuotesHttpGet) GetQuote(symbol string, days int32, since time.Time, opts ...CallOption) (*Quote, error) {",
+		"CallHTTP(context.Background(), \"GET\", \"/GetQuote\", HTTPURLEncoded, url.Values{\n" +
+			"\t\t\"symbol\": {symbol},\n\t\t\"days\":   {fmt.Sprint(days)},\n\t\t\"since\":  {since.Format(time.RFC3339)},\n\t}, response, opts...)",
+		"CallHTTP(context.Background(), \"GET\", \"/quotes/(symbol)\", HTTPURLReplacement,",
+		"CallHTTP(context.Background(), \"POST\", \"/GetQuote\", HTTPFormEncoded,",
+		"// gowsdl: unsupported HTTP operation GetChart was skipped",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+	if strings.Contains(code, "func (service *QuotesHttpGet) AddHeader") {
+		t.Error("HTTP clients should not expose SOAP headers")
+	}
+}
+
+func TestHeaderFaultsRegistered(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/headerfault.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"// Calls fail with a *HeaderFault holding a *AuthFaultType when a response header carries AuthFault.\n",
+		"client.RegisterHeaderFault(\"AuthFault\", \"AuthFaultType\", func() interface{} { return new(AuthFaultType) })",
+		"client.RegisterHeaderFault(\"RoutingFault\", \"RoutingFault\", func() interface{} { return new(RoutingFault) })",
+		"type HeaderFault struct {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+	if strings.Count(code, "RegisterHeaderFault(\"AuthFault\"") != 2 {
+		t.Error("both constructors should register every header fault once")
+	}
+}
+
+func TestResponseHeadersResult(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/responseheaders.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateInterfaces(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"\tListProductsWithHeaders(request *ListProducts, opts ...CallOption) (*ListProductsResult, error)\n",
+		"type ListProductsResult struct {\n" +
+			"\tResponse      *ListProductsResponse\n" +
+			"\tSession       *SessionType\n" +
+			"\tNextPageToken *string\n}\n",
+		"{element: \"Session\", typeName: \"SessionType\", value: &result.Session},",
+		"{element: \"NextPageToken\", typeName: \"string\", value: &result.NextPageToken},",
+		"return decodeResponseHeaders(rawbody, headers)",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+}
+
+func TestMultiRefResolutionGenerated(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGenerated(t, resp, `package myservice
+
+import "testing"
+
+func TestResolveMultiRefs(t *testing.T) {
+	// An Axis style envelope: the order refers to an address, which both of
+	// its addresses share, and to itself.
+	envelope := "<soapenv:Envelope xmlns:soapenv=\"http://schemas.xmlsoap.org/soap/envelope/\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\"><soapenv:Body>" +
+		"<ns1:getOrderResponse xmlns:ns1=\"urn:orders\"><getOrderReturn href=\"#id0\"/></ns1:getOrderResponse>" +
+		"<multiRef id=\"id0\" xsi:type=\"ns2:Order\" xmlns:ns2=\"urn:orders\"><billing href=\"#id1\"/><shipping href=\"#id1\"/><parent href=\"#id0\"/></multiRef>" +
+		"<multiRef id=\"id1\" xsi:type=\"ns3:Address\" xmlns:ns3=\"urn:orders\"><city>Budapest</city></multiRef>" +
+		"</soapenv:Body></soapenv:Envelope>"
+	want := "<soapenv:Envelope xmlns:soapenv=\"http://schemas.xmlsoap.org/soap/envelope/\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\"><soapenv:Body>" +
+		"<ns1:getOrderResponse xmlns:ns1=\"urn:orders\"><getOrderReturn xsi:type=\"ns2:Order\" xmlns:ns2=\"urn:orders\">" +
+		"<billing xsi:type=\"ns3:Address\" xmlns:ns3=\"urn:orders\"><city>Budapest</city></billing>" +
+		"<shipping xsi:type=\"ns3:Address\" xmlns:ns3=\"urn:orders\"><city>Budapest</city></shipping>" +
+		"<parent href=\"#id0\"></parent>" +
+		"</getOrderReturn></ns1:getOrderResponse>" +
+		"</soapenv:Body></soapenv:Envelope>"
+
+	got, err := resolveMultiRefs([]byte(envelope))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	plain := "<soapenv:Envelope xmlns:soapenv=\"http://schemas.xmlsoap.org/soap/envelope/\"><soapenv:Body><a/></soapenv:Body></soapenv:Envelope>"
+	if got, err := resolveMultiRefs([]byte(plain)); err != nil || string(got) != plain {
+		t.Errorf("got %s, %v for an envelope without references", got, err)
+	}
+}
+`, nil)
+}
+
+func TestDownloadLimits(t *testing.T) {
+	wsdl := `<?xml version="1.0"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <types>
+    <xs:schema targetNamespace="urn:a">
+      <xs:import namespace="urn:b" schemaLocation="b.xsd"/>
+    </xs:schema>
+  </types>
+</definitions>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.URL.Path {
+		case "/service.wsdl":
+			w.Write([]byte(wsdl))
+		case "/b.xsd":
+			w.Write([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:b"><xs:import namespace="urn:c" schemaLocation="c.xsd"/></xs:schema>`))
+		case "/c.xsd":
+			w.Write([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:c"/>`))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		location string
+		limits   DownloadLimits
+		err      string
+	}{
+		{srv.URL + "/service.wsdl", DownloadLimits{}, ""},
+		{srv.URL + "/service.wsdl", DownloadLimits{Schemas: 2, FileSize: int64(len(wsdl)), TotalSize: 1000}, ""},
+		{srv.URL + "/service.wsdl", DownloadLimits{Schemas: 1}, "more than 1 external schemas are imported, " + srv.URL + "/c.xsd exceeds the limit"},
+		{srv.URL + "/service.wsdl", DownloadLimits{FileSize: 100}, srv.URL + "/service.wsdl is larger than the limit of 100 bytes"},
+		{srv.URL + "/service.wsdl", DownloadLimits{TotalSize: int64(len(wsdl)) + 10}, fmt.Sprintf("limit of %d bytes in total, exceeded by %s/b.xsd", len(wsdl)+10, srv.URL)},
+		{"fixtures/test.wsdl", DownloadLimits{FileSize: 100}, "test.wsdl is larger than the limit of 100 bytes"},
+	}
+	for _, test := range tests {
+		g, err := NewGoWSDL(test.location, "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.SetDownloadLimits(test.limits)
+
+		err = g.load()
+		if test.err == "" && err != nil {
+			t.Errorf("%+v: %v", test.limits, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%+v: got error %v, want %q", test.limits, err, test.err)
+		}
+	}
+}
+
+// spaceReader endlessly reads spaces, counting them.
+type spaceReader struct {
+	read int
+}
+
+func (r *spaceReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	r.read += len(p)
+	return len(p), nil
+}
+
+func TestDecodeSchemaStopsEarly(t *testing.T) {
+	for doc, expected := range map[string]string{
+		`<?xml version="1.0"?><html><body>`: "huge.xsd is not an XML schema, its root element is html",
+		`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a"></xs:schema>`: "element <element> closed by </schema>",
+	} {
+		rest := new(spaceReader)
+		_, err := decodeSchema(io.MultiReader(strings.NewReader(doc), rest), "huge.xsd")
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("got error %v, want %q", err, expected)
+		}
+		if rest.read > 1<<20 {
+			t.Errorf("%s: read %d bytes past the error", expected, rest.read)
+		}
+	}
+
+	schema, err := decodeSchema(strings.NewReader(`<?xml version="1.0"?>
+<!-- orders -->
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders"/>
+`), "orders.xsd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.TargetNamespace != "urn:orders" {
+		t.Errorf("got target namespace %q", schema.TargetNamespace)
+	}
+}
+
+func TestDownloadFileRejectsHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<!DOCTYPE html>\n<html><body>Please sign in</body></html>"))
+		case "/sniffed":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("\n  <HTML><body>Blocked by proxy</body></HTML>"))
+		case "/missing":
+			http.Error(w, "no such service", http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "text/xml")
+			w.Write([]byte(`<?xml version="1.0"?><definitions/>`))
+		}
+	}))
+	defer srv.Close()
+
+	for path, expected := range map[string]string{
+		"/login":   `response is not an XML document, a login or proxy page may have been returned (status "200 OK", content type "text/html; charset=utf-8"): <!DOCTYPE html> <html><body>Please sign in</body></html>`,
+		"/sniffed": "Blocked by proxy",
+		"/missing": `unexpected response (status "404 Not Found", content type "text/plain; charset=utf-8"): no such service`,
+	} {
+		_, _, err := downloadFile(srv.URL+path, downloadOptions{})
+		if err == nil || !strings.Contains(err.Error(), srv.URL+path) || !strings.Contains(err.Error(), expected) {
+			t.Errorf("unexpected error for %s: %v", path, err)
+		}
+	}
+	if _, _, err := downloadFile(srv.URL+"/service.wsdl", downloadOptions{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDownloadRedirects(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<auth user=%q header=%q/>`, r.Header.Get("Authorization"), r.Header.Get("X-Token"))
+	}))
+	defer other.Close()
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.Path)
+		switch r.URL.Path {
+		case "/service.wsdl":
+			http.Redirect(w, r, "/v2/service.wsdl", http.StatusFound)
+		case "/v2/service.wsdl":
+			w.Write([]byte(`<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"><types>
+				<schema xmlns="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:svc">
+					<import namespace="urn:types" schemaLocation="types.xsd"/>
+				</schema></types></definitions>`))
+		case "/v2/types.xsd":
+			http.Redirect(w, r, "/v3/types.xsd", http.StatusTemporaryRedirect)
+		case "/v3/types.xsd":
+			w.Write([]byte(`<schema xmlns="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:types"/>`))
+		case "/away":
+			http.Redirect(w, r, other.URL, http.StatusMovedPermanently)
+		case "/here":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/echo":
+			fmt.Fprintf(w, `<auth user=%q header=%q/>`, r.Header.Get("Authorization"), r.Header.Get("X-Token"))
+		}
+	}))
+	defer srv.Close()
+
+	g, err := NewGoWSDL(srv.URL+"/service.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.wsdl.Types.Schemas) != 2 || g.wsdl.Types.Schemas[1].TargetNamespace != "urn:types" {
+		t.Errorf("types.xsd should be resolved against the redirect target, requested %v", got)
+	}
+
+	headers := http.Header{"X-Token": {"secret"}}
+	auth := &basicAuth{Login: "user", Password: "pass"}
+	for policy, resent := range map[RedirectAuthPolicy]bool{
+		RedirectAuthSameHost: false,
+		RedirectAuthAlways:   true,
+		RedirectAuthNever:    false,
+	} {
+		data, u, err := downloadFile(srv.URL+"/away", downloadOptions{auth: auth, headers: headers, redirectAuth: policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.String() != other.URL {
+			t.Errorf("unexpected final URL %s", u)
+		}
+		if sent := strings.Contains(string(data), "secret"); sent != resent || strings.Contains(string(data), `user="Basic`) != resent {
+			t.Errorf("%s policy: unexpected redirected request %s", policy, data)
+		}
+	}
+
+	data, _, err := downloadFile(srv.URL+"/here", downloadOptions{auth: auth, headers: headers, redirectAuth: RedirectAuthSameHost})
+	if err != nil || !strings.Contains(string(data), "secret") || !strings.Contains(string(data), `user="Basic`) {
+		t.Errorf("credentials should be sent again on the same host: %s %v", data, err)
+	}
+
+	if err := g.SetRedirectAuthPolicy("sometimes"); err == nil {
+		t.Error("unknown redirect auth policies should be rejected")
+	}
+}
+
+func TestDownloadThroughProxy(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<tls auth=%q proxy=%q/>`, r.Header.Get("Authorization"), r.Header.Get("Proxy-Authorization"))
+	}))
+	defer target.Close()
+
+	var connects []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			fmt.Fprintf(w, `<plain url=%q proxy=%q/>`, r.URL, r.Header.Get("Proxy-Authorization"))
+			return
+		}
+		connects = append(connects, r.Header.Get("Proxy-Authorization"))
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	defer proxy.Close()
+
+	g, err := NewGoWSDL(target.URL, "myservice", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetProxy(proxy.URL); err != nil {
+		t.Fatal(err)
+	}
+	g.SetProxyAuth("proxyuser", "proxypass")
+	g.SetBasicAuth("user", "pass")
+	proxyAuth := "Basic cHJveHl1c2VyOnByb3h5cGFzcw=="
+
+	data, _, err := downloadFile("http://wsdl.invalid/service.wsdl", g.downloadOptions(nil))
+	if err != nil || string(data) != `<plain url="http://wsdl.invalid/service.wsdl" proxy="`+proxyAuth+`"/>` {
+		t.Errorf("plain downloads should be sent to the proxy with its credentials: %s %v", data, err)
+	}
+
+	data, _, err = downloadFile(target.URL, g.downloadOptions(nil))
+	if err != nil || string(data) != `<tls auth="Basic dXNlcjpwYXNz" proxy=""/>` {
+		t.Errorf("https downloads should be tunnelled with the server credentials only: %s %v", data, err)
+	}
+	if len(connects) != 1 || connects[0] != proxyAuth {
+		t.Errorf("CONNECT should carry the proxy credentials, got %q", connects)
+	}
+
+	if err := g.SetProxy("proxy.example.com"); err == nil {
+		t.Error("proxy URLs without a scheme should be rejected")
+	}
+}
+
+func TestSchemaImportCycles(t *testing.T) {
+	schemas := map[string]string{
+		// a.xsd and b.xsd both include common.xsd, which is no cycle.
+		"/a.xsd":      `<import schemaLocation="b.xsd"/><include schemaLocation="common.xsd"/>`,
+		"/b.xsd":      `<include schemaLocation="common.xsd"/>`,
+		"/common.xsd": ``,
+		"/x.xsd":      `<import schemaLocation="y.xsd"/>`,
+		"/y.xsd":      `<import schemaLocation="x.xsd"/>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/diamond.wsdl", "/cycle.wsdl":
+			fmt.Fprintf(w, `<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"><types>
+				<schema xmlns="http://www.w3.org/2001/XMLSchema"><import schemaLocation="%s"/></schema>
+				</types></definitions>`, map[string]string{"/diamond.wsdl": "a.xsd", "/cycle.wsdl": "x.xsd"}[r.URL.Path])
+		default:
+			fmt.Fprintf(w, `<schema xmlns="http://www.w3.org/2001/XMLSchema">%s</schema>`, schemas[r.URL.Path])
+		}
+	}))
+	defer srv.Close()
+
+	g, err := NewGoWSDL(srv.URL+"/diamond.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.wsdl.Types.Schemas) != 4 {
+		t.Errorf("expected every schema to be resolved once, got %d schemas", len(g.wsdl.Types.Schemas))
+	}
+
+	g, err = NewGoWSDL(srv.URL+"/cycle.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.load()
+	cycle := fmt.Sprintf("schema import cycle: %[1]s/x.xsd -> %[1]s/y.xsd -> %[1]s/x.xsd", srv.URL)
+	if err == nil || err.Error() != cycle {
+		t.Errorf("expected %q, got %v", cycle, err)
+	}
+}
+
+func TestElementLookupByNamespace(t *testing.T) {
+	orders := &XSDSchema{TargetNamespace: "urn:orders", Elements: []*XSDElement{
+		{Name: "status", Type: "tns:LowerStatus"},
+		{Name: "Lookup", Type: "tns:OrderLookup"},
+	}}
+	billing := &XSDSchema{TargetNamespace: "urn:billing", Elements: []*XSDElement{
+		{Name: "Status", Type: "b:BillingStatus"},
+		{Name: "Lookup", Type: "b:BillingLookup"},
+	}}
+	g := &GoWSDL{wsdl: &WSDL{
+		Xmlns: map[string]string{"tns": "urn:orders", "b": "urn:billing", "x": "urn:unknown"},
+		Types: WSDLType{Schemas: []*XSDSchema{orders, billing}},
+	}}
+
+	for qname, expected := range map[string]string{
+		"tns:Lookup": "OrderLookup",
+		"b:Lookup":   "BillingLookup",
+		"tns:status": "LowerStatus",
+		"tns:Status": "LowerStatus",
+		"b:status":   "BillingStatus",
+		"x:Status":   "BillingStatus",
+		"Lookup":     "OrderLookup",
+	} {
+		if name, ok := g.elementType(qname); !ok || name != expected {
+			t.Errorf("%s: got %s wanted %s", qname, name, expected)
+		}
+	}
+	if _, ok := g.elementType("tns:Missing"); ok {
+		t.Error("unknown elements should not be found")
+	}
+}
+
+func TestSimpleTypeParts(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/simpleparts.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetGenerateAsync(true)
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"type xmlValue[T any] struct {",
+		"func (service *GeoPortType) Echo(request string, opts ...CallOption) (string, error) {",
+		"response := new(xmlValue[string])",
+		"&xmlValue[string]{XMLName: xml.Name{Space: \"\", Local: \"text\"}, Value: request}",
+		"func (service *GeoPortType) Lookup(request *Lookup, opts ...CallOption) (CountryCode, error) {",
+		"response := new(xmlValue[CountryCode])",
+		"func (service *GeoPortType) Population(request string, opts ...CallOption) (int64, error) {",
+		"func (service *GeoPortType) EchoAsync(ctx context.Context, request string, opts ...CallOption) <-chan AsyncResult[string] {",
+		"ch <- AsyncResult[string]{Response: &response.Value}",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+	if strings.Contains(code, "*String") {
+		t.Error("simple parts should not refer to a generated struct")
+	}
+}
+
+func TestRegisterTemplateFunc(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, fn := range map[string]interface{}{
+		"shout":  "not a function",
+		"a-b":    strings.ToUpper,
+		"shouts": func() (string, string) { return "", "" },
+	} {
+		if err := g.RegisterTemplateFunc(name, fn); err == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+	if err := g.RegisterTemplateFunc("shout", strings.ToUpper); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetOperationNaming(`{{shout .Port}}_{{.Operation}}`); err != nil {
+		t.Fatal(err)
+	}
+	// Built-in functions are replaced for this generator only.
+	if err := g.RegisterTemplateFunc("comment", func(text string) string { return "// ACME: " + text }); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"func (service *MNBArfolyamServiceType) MNBARFOLYAMSERVICETYPE_GetInfoSoap(request *GetInfo,",
+		"// ACME: this is a comment",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+
+	h, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = h.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(resp["types"], []byte("ACME")) {
+		t.Error("functions registered with one generator should not affect others")
+	}
+}
@@ -0,0 +1,271 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GraphFormat selects how TypeGraph renders the dependency graph.
+type GraphFormat string
+
+const (
+	// GraphDOT renders the graph for Graphviz, one cluster per namespace.
+	GraphDOT GraphFormat = "dot"
+	// GraphJSON renders the graph as JSON lists of nodes and edges.
+	GraphJSON GraphFormat = "json"
+)
+
+type typeGraph struct {
+	Nodes []*typeGraphNode `json:"nodes"`
+	Edges []typeGraphEdge  `json:"edges"`
+}
+
+// typeGraphNode is a schema, a named type or a global element. Its ID is
+// the kind and name joined by a colon, as types and elements of the same
+// name are distinct.
+type typeGraphNode struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type typeGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// typeGraphBuilder collects nodes and edges in declaration order.
+type typeGraphBuilder struct {
+	graph    typeGraph
+	nodes    map[string]*typeGraphNode
+	edges    map[typeGraphEdge]bool
+	types    map[string]string
+	elements map[string]string
+}
+
+// TypeGraph returns the dependency graph of the WSDL's schemas and types:
+// schema imports, type derivations, and the types and elements every type
+// and global element uses. Built-in XSD types are left out; references that
+// resolve to no loaded declaration show up as unresolved nodes.
+func (g *GoWSDL) TypeGraph(format GraphFormat) ([]byte, error) {
+	if format != GraphDOT && format != GraphJSON {
+		return nil, fmt.Errorf("unknown graph format %q", format)
+	}
+	err := g.load()
+	if err != nil {
+		return nil, err
+	}
+
+	b := newTypeGraphBuilder()
+	b.build(g.wsdl.Types.Schemas)
+
+	if format == GraphJSON {
+		return json.MarshalIndent(&b.graph, "", "  ")
+	}
+	title := g.wsdl.Name
+	if title == "" {
+		title = g.wsdl.TargetNamespace
+	}
+	return b.graph.dot(title), nil
+}
+
+func newTypeGraphBuilder() *typeGraphBuilder {
+	return &typeGraphBuilder{
+		nodes:    make(map[string]*typeGraphNode),
+		edges:    make(map[typeGraphEdge]bool),
+		types:    make(map[string]string),
+		elements: make(map[string]string),
+	}
+}
+
+func (b *typeGraphBuilder) build(schemas []*XSDSchema) {
+	for _, schema := range schemas {
+		ns := schema.TargetNamespace
+		b.node("schema", ns, ns)
+		for _, st := range schema.SimpleType {
+			b.types[st.Name] = b.node("simpleType", st.Name, ns)
+		}
+		for _, ct := range schema.ComplexTypes {
+			b.types[ct.Name] = b.node("complexType", ct.Name, ns)
+		}
+		for _, el := range schema.Elements {
+			b.elements[el.Name] = b.node("element", el.Name, ns)
+		}
+	}
+
+	for _, schema := range schemas {
+		from := "schema:" + schema.TargetNamespace
+		for _, impt := range schema.Imports {
+			b.edge(from, b.node("schema", impt.Namespace, impt.Namespace), "imports")
+		}
+		for _, st := range schema.SimpleType {
+			b.simpleType("simpleType:"+st.Name, st)
+		}
+		for _, ct := range schema.ComplexTypes {
+			b.complexType("complexType:"+ct.Name, ct)
+		}
+		for _, el := range schema.Elements {
+			b.element("element:"+el.Name, el, "type")
+		}
+	}
+}
+
+// node adds a node unless it exists and returns its ID.
+func (b *typeGraphBuilder) node(kind, name, ns string) string {
+	id := kind + ":" + name
+	if b.nodes[id] == nil {
+		n := &typeGraphNode{ID: id, Kind: kind, Name: name, Namespace: ns}
+		b.nodes[id] = n
+		b.graph.Nodes = append(b.graph.Nodes, n)
+	}
+	return id
+}
+
+func (b *typeGraphBuilder) edge(from, to, kind string) {
+	e := typeGraphEdge{From: from, To: to, Kind: kind}
+	if to == "" || b.edges[e] {
+		return
+	}
+	b.edges[e] = true
+	b.graph.Edges = append(b.graph.Edges, e)
+}
+
+// typeRef returns the node of the named XSD type, or "" for built-in types.
+func (b *typeGraphBuilder) typeRef(xsdType string) string {
+	name := localName(xsdType)
+	if id, ok := b.types[name]; ok {
+		return id
+	}
+	if _, ok := xsd2JSONTypes[strings.ToLower(name)]; ok {
+		return ""
+	}
+	return b.node("unresolved", name, "")
+}
+
+func (b *typeGraphBuilder) elementRef(ref string) string {
+	name := localName(ref)
+	if id, ok := b.elements[name]; ok {
+		return id
+	}
+	return b.node("unresolved", name, "")
+}
+
+// simpleType adds the edges of st, named or anonymous, to from.
+func (b *typeGraphBuilder) simpleType(from string, st *XSDSimpleType) {
+	if st.Restriction.Base != "" {
+		b.edge(from, b.typeRef(st.Restriction.Base), "restricts")
+	}
+	if st.List.ItemType != "" {
+		b.edge(from, b.typeRef(st.List.ItemType), "list")
+	}
+	if st.List.SimpleType != nil {
+		b.simpleType(from, st.List.SimpleType)
+	}
+	for _, member := range strings.Fields(st.Union.MemberTypes) {
+		b.edge(from, b.typeRef(member), "union")
+	}
+	for _, member := range st.Union.SimpleType {
+		b.simpleType(from, member)
+	}
+}
+
+// complexType adds the edges of ct, named or anonymous, to from.
+func (b *typeGraphBuilder) complexType(from string, ct *XSDComplexType) {
+	for _, ext := range []XSDExtension{ct.ComplexContent.Extension, ct.SimpleContent.Extension} {
+		if ext.Base != "" {
+			b.edge(from, b.typeRef(ext.Base), "extends")
+		}
+		for i := range ext.Sequence {
+			b.element(from, &ext.Sequence[i], "element")
+		}
+		for _, attr := range ext.Attributes {
+			b.attribute(from, attr)
+		}
+	}
+	for _, elements := range [][]*XSDElement{ct.Sequence, ct.Choice, ct.SequenceChoice, ct.All} {
+		for _, el := range elements {
+			b.element(from, el, "element")
+		}
+	}
+	for _, attr := range ct.Attributes {
+		b.attribute(from, attr)
+	}
+}
+
+// element adds the edges of el to from. The type of a global element is
+// linked with kind, a reference to one with "ref".
+func (b *typeGraphBuilder) element(from string, el *XSDElement, kind string) {
+	switch {
+	case el.Ref != "":
+		b.edge(from, b.elementRef(el.Ref), "ref")
+	case el.Type != "":
+		b.edge(from, b.typeRef(el.Type), kind)
+	case el.ComplexType != nil:
+		b.complexType(from, el.ComplexType)
+	case el.SimpleType != nil:
+		b.simpleType(from, el.SimpleType)
+	}
+}
+
+func (b *typeGraphBuilder) attribute(from string, attr *XSDAttribute) {
+	switch {
+	case attr.Type != "":
+		b.edge(from, b.typeRef(attr.Type), "attribute")
+	case attr.SimpleType != nil:
+		b.simpleType(from, attr.SimpleType)
+	}
+}
+
+var typeGraphShapes = map[string]string{
+	"schema":      "folder",
+	"complexType": "box",
+	"simpleType":  "ellipse",
+	"element":     "box, style=rounded",
+	"unresolved":  "box, style=dashed",
+}
+
+// dot renders the graph for Graphviz, clustering nodes by namespace.
+func (tg *typeGraph) dot(title string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph %q {\n", title)
+	buf.WriteString("  rankdir=LR;\n")
+
+	var namespaces []string
+	clusters := make(map[string][]*typeGraphNode)
+	for _, n := range tg.Nodes {
+		if _, ok := clusters[n.Namespace]; !ok {
+			namespaces = append(namespaces, n.Namespace)
+		}
+		clusters[n.Namespace] = append(clusters[n.Namespace], n)
+	}
+	for i, ns := range namespaces {
+		indent := "  "
+		if ns != "" {
+			fmt.Fprintf(&buf, "  subgraph \"cluster_%d\" {\n    label=%q;\n", i, ns)
+			indent = "    "
+		}
+		for _, n := range clusters[ns] {
+			label := n.Name
+			if n.Kind == "schema" {
+				label = "schema"
+			}
+			fmt.Fprintf(&buf, "%s%q [label=%q, shape=%s];\n", indent, n.ID, label, typeGraphShapes[n.Kind])
+		}
+		if ns != "" {
+			buf.WriteString("  }\n")
+		}
+	}
+	for _, e := range tg.Edges {
+		fmt.Fprintf(&buf, "  %q -> %q [label=%q];\n", e.From, e.To, e.Kind)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,79 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTypeGraph(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/unsupported.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := g.TypeGraph(GraphJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var graph typeGraph
+	if err := json.Unmarshal(data, &graph); err != nil {
+		t.Fatal(err)
+	}
+	expected := []typeGraphEdge{
+		{From: "simpleType:CodeList", To: "simpleType:Code", Kind: "list"},
+		{From: "simpleType:CodeOrAmount", To: "simpleType:Amount", Kind: "union"},
+		{From: "complexType:Envelope", To: "simpleType:Code", Kind: "element"},
+		{From: "element:Submit", To: "complexType:Envelope", Kind: "element"},
+	}
+	for _, e := range expected {
+		found := false
+		for _, edge := range graph.Edges {
+			found = found || edge == e
+		}
+		if !found {
+			t.Errorf("missing edge %+v in %+v", e, graph.Edges)
+		}
+	}
+
+	data, err = g.TypeGraph(GraphDOT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dot := string(data)
+	if !strings.HasPrefix(dot, "digraph \"http://example.org/unsupported/\" {") ||
+		!strings.Contains(dot, "label=\"http://example.org/unsupported/\";") ||
+		!strings.Contains(dot, "\"complexType:Envelope\" -> \"simpleType:Code\" [label=\"element\"];") {
+		t.Errorf("unexpected DOT graph:\n%s", dot)
+	}
+
+	if _, err := g.TypeGraph("svg"); err == nil {
+		t.Error("unknown graph formats should be rejected")
+	}
+}
+
+func TestTypeGraphImportsAndUnresolved(t *testing.T) {
+	b := newTypeGraphBuilder()
+	b.build([]*XSDSchema{{
+		TargetNamespace: "urn:orders",
+		Imports:         []*XSDImport{{Namespace: "urn:common"}},
+		ComplexTypes: []*XSDComplexType{{
+			Name:     "Order",
+			Sequence: []*XSDElement{{Name: "Customer", Type: "c:Customer"}, {Name: "Id", Type: "xs:string"}},
+		}},
+	}})
+
+	if len(b.graph.Edges) != 2 {
+		t.Fatalf("unexpected edges %+v", b.graph.Edges)
+	}
+	if e := b.graph.Edges[0]; e != (typeGraphEdge{From: "schema:urn:orders", To: "schema:urn:common", Kind: "imports"}) {
+		t.Errorf("unexpected import edge %+v", e)
+	}
+	if e := b.graph.Edges[1]; e != (typeGraphEdge{From: "complexType:Order", To: "unresolved:Customer", Kind: "element"}) {
+		t.Errorf("unexpected unresolved edge %+v", e)
+	}
+}
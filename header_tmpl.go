@@ -5,22 +5,56 @@
 package gowsdl
 
 var headerTmpl = `
-package {{.}}
+{{with fileHeader}}{{.}}
+
+{{end}}package {{.}}
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
+	{{if or usesLists generateStringer}}
+		"encoding"
+	{{end}}
+	{{if usesLists}}
+		"strconv"
+	{{end}}
+	{{if or generateBatch generateCaching}}
+		"sync"
+	{{end}}
+	{{if streamBase64}}
+		"encoding/base64"
+	{{end}}
+	{{if or usesHexBinary generateVCR}}
+		"encoding/hex"
+	{{end}}
+	{{if and generateTestServer hasSOAPClients}}
+		"net/http/httptest"
+	{{end}}
+	{{if generateVCR}}
+		"crypto/sha256"
+		"encoding/json"
+		"os"
+		"path/filepath"
+	{{end}}
 
-	{{/*range .Imports*/}}
-		{{/*.*/}}
-	{{/*end*/}}
+	{{range typeImports}}
+		"{{.}}"
+	{{end}}
 )
 
 // against "unused imports"
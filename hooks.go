@@ -0,0 +1,50 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PostProcessFunc post-processes the generated Go files once they are
+// written, such as by running goimports or a linter on them or by stamping
+// a license, failing the generation when it returns an error.
+type PostProcessFunc func(files []string) error
+
+// PostCommand returns a PostProcessFunc running command, whose arguments are
+// separated by spaces, with the generated files appended to its arguments,
+// such as "goimports -w". The output of a failed command is part of the
+// error.
+func PostCommand(command string) PostProcessFunc {
+	return func(files []string) error {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return errors.New("empty post-processing command")
+		}
+		cmd := exec.Command(args[0], append(args[1:], files...)...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			if output = bytes.TrimSpace(output); len(output) > 0 {
+				return fmt.Errorf("running %s: %v\n%s", command, err, output)
+			}
+			return fmt.Errorf("running %s: %v", command, err)
+		}
+		return nil
+	}
+}
+
+// postProcess runs hooks on files in order, stopping at the first failure.
+func postProcess(hooks []PostProcessFunc, files []string) error {
+	for _, hook := range hooks {
+		if err := hook(files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestHelperProcess is run by the post-processing commands of the tests,
+// failing with its arguments as output when GOWSDL_HELPER_FAIL is set.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GOWSDL_HELPER_PROCESS") != "1" {
+		return
+	}
+	if os.Getenv("GOWSDL_HELPER_FAIL") != "" {
+		fmt.Println("lint:", strings.Join(os.Args[len(os.Args)-1:], " "))
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+func TestGeneratePostProcess(t *testing.T) {
+	quietLog(t)
+	outFile := filepath.Join(t.TempDir(), "myservice", "myservice.go")
+	var processed [][]string
+	generator := &Generator{
+		WsdlPath:    "fixtures/test.wsdl",
+		Pkg:         "myservice",
+		OutFile:     outFile,
+		MakePublic:  true,
+		OmitEmpty:   "all",
+		GraphFormat: "dot",
+		Examples:    true,
+		PostProcess: []PostProcessFunc{
+			func(files []string) error {
+				processed = append(processed, files)
+				return nil
+			},
+			func(files []string) error {
+				processed = append(processed, files)
+				return nil
+			},
+		},
+	}
+	if err := generator.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	files := []string{outFile, filepath.Join(filepath.Dir(outFile), "examples_test.go")}
+	if expected := [][]string{files, files}; !reflect.DeepEqual(processed, expected) {
+		t.Errorf("got hooks run on %v, want %v", processed, expected)
+	}
+
+	failure := errors.New("license missing")
+	generator.PostProcess = []PostProcessFunc{
+		func([]string) error { return failure },
+		func([]string) error {
+			t.Error("hook run after a failure")
+			return nil
+		},
+	}
+	if err := generator.Generate(); err != failure {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+}
+
+func TestPostCommand(t *testing.T) {
+	t.Setenv("GOWSDL_HELPER_PROCESS", "1")
+	command := os.Args[0] + " -test.run=TestHelperProcess --"
+	files := []string{"a.go", "b.go"}
+
+	if err := PostCommand(command)(files); err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+
+	t.Setenv("GOWSDL_HELPER_FAIL", "1")
+	err := PostCommand(command)(files)
+	if err == nil || !strings.Contains(err.Error(), "lint: b.go") {
+		t.Errorf("got error %v, want the output of the command", err)
+	}
+
+	if err := PostCommand(" ")(files); err == nil {
+		t.Error("got no error running an empty command")
+	}
+}
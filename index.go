@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+// wsdlIndex indexes the messages, global elements and simple types of the
+// WSDL by name, in declaration order, so that the lookups done for every
+// type and operation do not scan all of them.
+type wsdlIndex struct {
+	messages    map[string][]*WSDLMessage
+	elements    map[string][]indexedElement
+	simpleTypes map[string][]*XSDSimpleType
+}
+
+// indexedElement is a global element with the schema declaring it.
+type indexedElement struct {
+	el     *XSDElement
+	schema *XSDSchema
+}
+
+// index returns the index of the WSDL, which is built on first use and so
+// must not be used before the WSDL is prepared.
+func (g *GoWSDL) index() *wsdlIndex {
+	g.indexOnce.Do(func() {
+		idx := &wsdlIndex{
+			messages:    make(map[string][]*WSDLMessage),
+			elements:    make(map[string][]indexedElement),
+			simpleTypes: make(map[string][]*XSDSimpleType),
+		}
+		for _, msg := range g.wsdl.Messages {
+			idx.messages[msg.Name] = append(idx.messages[msg.Name], msg)
+		}
+		for _, schema := range g.wsdl.Types.Schemas {
+			for _, el := range schema.Elements {
+				idx.elements[el.Name] = append(idx.elements[el.Name], indexedElement{el, schema})
+			}
+			for _, st := range schema.SimpleType {
+				idx.simpleTypes[st.Name] = append(idx.simpleTypes[st.Name], st)
+			}
+		}
+		g.wsdlIndex = idx
+	})
+	return g.wsdlIndex
+}
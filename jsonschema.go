@@ -0,0 +1,317 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema needed to describe XSD types.
+type jsonSchema struct {
+	Ref              string                 `json:"$ref,omitempty"`
+	Type             string                 `json:"type,omitempty"`
+	Format           string                 `json:"format,omitempty"`
+	Description      string                 `json:"description,omitempty"`
+	Properties       map[string]*jsonSchema `json:"properties,omitempty"`
+	Required         []string               `json:"required,omitempty"`
+	Items            *jsonSchema            `json:"items,omitempty"`
+	Enum             []string               `json:"enum,omitempty"`
+	AllOf            []*jsonSchema          `json:"allOf,omitempty"`
+	Pattern          string                 `json:"pattern,omitempty"`
+	MinLength        *int                   `json:"minLength,omitempty"`
+	MaxLength        *int                   `json:"maxLength,omitempty"`
+	Minimum          *float64               `json:"minimum,omitempty"`
+	Maximum          *float64               `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64               `json:"exclusiveMaximum,omitempty"`
+}
+
+// jsonSchemaDocument is a standalone JSON Schema holding type definitions.
+type jsonSchemaDocument struct {
+	Schema      string                 `json:"$schema"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Defs        map[string]*jsonSchema `json:"$defs"`
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) document defining every
+// XSD type of the WSDL under $defs, including the enumeration, pattern,
+// length and range facets JSON Schema can express.
+func (g *GoWSDL) JSONSchema() ([]byte, error) {
+	err := g.load()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &schemaConverter{refPrefix: "#/$defs/"}
+	return json.MarshalIndent(&jsonSchemaDocument{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		Title:       g.wsdl.TargetNamespace,
+		Description: strings.TrimSpace(g.wsdl.Doc),
+		Defs:        c.definitions(g.wsdl.Types.Schemas),
+	}, "", "  ")
+}
+
+// xsd2JSONTypes maps XSD built-in types to JSON Schema types and formats.
+var xsd2JSONTypes = map[string]jsonSchema{
+	"string":             {Type: "string"},
+	"normalizedstring":   {Type: "string"},
+	"token":              {Type: "string"},
+	"language":           {Type: "string"},
+	"name":               {Type: "string"},
+	"ncname":             {Type: "string"},
+	"nmtoken":            {Type: "string"},
+	"id":                 {Type: "string"},
+	"idref":              {Type: "string"},
+	"qname":              {Type: "string"},
+	"anyuri":             {Type: "string", Format: "uri"},
+	"base64binary":       {Type: "string", Format: "byte"},
+	"hexbinary":          {Type: "string"},
+	"boolean":            {Type: "boolean"},
+	"byte":               {Type: "integer", Format: "int32"},
+	"short":              {Type: "integer", Format: "int32"},
+	"int":                {Type: "integer", Format: "int32"},
+	"long":               {Type: "integer", Format: "int64"},
+	"integer":            {Type: "integer"},
+	"nonnegativeinteger": {Type: "integer"},
+	"positiveinteger":    {Type: "integer"},
+	"nonpositiveinteger": {Type: "integer"},
+	"negativeinteger":    {Type: "integer"},
+	"unsignedbyte":       {Type: "integer", Format: "int32"},
+	"unsignedshort":      {Type: "integer", Format: "int32"},
+	"unsignedint":        {Type: "integer", Format: "int64"},
+	"unsignedlong":       {Type: "integer"},
+	"decimal":            {Type: "number"},
+	"float":              {Type: "number", Format: "float"},
+	"double":             {Type: "number", Format: "double"},
+	"datetime":           {Type: "string", Format: "date-time"},
+	"date":               {Type: "string", Format: "date"},
+	"time":               {Type: "string", Format: "time"},
+	"duration":           {Type: "string"},
+	"anytype":            {},
+	"anysimpletype":      {},
+}
+
+// schemaConverter turns parsed XSD definitions into JSON Schemas. Named
+// types are referenced as refPrefix followed by their name.
+type schemaConverter struct {
+	refPrefix string
+}
+
+// typeRef returns the schema of a value of the given XSD type.
+func (c *schemaConverter) typeRef(xsdType string) *jsonSchema {
+	name := localName(xsdType)
+	if builtin, ok := xsd2JSONTypes[strings.ToLower(name)]; ok {
+		return &builtin
+	}
+	return &jsonSchema{Ref: c.refPrefix + name}
+}
+
+// simpleType converts a simple type definition.
+func (c *schemaConverter) simpleType(st *XSDSimpleType) *jsonSchema {
+	var s *jsonSchema
+	switch {
+	case st.List.ItemType != "":
+		s = &jsonSchema{Type: "array", Items: c.typeRef(st.List.ItemType)}
+	case st.Restriction.Base != "":
+		s = c.typeRef(st.Restriction.Base)
+	default:
+		s = &jsonSchema{Type: "string"}
+	}
+	if s.Ref != "" && hasFacets(&st.Restriction) {
+		// Facets next to a $ref restrict the referenced type.
+		s = &jsonSchema{AllOf: []*jsonSchema{s}}
+	}
+	addFacets(s, &st.Restriction)
+	s.Description = strings.TrimSpace(st.Doc)
+	return s
+}
+
+func hasFacets(r *XSDRestriction) bool {
+	return len(r.Enumeration) > 0 || r.Pattern.Value != "" ||
+		r.Length.Value != "" || r.MinLength.Value != "" || r.MaxLength.Value != "" ||
+		r.MinInclusive.Value != "" || r.MaxInclusive.Value != "" ||
+		r.MinExclusive.Value != "" || r.MaxExclusive.Value != ""
+}
+
+// addFacets copies the facets of r that JSON Schema can express onto s.
+func addFacets(s *jsonSchema, r *XSDRestriction) {
+	for _, e := range r.Enumeration {
+		s.Enum = append(s.Enum, e.Value)
+	}
+	if r.Pattern.Value != "" {
+		// XSD patterns match the whole value.
+		s.Pattern = "^(?:" + r.Pattern.Value + ")$"
+	}
+	if s.Type == "array" {
+		return
+	}
+	s.MinLength = parseFacetInt(r.MinLength.Value)
+	s.MaxLength = parseFacetInt(r.MaxLength.Value)
+	if length := parseFacetInt(r.Length.Value); length != nil {
+		s.MinLength, s.MaxLength = length, length
+	}
+	s.Minimum = parseFacetFloat(r.MinInclusive.Value)
+	s.Maximum = parseFacetFloat(r.MaxInclusive.Value)
+	s.ExclusiveMinimum = parseFacetFloat(r.MinExclusive.Value)
+	s.ExclusiveMaximum = parseFacetFloat(r.MaxExclusive.Value)
+}
+
+func parseFacetInt(value string) *int {
+	i, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	return &i
+}
+
+// parseFacetFloat parses numeric bounds; bounds of other types, such as
+// dates, cannot be expressed and yield nil.
+func parseFacetFloat(value string) *float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+// complexType converts a complex type definition into an object schema.
+func (c *schemaConverter) complexType(ct *XSDComplexType) *jsonSchema {
+	s := &jsonSchema{Type: "object"}
+	for _, elements := range [][]*XSDElement{ct.Sequence, ct.Choice, ct.SequenceChoice, ct.All} {
+		for _, el := range elements {
+			c.addElement(s, el)
+		}
+	}
+	for _, attr := range ct.Attributes {
+		c.addAttribute(s, attr)
+	}
+
+	if ext := ct.ComplexContent.Extension; ext.Base != "" {
+		for i := range ext.Sequence {
+			c.addElement(s, &ext.Sequence[i])
+		}
+		for _, attr := range ext.Attributes {
+			c.addAttribute(s, attr)
+		}
+		s = &jsonSchema{AllOf: []*jsonSchema{c.typeRef(ext.Base), s}}
+	}
+	if ext := ct.SimpleContent.Extension; ext.Base != "" {
+		c.addProperty(s, "Value", c.typeRef(ext.Base), true)
+		for _, attr := range ext.Attributes {
+			c.addAttribute(s, attr)
+		}
+	}
+
+	return s
+}
+
+// element returns the schema of an element's value.
+func (c *schemaConverter) element(el *XSDElement) *jsonSchema {
+	var s *jsonSchema
+	switch {
+	case el.Ref != "":
+		s = c.typeRef(el.Ref)
+	case el.Type != "":
+		s = c.typeRef(el.Type)
+	case el.ComplexType != nil:
+		s = c.complexType(el.ComplexType)
+	case el.SimpleType != nil:
+		s = c.simpleType(el.SimpleType)
+	default:
+		s = &jsonSchema{}
+	}
+	if el.Doc != "" && s.Ref == "" {
+		s.Description = strings.TrimSpace(el.Doc)
+	}
+	if el.MaxOccurs == "unbounded" || (el.MaxOccurs != "" && el.MaxOccurs != "0" && el.MaxOccurs != "1") {
+		s = &jsonSchema{Type: "array", Items: s}
+	}
+	return s
+}
+
+func (c *schemaConverter) addElement(s *jsonSchema, el *XSDElement) {
+	name := el.Name
+	if el.Ref != "" {
+		name = localName(el.Ref)
+	}
+	c.addProperty(s, name, c.element(el), el.MinOccurs != "0")
+}
+
+func (c *schemaConverter) addAttribute(s *jsonSchema, attr *XSDAttribute) {
+	name := attr.Name
+	var value *jsonSchema
+	switch {
+	case attr.Ref != "":
+		name = localName(attr.Ref)
+		value = &jsonSchema{Type: "string"}
+	case attr.SimpleType != nil:
+		value = c.simpleType(attr.SimpleType)
+	default:
+		value = c.typeRef(attr.Type)
+	}
+	c.addProperty(s, name, value, attr.Use == "required")
+}
+
+func (c *schemaConverter) addProperty(s *jsonSchema, name string, value *jsonSchema, required bool) {
+	if s.Properties == nil {
+		s.Properties = make(map[string]*jsonSchema)
+	}
+	s.Properties[name] = value
+	if required {
+		s.Required = append(s.Required, name)
+	}
+}
+
+// definitions converts every named type and every global element declaring
+// its type inline. Elements whose names clash with a type are left out;
+// references to them resolve to the type instead.
+func (c *schemaConverter) definitions(schemas []*XSDSchema) map[string]*jsonSchema {
+	defs := make(map[string]*jsonSchema)
+	for _, schema := range schemas {
+		for _, st := range schema.SimpleType {
+			defs[st.Name] = c.simpleType(st)
+		}
+		for _, ct := range schema.ComplexTypes {
+			defs[ct.Name] = c.complexType(ct)
+		}
+	}
+	for _, schema := range schemas {
+		for _, el := range schema.Elements {
+			if el.Type != "" || defs[el.Name] != nil {
+				continue
+			}
+			defs[el.Name] = c.element(el)
+		}
+	}
+	return defs
+}
+
+// messageSchema returns the schema of the payload of a WSDL message, or nil
+// when the message has no parts.
+func (c *schemaConverter) messageSchema(w *WSDL, message string) *jsonSchema {
+	message = localName(message)
+	for _, msg := range w.Messages {
+		if msg.Name != message || len(msg.Parts) == 0 {
+			continue
+		}
+		part := msg.Parts[0]
+		if part.Type != "" {
+			return c.typeRef(part.Type)
+		}
+		name := localName(part.Element)
+		for _, schema := range w.Types.Schemas {
+			for _, el := range schema.Elements {
+				if el.Name == name && el.Type != "" {
+					return c.typeRef(el.Type)
+				}
+			}
+		}
+		return &jsonSchema{Ref: c.refPrefix + name}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/unsupported.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := g.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc jsonSchemaDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	code := doc.Defs["Code"]
+	if code == nil || code.Type != "string" || code.Pattern != "^(?:[A-Z]{3})$" ||
+		code.MaxLength == nil || *code.MaxLength != 3 {
+		t.Errorf("unexpected Code schema %s", mustJSON(code))
+	}
+	amount := doc.Defs["Amount"]
+	if amount == nil || amount.Type != "number" || amount.ExclusiveMinimum == nil || *amount.ExclusiveMinimum != 0 {
+		t.Errorf("unexpected Amount schema %s", mustJSON(amount))
+	}
+	list := doc.Defs["CodeList"]
+	if list == nil || list.Type != "array" || list.Items.Ref != "#/$defs/Code" {
+		t.Errorf("unexpected CodeList schema %s", mustJSON(list))
+	}
+	envelope := doc.Defs["Envelope"]
+	if envelope == nil || envelope.Properties["Code"].Ref != "#/$defs/Code" ||
+		len(envelope.Required) != 1 || envelope.Required[0] != "Code" {
+		t.Errorf("unexpected Envelope schema %s", mustJSON(envelope))
+	}
+
+	g, err = NewGoWSDL("fixtures/chromedata.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = g.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc = jsonSchemaDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if driveTrain := doc.Defs["DriveTrain"]; driveTrain == nil || len(driveTrain.Enum) == 0 {
+		t.Errorf("unexpected DriveTrain schema %s", mustJSON(driveTrain))
+	}
+}
+
+func mustJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
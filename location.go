@@ -6,13 +6,16 @@ package gowsdl
 
 import (
 	"net/url"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
 // A Location encapsulate information about the loc of WSDL/XSD.
 //
-// It could be either URL or an absolute file path.
+// It could be either URL or an absolute file path. URLs of schemes other
+// than http and https are read by the fetchers registered with
+// RegisterFetcher.
 type Location struct {
 	u *url.URL
 	f string
@@ -20,11 +23,17 @@ type Location struct {
 
 // ParseLocation parses a rawloc into a Location structure.
 //
-// If rawloc is URL then it should be absolute.
+// If rawloc is URL then it should be absolute. file:// URLs, Windows drive
+// letter and UNC paths are file paths.
 // Relative file path will be converted into absolute path.
 func ParseLocation(rawloc string) (*Location, error) {
-	u, _ := url.Parse(rawloc)
-	if u.Scheme != "" {
+	if isWindowsPath(rawloc) {
+		return &Location{f: rawloc}, nil
+	}
+	if u, err := url.Parse(rawloc); err == nil && u.Scheme != "" {
+		if u.Scheme == "file" {
+			return &Location{f: fileURLPath(u)}, nil
+		}
 		return &Location{u: u}, nil
 	}
 
@@ -39,6 +48,22 @@ func ParseLocation(rawloc string) (*Location, error) {
 // Parse parses path in the context of the receiver. The provided path may be relative or absolute.
 // Parse returns nil, err on parse failure.
 func (r *Location) Parse(ref string) (*Location, error) {
+	if r.u != nil && r.u.Opaque != "" {
+		// Opaque URLs, such as bundle:wsdl/orders.wsdl, hold relative paths
+		// in bundles.
+		refURL, err := url.Parse(ref)
+		if err != nil {
+			return nil, err
+		}
+		if refURL.Scheme != "" {
+			return ParseLocation(ref)
+		}
+		u := url.URL{Scheme: r.u.Scheme, Opaque: path.Join(path.Dir(r.u.Opaque), refURL.Path)}
+		if path.IsAbs(refURL.Path) {
+			u.Opaque = path.Clean(refURL.Path)[1:]
+		}
+		return &Location{u: &u}, nil
+	}
 	if r.u != nil {
 		u, err := r.u.Parse(fixReference(ref))
 		if err != nil {
@@ -47,35 +72,96 @@ func (r *Location) Parse(ref string) (*Location, error) {
 		return &Location{u: u}, nil
 	}
 
-	if filepath.IsAbs(ref) {
+	if filepath.IsAbs(ref) || isWindowsPath(ref) {
 		return &Location{f: ref}, nil
 	}
 
 	if u, err := url.Parse(ref); err == nil {
+		if u.Scheme == "file" {
+			return &Location{f: fileURLPath(u)}, nil
+		}
 		if u.Scheme != "" {
 			return &Location{u: u}, nil
 		}
 	}
 
-	return &Location{f: filepath.Join(filepath.Dir(r.f), ref)}, nil
+	return &Location{f: joinPath(r.f, ref)}, nil
+}
+
+// isWindowsPath reports whether loc is an absolute Windows path, with a
+// drive letter or UNC, whatever the OS gowsdl runs on.
+func isWindowsPath(loc string) bool {
+	if strings.HasPrefix(loc, `\\`) {
+		return true
+	}
+	return len(loc) >= 3 && loc[1] == ':' && (loc[2] == '\\' || loc[2] == '/') &&
+		('a' <= loc[0] && loc[0] <= 'z' || 'A' <= loc[0] && loc[0] <= 'Z')
+}
+
+// fileURLPath returns the path of a file:// URL, such as /tmp/my.wsdl,
+// C:\wsdl\my.wsdl for file:///C:/wsdl/my.wsdl, or a UNC path when the URL
+// names a host.
+func fileURLPath(u *url.URL) string {
+	p := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		return filepath.FromSlash("//" + u.Host + p)
+	}
+	if len(p) >= 3 && p[0] == '/' && isWindowsPath(p[1:]) {
+		return strings.Replace(p[1:], "/", `\`, -1)
+	}
+	return filepath.FromSlash(p)
+}
+
+// joinPath resolves the relative reference ref against the file base.
+// Windows bases are joined the same way on any OS.
+func joinPath(base, ref string) string {
+	if !isWindowsPath(base) {
+		return filepath.Join(filepath.Dir(base), filepath.FromSlash(ref))
+	}
+	dir := path.Dir(strings.Replace(base, `\`, "/", -1))
+	joined := path.Join(dir, strings.Replace(ref, `\`, "/", -1))
+	if strings.HasPrefix(base, `\\`) {
+		// path.Join cleans the leading // of UNC paths.
+		joined = "/" + joined
+	}
+	return strings.Replace(joined, "/", `\`, -1)
 }
 
 // IsFile determines whether the Location contains a file path.
-func (r *Location) isFile() bool {
+func (r *Location) IsFile() bool {
 	return r.f != ""
 }
 
-// IsFile determines whether the Location contains URL.
-func (r *Location) isURL() bool {
+// IsURL determines whether the Location contains URL.
+func (r *Location) IsURL() bool {
 	return r.u != nil
 }
 
+// FilePath returns the absolute file path of the Location, or an empty
+// string for URLs.
+func (r *Location) FilePath() string {
+	return r.f
+}
+
+// URL returns a copy of the URL of the Location, or nil for file paths.
+func (r *Location) URL() *url.URL {
+	if r.u == nil {
+		return nil
+	}
+	u := *r.u
+	if r.u.User != nil {
+		user := *r.u.User
+		u.User = &user
+	}
+	return &u
+}
+
 // String reassembles the Location either into a valid URL string or a file path.
 func (r *Location) String() string {
-	if r.isFile() {
+	if r.IsFile() {
 		return r.f
 	}
-	if r.isURL() {
+	if r.IsURL() {
 		return r.u.String()
 	}
 	return ""
@@ -16,7 +16,7 @@ func TestLocation_ParseLocation_URL(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !r.isURL() || r.isFile() {
+	if !r.IsURL() || r.IsFile() {
 		t.Error("Location should be a URL type")
 	}
 	if r.String() != "http://example.org/my.wsdl" {
@@ -46,7 +46,7 @@ func TestLocation_Parse_URL(t *testing.T) {
 			continue
 		}
 
-		if !r.isURL() || r.isFile() {
+		if !r.IsURL() || r.IsFile() {
 			t.Error("Location should be a URL type")
 		}
 		if r.String() != test.expected {
@@ -69,7 +69,7 @@ func TestLocation_ParseLocation_File(t *testing.T) {
 			continue
 		}
 
-		if r.isURL() || !r.isFile() {
+		if r.IsURL() || !r.IsFile() {
 			t.Error("Location should be a FILE type")
 			continue
 		}
@@ -104,7 +104,7 @@ func TestLocation_Parse_File(t *testing.T) {
 			continue
 		}
 
-		if r.isURL() || !r.isFile() {
+		if r.IsURL() || !r.IsFile() {
 			t.Error("Location should be a File type")
 			continue
 		}
@@ -136,7 +136,7 @@ func TestLocation_Parse_FileToURL(t *testing.T) {
 			continue
 		}
 
-		if !r.isURL() || r.isFile() {
+		if !r.IsURL() || r.IsFile() {
 			t.Error("Location should be a URL type")
 			continue
 		}
@@ -145,3 +145,117 @@ func TestLocation_Parse_FileToURL(t *testing.T) {
 		}
 	}
 }
+
+func TestLocation_ParseLocation_FileURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"file:///tmp/my.wsdl", filepath.FromSlash("/tmp/my.wsdl")},
+		{"file://localhost/tmp/my.wsdl", filepath.FromSlash("/tmp/my.wsdl")},
+		{"file:///C:/wsdl/my.wsdl", `C:\wsdl\my.wsdl`},
+		{"file://server/share/my.wsdl", filepath.FromSlash("//server/share/my.wsdl")},
+		{`C:\wsdl\my.wsdl`, `C:\wsdl\my.wsdl`},
+		{`\\server\share\my.wsdl`, `\\server\share\my.wsdl`},
+	}
+	for _, test := range tests {
+		r, err := ParseLocation(test.name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if r.IsURL() || !r.IsFile() {
+			t.Error("Location should be a FILE type")
+			continue
+		}
+		if r.String() != test.expected {
+			t.Error("got " + r.String() + " wanted " + test.expected)
+		}
+	}
+}
+
+func TestLocation_Parse_WindowsFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{`C:\wsdl\my.wsdl`, "some.xsd", `C:\wsdl\some.xsd`},
+		{`C:\wsdl\my.wsdl`, "xsd/some.xsd", `C:\wsdl\xsd\some.xsd`},
+		{`C:\wsdl\my.wsdl`, `..\some.xsd`, `C:\some.xsd`},
+		{`C:\wsdl\my.wsdl`, `D:\xsd\some.xsd`, `D:\xsd\some.xsd`},
+		{`\\server\share\my.wsdl`, "some.xsd", `\\server\share\some.xsd`},
+		{"file:///C:/wsdl/my.wsdl", "some.xsd", `C:\wsdl\some.xsd`},
+		{`C:\wsdl\my.wsdl`, "file:///C:/xsd/some.xsd", `C:\xsd\some.xsd`},
+	}
+	for _, test := range tests {
+		r, err := ParseLocation(test.name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		r, err = r.Parse(test.ref)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if r.IsURL() || !r.IsFile() {
+			t.Error("Location should be a File type")
+			continue
+		}
+		if r.String() != test.expected {
+			t.Error("got " + r.String() + " wanted " + test.expected)
+		}
+	}
+}
+
+func TestLocation_Accessors(t *testing.T) {
+	r, err := ParseLocation("s3://user:secret@artifacts/orders.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := r.URL()
+	if u == nil || u.Scheme != "s3" || u.Host != "artifacts" || r.FilePath() != "" {
+		t.Errorf("got URL %v and file path %q", u, r.FilePath())
+	}
+	u.Host = "other"
+	if r.String() != "s3://user:secret@artifacts/orders.wsdl" {
+		t.Errorf("changing the URL changed the location to %s", r)
+	}
+
+	f := os.TempDir() + string(os.PathSeparator) + "orders.wsdl"
+	if r, err = ParseLocation(f); err != nil {
+		t.Fatal(err)
+	}
+	if r.URL() != nil || r.FilePath() != f {
+		t.Errorf("got URL %v and file path %q, want %q", r.URL(), r.FilePath(), f)
+	}
+}
+
+func TestLocation_Parse_Opaque(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{"bundle:wsdl/orders.wsdl", "orders.xsd", "bundle:wsdl/orders.xsd"},
+		{"bundle:wsdl/orders.wsdl", "../types/orders.xsd", "bundle:types/orders.xsd"},
+		{"bundle:wsdl/orders.wsdl", "/types/orders.xsd", "bundle:types/orders.xsd"},
+		{"bundle:orders.wsdl", "http://example.org/some.xsd", "http://example.org/some.xsd"},
+	}
+	for _, test := range tests {
+		r, err := ParseLocation(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err = r.Parse(test.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.IsURL() || r.String() != test.expected {
+			t.Errorf("%s resolved against %s: got %s, want %s", test.ref, test.name, r, test.expected)
+		}
+	}
+}
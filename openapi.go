@@ -0,0 +1,143 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string                      `json:"openapi"`
+	Info       openAPIInfo                 `json:"info"`
+	Servers    []openAPIServer             `json:"servers,omitempty"`
+	Paths      map[string]*openAPIPathItem `json:"paths"`
+	Components openAPIComponents           `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIPathItem struct {
+	Post *openAPIOperation `json:"post"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	SOAPAction  string                      `json:"x-soap-action,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                    `json:"required"`
+	Content  map[string]openAPIMedia `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                  `json:"description"`
+	Content     map[string]openAPIMedia `json:"content,omitempty"`
+}
+
+type openAPIMedia struct {
+	Schema *jsonSchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*jsonSchema `json:"schemas"`
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the WSDL as JSON. Every
+// operation of every client becomes a POST on /{client}/{operation} carrying
+// its SOAP action in x-soap-action, and the XSD types become component
+// schemas.
+func (g *GoWSDL) OpenAPI() ([]byte, error) {
+	err := g.load()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &schemaConverter{refPrefix: "#/components/schemas/"}
+	doc := &openAPIDocument{
+		// 3.1 shares JSON Schema 2020-12 with the JSON Schema export.
+		OpenAPI: "3.1.0",
+		Info: openAPIInfo{
+			Title:       g.wsdl.Name,
+			Description: strings.TrimSpace(g.wsdl.Doc),
+			Version:     "1.0",
+		},
+		Paths:      make(map[string]*openAPIPathItem),
+		Components: openAPIComponents{Schemas: c.definitions(g.wsdl.Types.Schemas)},
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = g.wsdl.TargetNamespace
+	}
+
+	seen := make(map[string]bool)
+	for _, client := range g.portClients() {
+		if client.Address != "" && !seen[client.Address] {
+			seen[client.Address] = true
+			doc.Servers = append(doc.Servers, openAPIServer{URL: client.Address, Description: client.Name})
+		}
+
+		for _, op := range client.PortType.Operations {
+			if op.Input.Message == "" {
+				continue
+			}
+			operation := &openAPIOperation{
+				OperationID: client.Name + "_" + op.Name,
+				Summary:     strings.TrimSpace(op.Doc),
+				Tags:        []string{client.Name},
+				SOAPAction:  g.soapAction(op.Name, client.Binding),
+				Responses:   make(map[string]*openAPIResponse),
+			}
+			if s := c.messageSchema(g.wsdl, op.Input.Message); s != nil {
+				operation.RequestBody = &openAPIRequestBody{
+					Required: true,
+					Content:  map[string]openAPIMedia{"text/xml": {Schema: s}},
+				}
+			}
+			if op.Output.Message == "" {
+				operation.Responses["202"] = &openAPIResponse{Description: "Accepted"}
+			} else {
+				response := &openAPIResponse{Description: "OK"}
+				if s := c.messageSchema(g.wsdl, op.Output.Message); s != nil {
+					response.Content = map[string]openAPIMedia{"text/xml": {Schema: s}}
+				}
+				operation.Responses["200"] = response
+			}
+			operation.Responses["500"] = &openAPIResponse{Description: "SOAP fault"}
+
+			doc.Paths["/"+client.Name+"/"+op.Name] = &openAPIPathItem{Post: operation}
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// soapAction returns the SOAP action of an operation in a binding.
+func (g *GoWSDL) soapAction(operation, binding string) string {
+	for _, b := range g.wsdl.Binding {
+		if b.Name != binding {
+			continue
+		}
+		for _, op := range b.Operations {
+			if op.Name == operation {
+				return op.SOAPOperation.SOAPAction
+			}
+		}
+	}
+	return ""
+}
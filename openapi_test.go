@@ -0,0 +1,49 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/multiport.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := g.OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("unexpected OpenAPI version %s", doc.OpenAPI)
+	}
+	if len(doc.Servers) != 2 || doc.Servers[0].URL != "http://orders.example.org/soap" {
+		t.Errorf("unexpected servers %+v", doc.Servers)
+	}
+
+	item := doc.Paths["/OrdersPort/PlaceOrder"]
+	if item == nil || item.Post == nil {
+		t.Fatal("PlaceOrder should be exported as a POST")
+	}
+	if item.Post.SOAPAction != "urn:PlaceOrder" {
+		t.Errorf("unexpected SOAP action %s", item.Post.SOAPAction)
+	}
+	if ref := item.Post.RequestBody.Content["text/xml"].Schema.Ref; ref != "#/components/schemas/PlaceOrder" {
+		t.Errorf("unexpected request schema %s", ref)
+	}
+
+	invoice := doc.Components.Schemas["GetInvoiceResponse"]
+	if invoice == nil || invoice.Properties["Total"].Type != "number" {
+		t.Errorf("unexpected GetInvoiceResponse schema %+v", invoice)
+	}
+}
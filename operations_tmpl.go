@@ -5,65 +5,953 @@
 package gowsdl
 
 var opsTmpl = `
+{{define "RequestParam"}}{{if .In}}request {{.In.Type}}{{else if ne .Type ""}}request *{{.Type}}{{end}}{{end}}
+{{define "CallParams"}}{{if or .In (ne .Type "")}}{{template "RequestParam" .}}, {{end}}opts ...CallOption{{end}}
+{{define "XMLValue"}}{{if generics}}xmlValue[{{.}}]{{else}}struct {
+	XMLName xml.Name
+	Value   {{.}} ` + "`" + `xml:",chardata"` + "`" + `
+}{{end}}{{end}}
+{{define "RequestArg"}}{{if and .In .In.Wrapper}}&{{.In.Wrapper}}{ {{.In.Field}}: request}{{else if .In}}&{{template "XMLValue" .In.Type}}{XMLName: xml.Name{Space: {{printf "%q" .In.Space}}, Local: {{printf "%q" .In.Name}}}, Value: request}{{else if ne .Type ""}}request{{else}}nil{{end}}{{end}}
+{{define "ResponseValue"}}{{if .Wrapper}}{{.Wrapper}}{{else}}{{template "XMLValue" .Type}}{{end}}{{end}}
+{{define "LoggingOperation"}}
+	{{$portType := .PortType}}
+	{{$httpVerb := .HTTPVerb}}
+	{{with .Op}}
+		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
+		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+		{{$in := messageValue .Input.Message}}
+		{{$out := messageValue .Output.Message}}
+		{{$request := dict "In" $in "Type" $requestType}}
+		{{$resultType := $responseType}}{{with $out}}{{$resultType = .Type}}{{end}}
+		{{$name := operationName .}}
+		{{$arg := ""}}{{if or $in (ne $requestType "")}}{{$arg = "request"}}{{end}}
+		{{if or $httpVerb (eq .Input.Message "")}}
+		{{else if eq .Output.Message ""}}
+		// {{$name}} logs the call to the wrapped client.
+		func (c *Logging{{$portType}}) {{$name}} ({{template "CallParams" $request}}) error {
+			start := time.Now()
+			err := c.{{$portType}}.{{$name}}({{if $arg}}request, {{end}}opts...)
+			logCall(c.Logger, {{printf "%q" $name}}, time.Since(start), {{if $arg}}request{{else}}nil{{end}}, nil, err)
+			return err
+		}
+		{{else if ne $resultType ""}}
+		// {{$name}} logs the call to the wrapped client.
+		func (c *Logging{{$portType}}) {{$name}} ({{template "CallParams" $request}}) ({{if $out}}{{$out.Type}}{{else}}*{{$responseType}}{{end}}, error) {
+			start := time.Now()
+			response, err := c.{{$portType}}.{{$name}}({{if $arg}}request, {{end}}opts...)
+			logCall(c.Logger, {{printf "%q" $name}}, time.Since(start), {{if $arg}}request{{else}}nil{{end}}, response, err)
+			return response, err
+		}
+		{{end}}
+	{{end}}
+{{end}}
+{{define "CachingOperation"}}
+	{{$portType := .PortType}}
+	{{$httpVerb := .HTTPVerb}}
+	{{with .Op}}
+		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
+		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+		{{$in := messageValue .Input.Message}}
+		{{$out := messageValue .Output.Message}}
+		{{$request := dict "In" $in "Type" $requestType}}
+		{{$resultType := $responseType}}{{with $out}}{{$resultType = .Type}}{{end}}
+		{{$name := operationName .}}
+		{{if and (not $httpVerb) (ne .Input.Message "") (ne .Output.Message "") (ne $resultType "")}}
+		// {{$name}} returns the cached response to request while its TTL runs,
+		// and calls the wrapped client otherwise. Calls with options are not
+		// cached. The cached response is shared and must not be modified.
+		func (c *Caching{{$portType}}) {{$name}} ({{template "CallParams" $request}}) ({{if $out}}{{$out.Type}}{{else}}*{{$responseType}}{{end}}, error) {
+			ttl, ok := c.TTL[{{printf "%q" $name}}]
+			if !ok || c.Cache == nil || len(opts) > 0 {
+				return c.{{$portType}}.{{$name}}({{if or $in (ne $requestType "")}}request, {{end}}opts...)
+			}
+			key, err := cacheKey({{printf "%q" $name}}, soapHeaders(c.{{$portType}}), {{if or $in (ne $requestType "")}}request{{else}}nil{{end}})
+			if err != nil {
+				return c.{{$portType}}.{{$name}}({{if or $in (ne $requestType "")}}request, {{end}}opts...)
+			}
+			if value, ok := c.Cache.Get(key); ok {
+				if response, ok := value.({{if $out}}{{$out.Type}}{{else}}*{{$responseType}}{{end}}); ok {
+					return response, nil
+				}
+			}
+
+			response, err := c.{{$portType}}.{{$name}}({{if or $in (ne $requestType "")}}request, {{end}}opts...)
+			if err == nil {
+				c.Cache.Set(key, response, ttl)
+			}
+			return response, err
+		}
+		{{end}}
+	{{end}}
+{{end}}
+{{define "Operation"}}
+	{{$portType := .PortType}}
+	{{$client := .Client}}
+	{{$binding := .Binding}}
+	{{$httpVerb := .HTTPVerb}}
+	{{$signature := .Signature}}
+	{{with .Op}}
+		{{$faults := len .Faults}}
+		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
+		{{$soapAction := findSOAPAction .Name $binding}}
+		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+		{{$in := messageValue .Input.Message}}
+		{{$out := messageValue .Output.Message}}
+		{{$request := dict "In" $in "Type" $requestType}}
+		{{$resultType := $responseType}}{{with $out}}{{$resultType = .Type}}{{end}}
+		{{$action := actionName $portType .}}
+
+		{{if and (not $httpVerb) (not $signature)}}
+		// {{$action}} is the SOAPAction of {{$portType}}.{{operationName .}}.
+		const {{$action}} = {{printf "%q" $soapAction}}
+
+		{{messageComment .Input.Message}}{{end}}{{messageComment .Output.Message}}
+		{{/*if ne $soapAction ""*/}}
+		{{if and (not $httpVerb) (ne .Input.Message "") (or (eq .Output.Message "") (ne $resultType ""))}}
+		{{if not $signature}}
+		{{if or (and (not $in) (ne $requestType "")) (and (ne .Output.Message "") (not $out))}}
+		// Messages of {{$portType}}.{{operationName .}}.
+		var ({{if and (not $in) (ne $requestType "")}}
+			_ *{{$requestType}}{{end}}{{if and (ne .Output.Message "") (not $out)}}
+			_ *{{$responseType}}{{end}}
+		)
+		{{end}}
+		{{end}}
+		// {{operationName .}} calls the {{.Name}} operation of the {{$portType}} port type with SOAPAction [{{$action}}],
+		// sending {{if and $in $in.Wrapper}}the {{$in.Field}} of a [{{$in.Wrapper}}] request{{else if $in}}the {{$in.Name}} part of type {{$in.Type}}{{else if ne $requestType ""}}a [{{$requestType}}] request{{else}}an empty request{{end}}{{if eq .Output.Message ""}} without waiting for a response.
+		{{- else}} and returning {{if and $out $out.Wrapper}}the {{$out.Field}} of the [{{$out.Wrapper}}] response{{else if $out}}the {{$out.Name}} part of type {{$out.Type}}{{else}}the [{{$responseType}}] response{{end}}.{{end}}
+		{{- end}}
+		{{- if gt $faults 0}}
+		// Error can be either of the following types:
+		// {{range .Faults}}
+		//   - {{.Name}} {{.Doc}}{{end}}{{end}}
+		{{- if ne .Doc ""}}
+		/* {{.Doc}} */{{end}}
+		{{- if $httpVerb}}
+		{{$op := httpOperation . $binding}}
+		{{if and (ne $op.Encoding "") $op.XMLOutput (ne $resultType "")}}
+		// {{operationName .}} sends an HTTP {{$httpVerb}} request to {{$op.Location}}.
+		{{if $signature}}{{operationName .}} ({{range $op.Params}}{{.Arg}} {{.Type}}, {{end}}opts ...CallOption) ({{if $out}}{{$out.Type}}{{else}}*{{$responseType}}{{end}}, error)
+		{{else -}}
+		func (service *{{$client}}) {{operationName .}} ({{range $op.Params}}{{.Arg}} {{.Type}}, {{end}}opts ...CallOption) ({{if $out}}{{$out.Type}}{{else}}*{{$responseType}}{{end}}, error) {
+			response := new({{if $out}}{{template "ResponseValue" $out}}{{else}}{{$responseType}}{{end}})
+			err := service.client.CallHTTP(context.Background(), "{{$httpVerb}}", {{printf "%q" $op.Location}}, {{$op.Encoding}}, url.Values{ {{range $op.Params}}
+				{{printf "%q" .Name}}: { {{.Value}} },{{end}}
+			}, response, opts...)
+			{{- if $out}}
+			return response.{{$out.Field}}, err
+			{{- else}}
+			if err != nil {
+				return nil, err
+			}
+
+			return response, nil
+			{{- end}}
+		}
+		{{end}}
+		{{else}}
+		// gowsdl: unsupported HTTP operation {{.Name}} was skipped
+		{{end}}
+		{{else if eq .Input.Message ""}}
+		// gowsdl: unsupported notification operation {{.Name}} was skipped
+		{{else if eq .Output.Message ""}}
+		{{if $signature}}{{operationName .}} ({{template "CallParams" $request}}) error
+		{{else -}}
+		func (service *{{$client}}) {{operationName .}} ({{template "CallParams" $request}}) error {
+			return service.client.call(context.Background(), {{printf "%q" (operationName .)}}, {{$action}}, {{template "RequestArg" $request}}, nil, nil, opts...)
+		}
+		{{end}}
+
+		{{if generateAsync}}
+		// {{operationName .}}Async calls {{operationName .}} in a new goroutine. The returned
+		// channel receives the call error, nil on success, and is then closed.
+		{{if $signature}}{{operationName .}}Async (ctx context.Context, {{template "CallParams" $request}}) <-chan error
+		{{else -}}
+		func (service *{{$client}}) {{operationName .}}Async (ctx context.Context, {{template "CallParams" $request}}) <-chan error {
+			ch := make(chan error, 1)
+			go func() {
+				defer close(ch)
+				ch <- service.client.call(ctx, {{printf "%q" (operationName .)}}, {{$action}}, {{template "RequestArg" $request}}, nil, nil, opts...)
+			}()
+
+			return ch
+		}
+		{{end}}
+		{{end}}
+		{{else if eq $resultType ""}}
+		// gowsdl: unsupported operation {{.Name}} without a response type was skipped
+		{{else}}
+		{{if $signature}}{{operationName .}} ({{template "CallParams" $request}}) ({{if $out}}{{$out.Type}}{{else}}*{{$responseType}}{{end}}, error)
+		{{else -}}
+		func (service *{{$client}}) {{operationName .}} ({{template "CallParams" $request}}) ({{if $out}}{{$out.Type}}{{else}}*{{$responseType}}{{end}}, error) {
+			response := new({{if $out}}{{template "ResponseValue" $out}}{{else}}{{$responseType}}{{end}})
+			err := service.client.call(context.Background(), {{printf "%q" (operationName .)}}, {{$action}}, {{template "RequestArg" $request}}, response, nil, opts...)
+			{{- if $out}}
+			return response.{{$out.Field}}, err
+			{{- else}}
+			if err != nil {
+				return nil, err
+			}
+
+			return response, nil
+			{{- end}}
+		}
+		{{end}}
+
+		{{$headers := responseHeaders .Name $binding}}
+		{{if $headers}}
+		{{$result := resultName $portType .}}
+		{{if $signature}}{{operationName .}}WithHeaders ({{template "CallParams" $request}}) (*{{$result}}, error)
+		{{else -}}
+		// {{$result}} is the response of {{$portType}}.{{operationName .}} with its headers, which
+		// are nil when the response does not carry them.
+		type {{$result}} struct {
+			Response {{if $out}}{{$out.Type}}{{else}}*{{$responseType}}{{end}}{{range $headers}}
+			{{.Field}} {{.Type}}{{end}}
+		}
+
+		// {{operationName .}}WithHeaders calls {{operationName .}} and returns its response with
+		// the headers of the response.
+		func (service *{{$client}}) {{operationName .}}WithHeaders ({{template "CallParams" $request}}) (*{{$result}}, error) {
+			response := new({{if $out}}{{template "ResponseValue" $out}}{{else}}{{$responseType}}{{end}})
+			result := new({{$result}})
+			err := service.client.call(context.Background(), {{printf "%q" (operationName .)}}, {{$action}}, {{template "RequestArg" $request}}, response, []responseHeader{ {{range $headers}}
+				{element: {{printf "%q" .Element}}, typeName: {{printf "%q" .TypeName}}, value: &result.{{.Field}}},{{end}}
+			}, opts...)
+			if err != nil {
+				return nil, err
+			}
+
+			result.Response = response{{if $out}}.{{$out.Field}}{{end}}
+			return result, nil
+		}
+		{{end}}
+		{{end}}
+
+		{{if and generateBatch (ne $requestType "") (not $out)}}
+		// {{operationName .}}Batch calls {{operationName .}} for every request using CallBatch.
+		{{if $signature}}{{operationName .}}Batch (ctx context.Context, requests []*{{$requestType}}, concurrency int, opts ...CallOption) BatchResult[{{$responseType}}]
+		{{else -}}
+		func (service *{{$client}}) {{operationName .}}Batch (ctx context.Context, requests []*{{$requestType}}, concurrency int, opts ...CallOption) BatchResult[{{$responseType}}] {
+			return CallBatch(ctx, requests, concurrency, func(ctx context.Context, request *{{$requestType}}) (*{{$responseType}}, error) {
+				response := new({{$responseType}})
+				err := service.client.call(ctx, {{printf "%q" (operationName .)}}, {{$action}}, request, response, nil, opts...)
+				if err != nil {
+					return nil, err
+				}
+
+				return response, nil
+			})
+		}
+		{{end}}
+		{{end}}
+
+		{{if generateAsync}}
+		// {{operationName .}}Async calls {{operationName .}} in a new goroutine. The returned
+		// channel receives exactly one result and is then closed.
+		{{if $signature}}{{operationName .}}Async (ctx context.Context, {{template "CallParams" $request}}) <-chan AsyncResult[{{$resultType}}]
+		{{else -}}
+		func (service *{{$client}}) {{operationName .}}Async (ctx context.Context, {{template "CallParams" $request}}) <-chan AsyncResult[{{$resultType}}] {
+			ch := make(chan AsyncResult[{{$resultType}}], 1)
+			go func() {
+				defer close(ch)
+				response := new({{if $out}}{{template "ResponseValue" $out}}{{else}}{{$responseType}}{{end}})
+				err := service.client.call(ctx, {{printf "%q" (operationName .)}}, {{$action}}, {{template "RequestArg" $request}}, response, nil, opts...)
+				if err != nil {
+					ch <- AsyncResult[{{$resultType}}]{Err: err}
+					return
+				}
+
+				ch <- AsyncResult[{{$resultType}}]{Response: {{if $out}}&response.{{$out.Field}}{{else}}response{{end}}}
+			}()
+
+			return ch
+		}
+		{{end}}
+		{{end}}
+		{{end}}
+	{{end}}
+{{end}}
+
+{{if and hasSimpleParts generics}}
+// xmlValue carries a message of a simple type as a single element.
+type xmlValue[T any] struct {
+	XMLName xml.Name
+	Value   T ` + "`" + `xml:",chardata"` + "`" + `
+}
+{{end}}
+
+{{if generateAsync}}
+// AsyncResult carries the outcome of an asynchronous operation call.
+type AsyncResult[T any] struct {
+	Response *T
+	Err      error
+}
+{{end}}
+
+{{if generateCaching}}
+// Cache stores the responses of the operations of caching clients. Its
+// implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, unless it expired.
+	Get(key string) ({{emptyInterface}}, bool)
+	// Set stores value under key for ttl.
+	Set(key string, value {{emptyInterface}}, ttl time.Duration)
+}
+
+// MemoryCache is a Cache keeping its entries in memory. Expired entries are
+// dropped when they are read.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	value   {{emptyInterface}}
+	expires time.Time
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+// Get returns the value stored under key, unless it expired.
+func (c *MemoryCache) Get(key string) ({{emptyInterface}}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key for ttl.
+func (c *MemoryCache) Set(key string, value {{emptyInterface}}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = memoryCacheEntry{value: value, expires: time.Now().Add(ttl)}
+}
+
+// cacheKey returns the key caching the response of operation to request
+// sent with headers: the name of the operation followed by the XML
+// encodings of the headers and of the request.
+func cacheKey(operation string, headers []{{emptyInterface}}, request {{emptyInterface}}) (string, error) {
+	key := operation + ":"
+	for _, header := range headers {
+		data, err := xml.Marshal(header)
+		if err != nil {
+			return "", err
+		}
+		key += string(data)
+	}
+	data, err := xml.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	return key + ":" + string(data), nil
+}
+
+// soapHeaders returns the SOAP headers client adds to its requests, or nil
+// for clients not telling them.
+func soapHeaders(client {{emptyInterface}}) []{{emptyInterface}} {
+	if c, ok := client.(interface{ soapHeaders() []{{emptyInterface}} }); ok {
+		return c.soapHeaders()
+	}
+	return nil
+}
+{{end}}
+
+{{if generateLogging}}
+// Logger receives the lines of logging clients. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...{{emptyInterface}})
+}
+
+// logCall logs a call to operation that took d with logger, or with the
+// standard logger when nil: the sizes of the XML encodings of request and
+// response, and the fault code or error of failed calls.
+func logCall(logger Logger, operation string, d time.Duration, request, response {{emptyInterface}}, err error) {
+	printf := log.Printf
+	if logger != nil {
+		printf = logger.Printf
+	}
+	if fault, ok := err.(*SOAPFault); ok {
+		printf("%s: %s, request %d bytes, fault %s: %s", operation, d, payloadSize(request), fault.Code, fault.String)
+		return
+	}
+	if err != nil {
+		printf("%s: %s, request %d bytes, error: %v", operation, d, payloadSize(request), err)
+		return
+	}
+	printf("%s: %s, request %d bytes, response %d bytes", operation, d, payloadSize(request), payloadSize(response))
+}
+
+// payloadSize returns the size of the XML encoding of v, or -1 when v
+// cannot be encoded.
+func payloadSize(v {{emptyInterface}}) int {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return -1
+	}
+	return len(data)
+}
+{{end}}
+
+{{if generateBatch}}
+// BatchResult holds the outcome of CallBatch. Responses and Errors are
+// indexed like the requests passed in.
+type BatchResult[T any] struct {
+	Responses []*T
+	Errors    []error
+}
+
+// Err returns the first error in request order, or nil if every call succeeded.
+func (r BatchResult[T]) Err() error {
+	for _, err := range r.Errors {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CallBatch runs call for every request, with at most concurrency calls in
+// flight; a concurrency below one runs all requests at once. Requests not yet
+// started when ctx is done fail with the context error.
+func CallBatch[Req, Resp any](ctx context.Context, requests []*Req, concurrency int,
+	call func(context.Context, *Req) (*Resp, error)) BatchResult[Resp] {
+	result := BatchResult[Resp]{
+		Responses: make([]*Resp, len(requests)),
+		Errors:    make([]error, len(requests)),
+	}
+	if concurrency < 1 {
+		concurrency = len(requests)
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			result.Errors[i] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, request *Req) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result.Responses[i], result.Errors[i] = call(ctx, request)
+		}(i, request)
+	}
+	wg.Wait()
+
+	return result
+}
+{{end}}
+
+{{if or generateTestServer generateVCR}}
+// soapBodyElement returns the local name of the first element inside the
+// SOAP body of data.
+func soapBodyElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if se, ok := token.(xml.StartElement); ok {
+			if inBody {
+				return se.Name.Local, nil
+			}
+			inBody = se.Name.Local == "Body"
+		}
+	}
+}
+
+{{end}}
+
+{{if generateTestServer}}
+// writeSOAPResponse writes content, or a fault built from err, as a SOAP
+// envelope.
+func writeSOAPResponse(w http.ResponseWriter, content {{emptyInterface}}, err error) {
+	envelope := SOAPEnvelope{}
+	status := http.StatusOK
+	if err != nil {
+		fault, ok := err.(*SOAPFault)
+		if !ok {
+			fault = &SOAPFault{Code: "soap:Server", String: err.Error()}
+		}
+		envelope.Body.Fault = fault
+		status = http.StatusInternalServerError
+	} else {
+		envelope.Body.Content = content
+	}
+
+	w.Header().Set("Content-Type", "text/xml; charset=\"utf-8\"")
+	w.WriteHeader(status)
+	if err := xml.NewEncoder(w).Encode(envelope); err != nil {
+		log.Println(err)
+	}
+}
+{{end}}
+
+{{if generateVCR}}
+// VCRMode selects whether a VCRTransport records or replays exchanges.
+type VCRMode int
+
+const (
+	// VCRReplay serves recorded responses and fails on unknown requests.
+	VCRReplay VCRMode = iota
+	// VCRRecord forwards requests and records their responses.
+	VCRRecord
+)
+
+// VCRTransport is an http.RoundTripper that records SOAP exchanges to files
+// in Dir and replays them, keyed by operation and a digest of the request
+// body. Requests carrying varying content, such as WSS nonces, will not
+// match their recordings.
+type VCRTransport struct {
+	Dir  string
+	Mode VCRMode
+
+	// Transport sends requests in record mode; http.DefaultTransport is
+	// used when nil.
+	Transport http.RoundTripper
+}
+
+type vcrExchange struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *VCRTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	path := filepath.Join(t.Dir, vcrFileName(req, body))
+
+	if t.Mode == VCRReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.New("vcr: no recorded exchange: " + err.Error())
+		}
+		var exchange vcrExchange
+		if err := json.Unmarshal(data, &exchange); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			Status:        http.StatusText(exchange.StatusCode),
+			StatusCode:    exchange.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        exchange.Header,
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(exchange.Body))),
+			ContentLength: int64(len(exchange.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	data, err := json.MarshalIndent(vcrExchange{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       string(resBody),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// vcrFileName names the recording of a request after its SOAP action, or
+// its body element when there is none, and a digest of its body.
+func vcrFileName(req *http.Request, body []byte) string {
+	operation := req.Header.Get("SOAPAction")
+	if operation == "" {
+		operation, _ = soapBodyElement(body)
+	}
+	name := []byte(operation)
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			name[i] = '_'
+		}
+	}
+	sum := sha256.Sum256(body)
+
+	return string(name) + "-" + hex.EncodeToString(sum[:8]) + ".json"
+}
+{{end}}
+
+{{if hasHTTPClients}}
+// HTTPEncoding selects how CallHTTP sends the parameters of an operation
+// bound with http:binding.
+type HTTPEncoding int
+
+const (
+	// HTTPURLEncoded sends parameters in the query string.
+	HTTPURLEncoded HTTPEncoding = iota
+	// HTTPURLReplacement substitutes parameters for the (name) placeholders
+	// of the operation location.
+	HTTPURLReplacement
+	// HTTPFormEncoded sends parameters as a form in the request body.
+	HTTPFormEncoded
+)
+
+// CallHTTP sends params with the given verb to the operation at location,
+// relative to the client URL, and decodes the XML document answered into
+// response. It returns ErrEmptyResponse when the body answered is empty.
+func (s *SOAPClient) CallHTTP(ctx context.Context, verb, location string, encoding HTTPEncoding, params url.Values, response {{emptyInterface}}, opts ...CallOption) error {
+	ctx, cancel := s.operationContext(ctx, "")
+	defer cancel()
+
+	if encoding == HTTPURLReplacement {
+		for name := range params {
+			location = strings.Replace(location, "("+name+")", url.PathEscape(params.Get(name)), -1)
+		}
+	}
+	target := strings.TrimSuffix(s.url, "/") + location
+	if encoding == HTTPURLEncoded && len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+	body := ""
+	if encoding == HTTPFormEncoded {
+		body = params.Encode()
+	}
+
+	req, err := http.NewRequest(verb, target, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if s.requireTLS && req.URL.Scheme != "https" {
+		return ErrTLSRequired
+	}
+	req = req.WithContext(ctx)
+	if err := s.authenticate(req); err != nil {
+		return err
+	}
+	if encoding == HTTPFormEncoded {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("User-Agent", "gowsdl/0.1")
+	s.setHTTPHeaders(req, s.callRequestID(ctx), opts)
+
+	sent := time.Now()
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	rawbody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	recordResponse(req, res, sent)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &HTTPError{StatusCode: res.StatusCode, Status: res.Status, Body: rawbody}
+	}
+	if response == nil {
+		return nil
+	}
+	if len(bytes.TrimSpace(rawbody)) == 0 {
+		return ErrEmptyResponse
+	}
+
+	return xml.Unmarshal(rawbody, response)
+}
+{{end}}
+
 {{range .}}
 	{{$portType := .Name | makePublic}}
-	type {{$portType}} struct {
+	{{$client := $portType}}{{if generateInterfaces}}{{$client = clientTypeName $portType}}{{end}}
+	{{$binding := .Binding}}
+	{{$httpVerb := .HTTPVerb}}
+	{{if generateInterfaces}}
+	// {{$portType}} is the client of the {{.Name}} port type, implemented by
+	// the clients its constructors return.
+	type {{$portType}} interface {
+		{{if not $httpVerb}}
+		AddHeader(header {{emptyInterface}})
+		SetHeader(header {{emptyInterface}})
+		{{end}}
+		{{range .PortType.Operations}}
+			{{template "Operation" dict "Op" . "PortType" $portType "Client" $client "Binding" $binding "HTTPVerb" $httpVerb "Signature" true}}
+		{{end}}
+	}
+
+	{{end}}
+	{{with .Policy}}{{range .Unsupported}}// gowsdl: unsupported WS-Policy assertion {{.}}
+	{{end}}{{end}}{{range headerFaults $binding}}// Calls fail with a *HeaderFault holding a *{{.Type}} when a response header carries {{.Element}}.
+	{{end}}type {{$client}} struct {
 		client *SOAPClient
 	}
+	{{if generateInterfaces}}
+	var _ {{$portType}} = (*{{$client}})(nil)
+	{{end}}
 
-	func New{{$portType}}(url string, tls bool, auth *BasicAuth) *{{$portType}} {
+	func New{{$portType}}(url string, tls bool, auth CredentialsProvider, opts ...ClientOption) {{if generateInterfaces}}{{$portType}}{{else}}*{{$portType}}{{end}} {
 		if url == "" {
-			url = {{findServiceAddress .Name | printf "%q"}}
+			url = {{printf "%q" .Address}}
 		}
-		client := NewSOAPClient(url, tls, auth)
+		client := NewSOAPClient(url, tls, auth, opts...)
+		{{range headerFaults $binding}}
+		client.RegisterHeaderFault({{printf "%q" .Element}}, {{printf "%q" .TypeName}}, func() {{emptyInterface}} { return new({{.Type}}) })
+		{{end}}
 
-		return &{{$portType}}{
+		return &{{$client}}{
 			client: client,
 		}
 	}
 
-	func New{{$portType}}WithTLSConfig(url string, tlsCfg *tls.Config, auth *BasicAuth) *{{$portType}} {
+	func New{{$portType}}WithTLSConfig(url string, tlsCfg *tls.Config, auth CredentialsProvider, opts ...ClientOption) {{if generateInterfaces}}{{$portType}}{{else}}*{{$portType}}{{end}} {
 		if url == "" {
-			url = {{findServiceAddress .Name | printf "%q"}}
+			url = {{printf "%q" .Address}}
 		}
-		client := NewSOAPClientWithTLSConfig(url, tlsCfg, auth)
+		client := NewSOAPClientWithTLSConfig(url, tlsCfg, auth, opts...)
+		{{range headerFaults $binding}}
+		client.RegisterHeaderFault({{printf "%q" .Element}}, {{printf "%q" .TypeName}}, func() {{emptyInterface}} { return new({{.Type}}) })
+		{{end}}
 
-		return &{{$portType}}{
+		return &{{$client}}{
 			client: client,
 		}
 	}
 
-	func (service *{{$portType}}) AddHeader(header interface{}) {
+	{{with .Policy}}{{if .Names}}
+	// {{$portType}}PolicyOptions returns the client options meeting the
+	// WS-Policy of the {{$binding}} binding: {{.}}.
+	func {{$portType}}PolicyOptions({{if .UsernameToken}}username, password string{{end}}) []ClientOption {
+		return []ClientOption{
+			{{if .TLS}}WithRequireTLS(),{{end}}
+			{{if .UsernameToken}}WithUsernameToken(username, password),{{end}}
+			{{if .Addressing}}WithAddressing(),{{end}}
+		}
+	}
+	{{end}}{{end}}
+
+	{{if not $httpVerb}}
+	func (service *{{$client}}) AddHeader(header {{emptyInterface}}) {
 		service.client.AddHeader(header)
 	}
 
 	// Backwards-compatible function: use AddHeader instead
-	func (service *{{$portType}}) SetHeader(header interface{}) {
+	func (service *{{$client}}) SetHeader(header {{emptyInterface}}) {
 		service.client.AddHeader(header)
 	}
 
-	{{range .Operations}}
-		{{$faults := len .Faults}}
-		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
-		{{$soapAction := findSOAPAction .Name $portType}}
-		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+	{{if generateCaching}}
+	func (service *{{$client}}) soapHeaders() []{{emptyInterface}} {
+		return service.client.headers
+	}
+	{{end}}
+	{{end}}
 
-		{{/*if ne $soapAction ""*/}}
-		{{if gt $faults 0}}
-		// Error can be either of the following types:
-		// {{range .Faults}}
-		//   - {{.Name}} {{.Doc}}{{end}}{{end}}
-		{{if ne .Doc ""}}/* {{.Doc}} */{{end}}
-		func (service *{{$portType}}) {{makePublic .Name | replaceReservedWords}} ({{if ne $requestType ""}}request *{{$requestType}}{{end}}) (*{{$responseType}}, error) {
-			response := new({{$responseType}})
-			err := service.client.Call("{{$soapAction}}", {{if ne $requestType ""}}request{{else}}nil{{end}}, response)
-			if err != nil {
-				return nil, err
-			}
+	{{if and generateTestServer (not $httpVerb)}}
+	// {{$portType}}TestServer is an httptest.Server answering {{$portType}}
+	// operations with the handler funcs set on it. Operations without a
+	// handler fail with a SOAP fault.
+	type {{$portType}}TestServer struct {
+		*httptest.Server
+		{{range .PortType.Operations}}
+			{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
+			{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+			{{if ne $requestType ""}}
+				{{if eq .Output.Message ""}}
+					{{operationName .}} func(ctx context.Context, request *{{$requestType}}) error
+				{{else if ne $responseType ""}}
+					{{operationName .}} func(ctx context.Context, request *{{$requestType}}) (*{{$responseType}}, error)
+				{{end}}
+			{{end}}
+		{{end}}
+	}
 
-			return response, nil
+	// New{{$portType}}TestServer starts a {{$portType}}TestServer. Callers should
+	// Close it when done.
+	func New{{$portType}}TestServer() *{{$portType}}TestServer {
+		s := new({{$portType}}TestServer)
+		s.Server = httptest.NewServer(http.HandlerFunc(s.serveSOAP))
+		return s
+	}
+
+	// NewClient returns a {{$portType}} client talking to the test server.
+	func (s *{{$portType}}TestServer) NewClient(opts ...ClientOption) {{if generateInterfaces}}{{$portType}}{{else}}*{{$portType}}{{end}} {
+		return New{{$portType}}(s.URL, false, nil, opts...)
+	}
+
+	func (s *{{$portType}}TestServer) serveSOAP(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeSOAPResponse(w, nil, err)
+			return
+		}
+		element, err := soapBodyElement(data)
+		if err != nil {
+			writeSOAPResponse(w, nil, &SOAPFault{Code: "soap:Client", String: err.Error()})
+			return
 		}
-		{{/*end*/}}
+
+		switch element {
+		{{range .PortType.Operations}}
+			{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
+			{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+			{{$name := operationName .}}
+			{{if and (ne $requestType "") (or (eq .Output.Message "") (ne $responseType ""))}}
+			case {{findElement .Input.Message | printf "%q"}}:
+				if s.{{$name}} == nil {
+					writeSOAPResponse(w, nil, &SOAPFault{Code: "soap:Server", String: "{{$name}} is not implemented"})
+					return
+				}
+				request := new({{$requestType}})
+				if err := xml.Unmarshal(data, &SOAPEnvelope{Body: SOAPBody{Content: request}}); err != nil {
+					writeSOAPResponse(w, nil, &SOAPFault{Code: "soap:Client", String: err.Error()})
+					return
+				}
+				{{if eq .Output.Message ""}}
+					if err := s.{{$name}}(r.Context(), request); err != nil {
+						writeSOAPResponse(w, nil, err)
+						return
+					}
+					w.WriteHeader(http.StatusAccepted)
+				{{else}}
+					response, err := s.{{$name}}(r.Context(), request)
+					writeSOAPResponse(w, response, err)
+				{{end}}
+			{{end}}
+		{{end}}
+		default:
+			writeSOAPResponse(w, nil, &SOAPFault{Code: "soap:Client", String: "unknown operation " + element})
+		}
+	}
+	{{end}}
+
+	{{range .PortType.Operations}}
+		{{template "Operation" dict "Op" . "PortType" $portType "Client" $client "Binding" $binding "HTTPVerb" $httpVerb "Signature" false}}
+	{{end}}
+
+	{{if and generateCaching (not $httpVerb)}}
+	// Caching{{$portType}} is a {{$portType}} keeping the responses of its
+	// operations in Cache for the TTL of each operation, by method name.
+	// Operations without a TTL are not cached, nor are failed calls and calls
+	// with options. The SOAP headers of the wrapped client are part of the
+	// cache keys. Cached responses are shared by the callers, which must not
+	// modify them.
+	type Caching{{$portType}} struct {
+		{{$portType}}
+		Cache Cache
+		TTL   map[string]time.Duration
+	}
+
+	// NewCaching{{$portType}} returns a Caching{{$portType}} wrapping client.
+	func NewCaching{{$portType}}(client {{$portType}}, cache Cache, ttl map[string]time.Duration) *Caching{{$portType}} {
+		return &Caching{{$portType}}{ {{$portType}}: client, Cache: cache, TTL: ttl}
+	}
+
+	func (c *Caching{{$portType}}) soapHeaders() []{{emptyInterface}} {
+		return soapHeaders(c.{{$portType}})
+	}
+
+	{{range .PortType.Operations}}
+		{{template "CachingOperation" dict "Op" . "PortType" $portType "HTTPVerb" $httpVerb}}
+	{{end}}
 	{{end}}
+
+	{{if and generateLogging (not $httpVerb)}}
+	// Logging{{$portType}} is a {{$portType}} logging the duration and the
+	// payload sizes of its operation calls, and their faults or errors, with
+	// Logger, or with the standard logger when nil.
+	type Logging{{$portType}} struct {
+		{{$portType}}
+		Logger Logger
+	}
+
+	// NewLogging{{$portType}} returns a Logging{{$portType}} wrapping client.
+	func NewLogging{{$portType}}(client {{$portType}}, logger Logger) *Logging{{$portType}} {
+		return &Logging{{$portType}}{ {{$portType}}: client, Logger: logger}
+	}
+
+	{{if generateCaching}}
+	func (c *Logging{{$portType}}) soapHeaders() []{{emptyInterface}} {
+		return soapHeaders(c.{{$portType}})
+	}
+	{{end}}
+
+	{{range .PortType.Operations}}
+		{{template "LoggingOperation" dict "Op" . "PortType" $portType "HTTPVerb" $httpVerb}}
+	{{end}}
+	{{end}}
+{{end}}
+
+{{if hasSOAPClients}}
+// OperationInfo describes an operation of a generated client, for routing
+// requests or building tools on top of the clients.
+type OperationInfo struct {
+	// Port names the client type.
+	Port   string
+	Action string
+	// Input and Output name the elements carrying the request and the
+	// response.
+	Input  xml.Name
+	Output xml.Name
+}
+
+// Operations describes the operations of the generated SOAP clients by
+// method name. Methods found on several clients are keyed Client.Method.
+var Operations = map[string]OperationInfo{
+	{{- range .}}{{if not .HTTPVerb}}
+		{{- $portType := .Name | makePublic}}
+		{{- range .PortType.Operations}}
+			{{- $input := messageElement .Input.Message}}
+			{{- $output := messageElement .Output.Message}}
+			{{printf "%q" (operationKey $portType .)}}: {
+				Port:   {{printf "%q" $portType}},
+				Action: {{actionName $portType .}},
+				Input:  xml.Name{Space: {{printf "%q" $input.Space}}, Local: {{printf "%q" $input.Local}}},
+				Output: xml.Name{Space: {{printf "%q" $output.Space}}, Local: {{printf "%q" $output.Local}}},
+			},
+		{{- end}}
+	{{- end}}{{end}}
+}
 {{end}}
 `
@@ -0,0 +1,184 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/scanner"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+var newline = []byte("\n")
+
+// excerptLines is the number of lines shown before and after the line of a
+// formatting error.
+const excerptLines = 2
+
+// FormatError is returned when the generated code cannot be formatted. The
+// unformatted code is kept in Path, which the positions of Err refer to.
+type FormatError struct {
+	Path string
+	// Line and Column locate the first syntax error, and Excerpt shows the
+	// code around it, when Err has positions.
+	Line    int
+	Column  int
+	Excerpt string
+	Err     error
+}
+
+func (e *FormatError) Error() string {
+	msg := fmt.Sprintf("formatting the generated code failed, the unformatted code is in %s: %v", e.Path, e.Err)
+	if e.Excerpt != "" {
+		msg += "\n" + e.Excerpt
+	}
+	return msg
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+// writeFormattedSections go fmts each of sections apart and writes them to
+// name through a temporary file, so that the formatted code of a large
+// package is never held in memory at once and name is only replaced once
+// all of it is written. The first section must hold the package clause and
+// the others declarations only. When a section cannot be formatted, name is
+// left as it was, and the unformatted code is written to name with a
+// .broken suffix and reported by a *FormatError. Once name is written, the
+// .broken file of an earlier run is removed.
+func writeFormattedSections(name string, sections ...[]byte) (err error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(tmp)
+	// line is the line of the unformatted code that section starts on.
+	line, written := 1, false
+	for _, section := range sections {
+		code := bytes.TrimLeftFunc(section, unicode.IsSpace)
+		first := line + bytes.Count(section[:len(section)-len(code)], newline)
+		line += bytes.Count(section, newline)
+		code = bytes.TrimRightFunc(code, unicode.IsSpace)
+		if len(code) == 0 {
+			continue
+		}
+		source, err := format.Source(code)
+		if err != nil {
+			return writeBroken(name, sections, newFormatError(err, code, first))
+		}
+		if written {
+			w.Write(newline)
+		}
+		w.Write(source)
+		if source[len(source)-1] != '\n' {
+			w.Write(newline)
+		}
+		written = true
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return err
+	}
+	if err := os.Remove(name + ".broken"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// newFormatError returns a *FormatError for the error formatting code,
+// which starts on line first of the unformatted code.
+func newFormatError(err error, code []byte, first int) *FormatError {
+	formatErr := &FormatError{Err: err}
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 {
+		return formatErr
+	}
+	// Sections are formatted apart from the code before them.
+	for _, e := range list {
+		e.Pos.Line += first - 1
+	}
+	formatErr.Line, formatErr.Column = list[0].Pos.Line, list[0].Pos.Column
+	formatErr.Excerpt = excerpt(code, first, formatErr.Line, formatErr.Column)
+	return formatErr
+}
+
+// excerpt returns the lines of code, which starts on line first, around
+// line, numbered and with column marked.
+func excerpt(code []byte, first, line, column int) string {
+	var b strings.Builder
+	lines := bytes.Split(code, newline)
+	for i := line - excerptLines; i <= line+excerptLines; i++ {
+		if i < first || i-first >= len(lines) {
+			continue
+		}
+		text := lines[i-first]
+		fmt.Fprintf(&b, "%6d | %s\n", i, text)
+		if i != line {
+			continue
+		}
+		// Keep the tabs before the column so that the mark lines up.
+		b.WriteString("       | ")
+		for j := 0; j < column-1 && j < len(text); j++ {
+			if text[j] == '\t' {
+				b.WriteByte('\t')
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString("^\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeBroken writes sections unformatted next to name and returns
+// formatErr with the file, which the positions of its error then refer to.
+func writeBroken(name string, sections [][]byte, formatErr *FormatError) error {
+	path := name + ".broken"
+	if list, ok := formatErr.Err.(scanner.ErrorList); ok {
+		for _, e := range list {
+			e.Pos.Filename = path
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, section := range sections {
+		w.Write(section)
+	}
+	if err = w.Flush(); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		return err
+	}
+	formatErr.Path = path
+	return formatErr
+}
@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"go/scanner"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFormattedSections(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/test.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var whole bytes.Buffer
+	sections := [][]byte{code["header"], code["types"], code["operations"], code["soap"]}
+	for _, section := range sections {
+		whole.Write(section)
+	}
+	expected, err := format.Source(whole.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "myservice.go")
+	// Left by an earlier run that could not format the code.
+	if err := ioutil.WriteFile(file+".broken", []byte("package myservice\n\nfunc (\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFormattedSections(file, sections...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file + ".broken"); !os.IsNotExist(err) {
+		t.Errorf("the unformatted code of the earlier run should be removed, got %v", err)
+	}
+	actual, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("got code:\n%s\nwant the code formatted as a whole:\n%s", actual, expected)
+	}
+	if files, _ := filepath.Glob(filepath.Join(filepath.Dir(file), ".*")); len(files) > 0 {
+		t.Errorf("temporary files left: %v", files)
+	}
+}
+
+func TestWriteFormattedSectionsKeepsUnformattedCode(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "myservice.go")
+	previous := []byte("package myservice\n")
+	if err := ioutil.WriteFile(file, previous, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	header := []byte("package myservice\n\nimport \"fmt\"\n")
+	types := []byte("\n\ntype Order struct {\n\tID int\n}\n")
+	ops := []byte("\nfunc (o *Order) String() string {\n\treturn fmt.Sprint(o.ID\n}\n")
+	err := writeFormattedSections(file, header, types, ops)
+
+	var formatErr *FormatError
+	if !errors.As(err, &formatErr) {
+		t.Fatalf("got error %v, want a *FormatError", err)
+	}
+	if formatErr.Path != file+".broken" {
+		t.Errorf("got unformatted code in %s, want %s", formatErr.Path, file+".broken")
+	}
+	unformatted, err := ioutil.ReadFile(formatErr.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := string(header) + string(types) + string(ops); string(unformatted) != expected {
+		t.Errorf("got unformatted code:\n%s\nwant:\n%s", unformatted, expected)
+	}
+	if data, _ := ioutil.ReadFile(file); !bytes.Equal(data, previous) {
+		t.Errorf("got output file:\n%s\nwant it unchanged", data)
+	}
+
+	// The error is on the line of the missing parenthesis in the whole file.
+	var list scanner.ErrorList
+	if !errors.As(formatErr, &list) || len(list) == 0 {
+		t.Fatalf("got error %v, want syntax errors", formatErr.Err)
+	}
+	if pos := list[0].Pos; pos.Filename != formatErr.Path || pos.Line != 11 {
+		t.Errorf("got error at %s, want line 11 of %s", pos, formatErr.Path)
+	}
+	if formatErr.Line != 11 || formatErr.Column != 24 {
+		t.Errorf("got error at %d:%d, want 11:24", formatErr.Line, formatErr.Column)
+	}
+	expected := "    10 | func (o *Order) String() string {\n" +
+		"    11 | \treturn fmt.Sprint(o.ID\n" +
+		"       | \t                      ^\n" +
+		"    12 | }"
+	if formatErr.Excerpt != expected {
+		t.Errorf("got excerpt:\n%s\nwant:\n%s", formatErr.Excerpt, expected)
+	}
+	if !strings.Contains(formatErr.Error(), expected) {
+		t.Errorf("got error %q, want it to show the excerpt", formatErr)
+	}
+}
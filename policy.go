@@ -0,0 +1,196 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// WSPolicy is a WS-Policy expression. Policy operators (ExactlyOne, All and
+// nested Policy elements) are flattened, so the alternatives of a policy
+// are merged into a single list of assertions.
+type WSPolicy struct {
+	ID         string
+	Name       string
+	Assertions []*WSPolicyAssertion
+	References []string
+}
+
+// WSPolicyAssertion is a policy assertion, identified by its local name,
+// with the assertions of its nested policy.
+type WSPolicyAssertion struct {
+	Name   string
+	Nested []*WSPolicyAssertion
+}
+
+// WSPolicyReference refers to a policy by URI, usually "#" and its wsu:Id.
+type WSPolicyReference struct {
+	URI string `xml:"URI,attr"`
+}
+
+// WSDLUsingAddressing marks a binding as using WS-Addressing.
+type WSDLUsingAddressing struct {
+	Required string `xml:"required,attr"`
+}
+
+// UnmarshalXML implements interface xml.Unmarshaler for WSPolicy.
+func (p *WSPolicy) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "Id", "id":
+			p.ID = attr.Value
+		case "Name":
+			p.Name = attr.Value
+		}
+	}
+	var err error
+	p.Assertions, err = decodePolicyContent(d, p)
+	return err
+}
+
+// decodePolicyContent decodes the content of the current element up to its
+// end, collecting assertions and recording policy references in p.
+func decodePolicyContent(d *xml.Decoder, p *WSPolicy) ([]*WSPolicyAssertion, error) {
+	var assertions []*WSPolicyAssertion
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "Policy", "ExactlyOne", "All":
+				nested, err := decodePolicyContent(d, p)
+				if err != nil {
+					return nil, err
+				}
+				assertions = append(assertions, nested...)
+			case "PolicyReference":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "URI" {
+						p.References = append(p.References, attr.Value)
+					}
+				}
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			default:
+				nested, err := decodePolicyContent(d, p)
+				if err != nil {
+					return nil, err
+				}
+				assertions = append(assertions, &WSPolicyAssertion{Name: t.Name.Local, Nested: nested})
+			}
+		case xml.EndElement:
+			return assertions, nil
+		}
+	}
+}
+
+// policyRequirements are the requirements detected in the policy of a
+// binding that generated clients can meet.
+type policyRequirements struct {
+	UsernameToken bool
+	TLS           bool
+	Addressing    bool
+	// Unsupported holds the assertions that imply none of the above.
+	Unsupported []string
+}
+
+// Names returns the detected requirements for reports and comments.
+func (r *policyRequirements) Names() []string {
+	var names []string
+	if r.TLS {
+		names = append(names, "TLS")
+	}
+	if r.UsernameToken {
+		names = append(names, "UsernameToken")
+	}
+	if r.Addressing {
+		names = append(names, "WS-Addressing")
+	}
+	return names
+}
+
+func (r *policyRequirements) String() string {
+	return strings.Join(r.Names(), ", ")
+}
+
+// bindingPolicy returns the requirements of the policies a binding holds or
+// refers to, or nil when it has none.
+func (w *WSDL) bindingPolicy(b *WSDLBinding) *policyRequirements {
+	if b == nil {
+		return nil
+	}
+	var assertions []*WSPolicyAssertion
+	seen := make(map[*WSPolicy]bool)
+	var collect func(p *WSPolicy)
+	collect = func(p *WSPolicy) {
+		if p == nil || seen[p] {
+			return
+		}
+		seen[p] = true
+		assertions = append(assertions, p.Assertions...)
+		for _, uri := range p.References {
+			collect(w.policy(uri))
+		}
+	}
+	for _, p := range b.Policies {
+		collect(p)
+	}
+	for _, ref := range b.PolicyReferences {
+		collect(w.policy(ref.URI))
+	}
+	if b.UsingAddressing != nil {
+		assertions = append(assertions, &WSPolicyAssertion{Name: "UsingAddressing"})
+	}
+	if len(assertions) == 0 {
+		return nil
+	}
+
+	r := new(policyRequirements)
+	for _, a := range assertions {
+		if !r.detect(a) {
+			r.Unsupported = append(r.Unsupported, a.Name)
+		}
+	}
+	return r
+}
+
+// policy returns the policy a PolicyReference URI points to, or nil.
+func (w *WSDL) policy(uri string) *WSPolicy {
+	for _, p := range w.Policies {
+		if (strings.HasPrefix(uri, "#") && p.ID == uri[1:]) || (p.Name != "" && p.Name == uri) {
+			return p
+		}
+	}
+	return nil
+}
+
+// detect records the requirements found in a and reports whether there
+// were any. Assertions only advertising WS-Security capabilities count as
+// met.
+func (r *policyRequirements) detect(a *WSPolicyAssertion) bool {
+	found := false
+	switch a.Name {
+	case "TransportBinding", "HttpsToken":
+		r.TLS, found = true, true
+	case "UsernameToken":
+		r.UsernameToken, found = true, true
+	case "UsingAddressing", "Addressing":
+		r.Addressing, found = true, true
+	case "Wss10", "Wss11":
+		found = true
+	}
+	for _, nested := range a.Nested {
+		if r.detect(nested) {
+			found = true
+		}
+	}
+	return found
+}
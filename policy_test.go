@@ -0,0 +1,44 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestBindingPolicy(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/policy.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := new(WSDL)
+	if err := xml.Unmarshal(data, w); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(w.Policies) != 1 || w.Policies[0].ID != "SecurePolicy" {
+		t.Fatalf("unexpected policies %+v", w.Policies)
+	}
+	r := w.bindingPolicy(w.Binding[0])
+	expected := &policyRequirements{
+		UsernameToken: true,
+		TLS:           true,
+		Addressing:    true,
+		Unsupported:   []string{"OptimizedMimeSerialization"},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("unexpected requirements %+v", r)
+	}
+
+	if r := w.bindingPolicy(&WSDLBinding{UsingAddressing: &WSDLUsingAddressing{}}); r == nil || !r.Addressing || r.TLS {
+		t.Errorf("UsingAddressing should require WS-Addressing only, got %+v", r)
+	}
+	if r := w.bindingPolicy(&WSDLBinding{}); r != nil {
+		t.Errorf("bindings without policy should have no requirements, got %+v", r)
+	}
+}
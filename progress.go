@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+// ProgressPhase is a phase of the generation.
+type ProgressPhase string
+
+const (
+	// PhaseFetch reads a WSDL or schema from a file or downloads it.
+	PhaseFetch ProgressPhase = "fetch"
+	// PhaseParse decodes a fetched WSDL or schema.
+	PhaseParse ProgressPhase = "parse"
+	// PhaseResolveImports looks for the schemas imported or included by a
+	// schema.
+	PhaseResolveImports ProgressPhase = "resolve imports"
+	// PhaseTraverse prepares the loaded schemas for the generation.
+	PhaseTraverse ProgressPhase = "traverse"
+	// PhaseRender executes the template of a part of the generated code.
+	PhaseRender ProgressPhase = "render"
+	// PhaseFormat formats the generated code.
+	PhaseFormat ProgressPhase = "format"
+)
+
+// Progress is reported when a generation phase starts.
+type Progress struct {
+	Phase ProgressPhase
+	// Item is the location of the document of the fetch, parse and resolve
+	// imports phases, the part of the generated code of the render phase, or
+	// the file of the format phase.
+	Item string
+	// Schemas, Types and Operations count the loaded schemas, their named
+	// simple and complex types, and the operations of the port types.
+	Schemas    int
+	Types      int
+	Operations int
+}
+
+// SetProgressFunc sets the function progress is reported to, so that tools
+// driving long generations can display it or detect stalls. Its calls are
+// serialized, and it should return quickly.
+func (g *GoWSDL) SetProgressFunc(progress func(Progress)) {
+	g.progressFunc = progress
+}
+
+// reportProgress reports the start of phase on item to the progress
+// function, if any.
+func (g *GoWSDL) reportProgress(phase ProgressPhase, item string) {
+	if g.progressFunc == nil {
+		return
+	}
+	g.progressMu.Lock()
+	defer g.progressMu.Unlock()
+
+	p := Progress{Phase: phase, Item: item}
+	if g.wsdl != nil {
+		p.Schemas = len(g.wsdl.Types.Schemas)
+		for _, schema := range g.wsdl.Types.Schemas {
+			p.Types += len(schema.SimpleType) + len(schema.ComplexTypes)
+		}
+		for _, pt := range g.wsdl.PortTypes {
+			p.Operations += len(pt.Operations)
+		}
+	}
+	g.progressFunc(p)
+}
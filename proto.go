@@ -0,0 +1,523 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// xsd2ProtoTypes maps XSD built-in types to proto3 scalar types. Types
+// without an exact counterpart, such as decimals and dates, keep their
+// lexical XML value as a string.
+var xsd2ProtoTypes = map[string]string{
+	"boolean":            "bool",
+	"byte":               "int32",
+	"short":              "int32",
+	"int":                "int32",
+	"long":               "int64",
+	"integer":            "int64",
+	"nonnegativeinteger": "uint64",
+	"positiveinteger":    "uint64",
+	"nonpositiveinteger": "int64",
+	"negativeinteger":    "int64",
+	"unsignedbyte":       "uint32",
+	"unsignedshort":      "uint32",
+	"unsignedint":        "uint32",
+	"unsignedlong":       "uint64",
+	"float":              "float",
+	"double":             "double",
+	"base64binary":       "bytes",
+	"hexbinary":          "bytes",
+	"string":             "string",
+	"normalizedstring":   "string",
+	"token":              "string",
+	"language":           "string",
+	"name":               "string",
+	"ncname":             "string",
+	"nmtoken":            "string",
+	"id":                 "string",
+	"idref":              "string",
+	"qname":              "string",
+	"anyuri":             "string",
+	"decimal":            "string",
+	"datetime":           "string",
+	"date":               "string",
+	"time":               "string",
+	"duration":           "string",
+	"anytype":            "string",
+	"anysimpletype":      "string",
+}
+
+const protoEmpty = "google.protobuf.Empty"
+
+type protoMessage struct {
+	Name   string
+	Doc    string
+	Fields []*protoField
+	Nested []*protoMessage
+	Enums  []*protoEnum
+}
+
+type protoField struct {
+	Name     string
+	Type     string
+	Doc      string
+	Repeated bool
+}
+
+type protoEnum struct {
+	Name   string
+	Doc    string
+	Values []string
+}
+
+// protoConverter turns parsed XSD definitions and WSDL messages into proto3
+// declarations.
+type protoConverter struct {
+	wsdl         *WSDL
+	simpleTypes  map[string]*XSDSimpleType
+	complexTypes map[string]*XSDComplexType
+	elements     map[string]*XSDElement
+	names        map[string]bool
+	messages     []*protoMessage
+	enums        []*protoEnum
+}
+
+// Proto returns a proto3 file mirroring the WSDL: every XSD complex type
+// becomes a message, every enumeration an enum, and every client a service
+// with one rpc per operation.
+func (g *GoWSDL) Proto() ([]byte, error) {
+	err := g.load()
+	if err != nil {
+		return nil, err
+	}
+
+	c := newProtoConverter(g.wsdl)
+	c.definitions()
+
+	var services bytes.Buffer
+	usesEmpty := false
+	for _, client := range g.portClients() {
+		writeProtoDoc(&services, "", client.PortType.Doc)
+		fmt.Fprintf(&services, "service %s {\n", protoIdent(client.Name))
+		for _, op := range client.PortType.Operations {
+			if op.Input.Message == "" {
+				continue
+			}
+			request := c.rpcMessage(op.Input.Message)
+			response := protoEmpty
+			if op.Output.Message != "" {
+				response = c.rpcMessage(op.Output.Message)
+			}
+			usesEmpty = usesEmpty || request == protoEmpty || response == protoEmpty
+			writeProtoDoc(&services, "  ", op.Doc)
+			fmt.Fprintf(&services, "  rpc %s(%s) returns (%s);\n", protoIdent(makePublic(op.Name)), request, response)
+		}
+		services.WriteString("}\n\n")
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gowsdl DO NOT EDIT.\n\n")
+	b.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&b, "package %s;\n\n", g.pkg)
+	if usesEmpty {
+		b.WriteString("import \"google/protobuf/empty.proto\";\n\n")
+	}
+	for _, e := range c.enums {
+		writeProtoEnum(&b, "", e)
+		b.WriteString("\n")
+	}
+	for _, m := range c.messages {
+		writeProtoMessage(&b, "", m)
+		b.WriteString("\n")
+	}
+	b.Write(services.Bytes())
+
+	return bytes.TrimRight(b.Bytes(), "\n"), nil
+}
+
+func newProtoConverter(w *WSDL) *protoConverter {
+	c := &protoConverter{
+		wsdl:         w,
+		simpleTypes:  make(map[string]*XSDSimpleType),
+		complexTypes: make(map[string]*XSDComplexType),
+		elements:     make(map[string]*XSDElement),
+		names:        make(map[string]bool),
+	}
+	for _, schema := range w.Types.Schemas {
+		for _, st := range schema.SimpleType {
+			c.simpleTypes[st.Name] = st
+		}
+		for _, ct := range schema.ComplexTypes {
+			c.complexTypes[ct.Name] = ct
+		}
+		for _, el := range schema.Elements {
+			c.elements[el.Name] = el
+		}
+	}
+	return c
+}
+
+// definitions declares an enum per enumerated simple type, a message per
+// complex type and a message per global element declaring its complex type
+// inline. Elements whose names clash with a type are left out; references
+// to them resolve to the type instead.
+func (c *protoConverter) definitions() {
+	for _, schema := range c.wsdl.Types.Schemas {
+		for _, st := range schema.SimpleType {
+			if len(st.Restriction.Enumeration) > 0 {
+				c.enums = append(c.enums, newProtoEnum(protoIdent(st.Name), st))
+				c.names[protoIdent(st.Name)] = true
+			}
+		}
+		for _, ct := range schema.ComplexTypes {
+			c.names[protoIdent(ct.Name)] = true
+		}
+	}
+	for _, schema := range c.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			c.messages = append(c.messages, c.complexType(protoIdent(ct.Name), ct))
+		}
+		for _, el := range schema.Elements {
+			name := protoIdent(el.Name)
+			if el.ComplexType == nil || el.Type != "" || c.names[name] {
+				continue
+			}
+			c.names[name] = true
+			m := c.complexType(name, el.ComplexType)
+			m.Doc = el.Doc
+			c.messages = append(c.messages, m)
+		}
+	}
+}
+
+// typeRef returns the proto type of a value of the given XSD type, and
+// whether the type is an XSD list.
+func (c *protoConverter) typeRef(xsdType string) (string, bool) {
+	name := localName(xsdType)
+	for depth := 0; depth < 32; depth++ {
+		if scalar, ok := xsd2ProtoTypes[strings.ToLower(name)]; ok {
+			return scalar, false
+		}
+		if _, ok := c.complexTypes[name]; ok {
+			return protoIdent(name), false
+		}
+		st, ok := c.simpleTypes[name]
+		if !ok {
+			break
+		}
+		switch {
+		case len(st.Restriction.Enumeration) > 0:
+			return protoIdent(name), false
+		case st.List.ItemType != "":
+			item, _ := c.typeRef(st.List.ItemType)
+			return item, true
+		case st.Restriction.Base != "":
+			// Proto has no aliases; restrictions resolve to their base.
+			name = localName(st.Restriction.Base)
+		default:
+			return "string", false
+		}
+	}
+	return "string", false
+}
+
+// complexType converts a complex type into a message. Extended base types
+// are flattened into the message, their fields first.
+func (c *protoConverter) complexType(name string, ct *XSDComplexType) *protoMessage {
+	m := &protoMessage{Name: name}
+	c.addComplexFields(m, ct, make(map[*XSDComplexType]bool))
+	return m
+}
+
+func (c *protoConverter) addComplexFields(m *protoMessage, ct *XSDComplexType, seen map[*XSDComplexType]bool) {
+	if seen[ct] {
+		return
+	}
+	seen[ct] = true
+
+	if ext := ct.ComplexContent.Extension; ext.Base != "" {
+		if base, ok := c.complexTypes[localName(ext.Base)]; ok {
+			c.addComplexFields(m, base, seen)
+		}
+	}
+	if ext := ct.SimpleContent.Extension; ext.Base != "" {
+		typ, repeated := c.typeRef(ext.Base)
+		c.addField(m, &protoField{Name: "value", Type: typ, Repeated: repeated})
+	}
+	for _, elements := range [][]*XSDElement{ct.Sequence, ct.Choice, ct.SequenceChoice, ct.All} {
+		for _, el := range elements {
+			c.addElement(m, el)
+		}
+	}
+	for i := range ct.ComplexContent.Extension.Sequence {
+		c.addElement(m, &ct.ComplexContent.Extension.Sequence[i])
+	}
+	for _, attrs := range [][]*XSDAttribute{ct.Attributes, ct.ComplexContent.Extension.Attributes, ct.SimpleContent.Extension.Attributes} {
+		for _, attr := range attrs {
+			c.addAttribute(m, attr)
+		}
+	}
+}
+
+func (c *protoConverter) addElement(m *protoMessage, el *XSDElement) {
+	name := el.Name
+	var typ string
+	var list bool
+	switch {
+	case el.Ref != "":
+		name = localName(el.Ref)
+		typ, list = c.elementRef(name)
+	case el.Type != "":
+		typ, list = c.typeRef(el.Type)
+	case el.ComplexType != nil:
+		nested := c.complexType(protoIdent(makePublic(el.Name)), el.ComplexType)
+		m.Nested = append(m.Nested, nested)
+		typ = nested.Name
+	case el.SimpleType != nil:
+		typ, list = c.inlineSimpleType(m, el.Name, el.SimpleType)
+	default:
+		typ = "string"
+	}
+	repeated := el.MaxOccurs == "unbounded" || (el.MaxOccurs != "" && el.MaxOccurs != "0" && el.MaxOccurs != "1")
+	if repeated && list {
+		// Proto cannot repeat a repeated field; keep the lists as text.
+		typ = "string"
+	}
+	c.addField(m, &protoField{Name: name, Type: typ, Doc: el.Doc, Repeated: repeated || list})
+}
+
+// elementRef returns the type of the global element name.
+func (c *protoConverter) elementRef(name string) (string, bool) {
+	el, ok := c.elements[name]
+	switch {
+	case !ok:
+		return "string", false
+	case el.Type != "":
+		return c.typeRef(el.Type)
+	case el.ComplexType != nil:
+		return protoIdent(name), false
+	case el.SimpleType != nil && el.SimpleType.Restriction.Base != "":
+		return c.typeRef(el.SimpleType.Restriction.Base)
+	}
+	return "string", false
+}
+
+func (c *protoConverter) addAttribute(m *protoMessage, attr *XSDAttribute) {
+	name := attr.Name
+	typ, list := "string", false
+	switch {
+	case attr.Ref != "":
+		name = localName(attr.Ref)
+	case attr.SimpleType != nil:
+		typ, list = c.inlineSimpleType(m, attr.Name, attr.SimpleType)
+	case attr.Type != "":
+		typ, list = c.typeRef(attr.Type)
+	}
+	c.addField(m, &protoField{Name: name, Type: typ, Doc: attr.Doc, Repeated: list})
+}
+
+// inlineSimpleType returns the type of an anonymous simple type, declaring
+// a nested enum in m for enumerations.
+func (c *protoConverter) inlineSimpleType(m *protoMessage, name string, st *XSDSimpleType) (string, bool) {
+	switch {
+	case len(st.Restriction.Enumeration) > 0:
+		e := newProtoEnum(protoIdent(makePublic(name)), st)
+		m.Enums = append(m.Enums, e)
+		return e.Name, false
+	case st.List.ItemType != "":
+		item, _ := c.typeRef(st.List.ItemType)
+		return item, true
+	case st.Restriction.Base != "":
+		return c.typeRef(st.Restriction.Base)
+	}
+	return "string", false
+}
+
+// addField appends f to m with a snake_case name unique within m.
+func (c *protoConverter) addField(m *protoMessage, f *protoField) {
+	base := protoFieldName(f.Name)
+	f.Name = base
+	for i := 2; ; i++ {
+		clash := false
+		for _, other := range m.Fields {
+			if other.Name == f.Name {
+				clash = true
+				break
+			}
+		}
+		if !clash {
+			break
+		}
+		f.Name = fmt.Sprintf("%s_%d", base, i)
+	}
+	m.Fields = append(m.Fields, f)
+}
+
+// rpcMessage returns the message carrying the parts of a WSDL message. A
+// single part of a message type is used as is; other messages get a
+// wrapper message with a field per part.
+func (c *protoConverter) rpcMessage(message string) string {
+	message = localName(message)
+	var msg *WSDLMessage
+	for _, m := range c.wsdl.Messages {
+		if m.Name == message {
+			msg = m
+			break
+		}
+	}
+	if msg == nil || len(msg.Parts) == 0 {
+		return protoEmpty
+	}
+
+	if len(msg.Parts) == 1 {
+		typ := c.partType(msg.Parts[0])
+		if c.names[typ] && !c.isEnum(typ) {
+			return typ
+		}
+	}
+
+	name := protoIdent(msg.Name)
+	for c.names[name] {
+		name += "Message"
+	}
+	c.names[name] = true
+	wrapper := &protoMessage{Name: name, Doc: msg.Doc}
+	for _, part := range msg.Parts {
+		c.addField(wrapper, &protoField{Name: part.Name, Type: c.partType(part)})
+	}
+	c.messages = append(c.messages, wrapper)
+	return name
+}
+
+func (c *protoConverter) partType(part *WSDLPart) string {
+	var typ string
+	if part.Type != "" {
+		typ, _ = c.typeRef(part.Type)
+	} else {
+		typ, _ = c.elementRef(localName(part.Element))
+	}
+	return typ
+}
+
+func (c *protoConverter) isEnum(name string) bool {
+	for _, e := range c.enums {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newProtoEnum(name string, st *XSDSimpleType) *protoEnum {
+	e := &protoEnum{Name: name, Doc: st.Doc}
+	for _, v := range st.Restriction.Enumeration {
+		e.Values = append(e.Values, v.Value)
+	}
+	return e
+}
+
+func writeProtoDoc(b *bytes.Buffer, indent, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, strings.TrimSpace(line))
+	}
+}
+
+func writeProtoMessage(b *bytes.Buffer, indent string, m *protoMessage) {
+	writeProtoDoc(b, indent, m.Doc)
+	fmt.Fprintf(b, "%smessage %s {\n", indent, m.Name)
+	for _, e := range m.Enums {
+		writeProtoEnum(b, indent+"  ", e)
+	}
+	for _, nested := range m.Nested {
+		writeProtoMessage(b, indent+"  ", nested)
+	}
+	for i, f := range m.Fields {
+		writeProtoDoc(b, indent+"  ", f.Doc)
+		label := ""
+		if f.Repeated {
+			label = "repeated "
+		}
+		fmt.Fprintf(b, "%s  %s%s %s = %d;\n", indent, label, f.Type, f.Name, i+1)
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// writeProtoEnum writes e with values prefixed by its name, as enum values
+// share their scope with the enum's siblings. The zero value required by
+// proto3 is NAME_UNSPECIFIED; each other value carries its XML form in a
+// trailing comment.
+func writeProtoEnum(b *bytes.Buffer, indent string, e *protoEnum) {
+	prefix := strings.ToUpper(protoFieldName(e.Name)) + "_"
+	writeProtoDoc(b, indent, e.Doc)
+	fmt.Fprintf(b, "%senum %s {\n", indent, e.Name)
+	fmt.Fprintf(b, "%s  %sUNSPECIFIED = 0;\n", indent, prefix)
+	used := map[string]bool{prefix + "UNSPECIFIED": true}
+	for i, v := range e.Values {
+		name := prefix + strings.ToUpper(protoFieldName(v))
+		if strings.TrimSpace(v) == "" {
+			name = fmt.Sprintf("%sVALUE_%d", prefix, i+1)
+		}
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s%s_%d", prefix, strings.ToUpper(protoFieldName(v)), n)
+		}
+		used[name] = true
+		fmt.Fprintf(b, "%s  %s = %d; // %q\n", indent, name, i+1, v)
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// protoIdent turns an XML name into a proto identifier.
+func protoIdent(name string) string {
+	ident := []rune(name)
+	for i, r := range ident {
+		if !isProtoIdentRune(r) {
+			ident[i] = '_'
+		}
+	}
+	if len(ident) == 0 || !unicode.IsLetter(ident[0]) {
+		ident = append([]rune("X"), ident...)
+	}
+	return string(ident)
+}
+
+// protoFieldName turns an XML name into a lower snake_case field name,
+// splitting words at case changes: "CustomerID" becomes "customer_id".
+func protoFieldName(name string) string {
+	runes := []rune(name)
+	var b bytes.Buffer
+	for i, r := range runes {
+		if !isProtoIdentRune(r) || r == '_' {
+			if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("_")) {
+				b.WriteByte('_')
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 && b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("_")) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	field := strings.TrimRight(b.String(), "_")
+	if field == "" || !unicode.IsLetter([]rune(field)[0]) {
+		field = "x_" + field
+	}
+	return field
+}
+
+func isProtoIdentRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
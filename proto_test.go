@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProto(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/chromedata.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := g.Proto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto := string(data)
+
+	expected := []string{
+		"syntax = \"proto3\";",
+		"package myservice;",
+		"enum DriveTrain {\n  DRIVE_TRAIN_UNSPECIFIED = 0;\n  DRIVE_TRAIN_FRONT_WHEEL_DRIVE = 1; // \"Front Wheel Drive\"",
+		"message AccountInfo {\n  // Account Number provided by Chrome.\n  string number = 1;",
+		"  string behalf_of = 5;\n}",
+		"rpc DescribeVehicle(VehicleDescriptionRequest) returns (VehicleDescription);",
+	}
+	for _, e := range expected {
+		if !strings.Contains(proto, e) {
+			t.Errorf("missing %q in proto:\n%s", e, proto)
+		}
+	}
+
+	g, err = NewGoWSDL("fixtures/oneway.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = g.Proto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto = string(data)
+	if !strings.Contains(proto, "import \"google/protobuf/empty.proto\";") ||
+		!strings.Contains(proto, "rpc LogEvent(LogEvent) returns (google.protobuf.Empty);") {
+		t.Errorf("one-way operations should return google.protobuf.Empty:\n%s", proto)
+	}
+	if strings.Contains(proto, "EventRaised(") {
+		t.Errorf("notification operations should be left out:\n%s", proto)
+	}
+}
+
+func TestProtoFieldName(t *testing.T) {
+	names := map[string]string{
+		"CustomerID": "customer_id",
+		"behalfOf":   "behalf_of",
+		"ABCValue":   "abc_value",
+		"Multi-View": "multi_view",
+		"_this":      "this",
+		"2ndLine":    "x_2nd_line",
+	}
+	for name, expected := range names {
+		if field := protoFieldName(name); field != expected {
+			t.Errorf("protoFieldName(%q) = %q, want %q", name, field, expected)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+)
+
+// renderCacheVersion changes whenever the code generated for unchanged
+// templates and inputs does, invalidating the fragments rendered before.
+const renderCacheVersion = "1"
+
+// SetRenderCache keeps the code rendered for each schema and for the
+// operations in dir, keyed by hashes of the schemas, the WSDL and the
+// options, so that generating again after a small change of a large WSDL only
+// renders the sections that changed. An empty dir disables the cache, and so
+// do the functions of RegisterTemplateFunc.
+func (g *GoWSDL) SetRenderCache(dir string) error {
+	g.renderCache = dir
+	if dir == "" {
+		return nil
+	}
+	return os.MkdirAll(dir, 0700)
+}
+
+// renderKeys hashes the inputs of the cached sections.
+type renderKeys struct {
+	// options covers the options and templates, names the names and simple
+	// types every section may refer to.
+	options string
+	names   string
+	// schemas holds the hash of each schema, or an empty string for schemas
+	// that cannot be hashed.
+	schemas map[*XSDSchema]string
+	// wsdl covers the messages, port types, bindings and services.
+	wsdl string
+}
+
+// newRenderKeys hashes the loaded WSDL and schemas, or returns nil when the
+// render cache is disabled.
+func (g *GoWSDL) newRenderKeys() *renderKeys {
+	if g.renderCache == "" {
+		return nil
+	}
+	if len(g.customFuncs) > 0 {
+		log.Println("[WARN] Rendered code not cached: the template functions registered may have changed")
+		return nil
+	}
+	keys := &renderKeys{schemas: make(map[*XSDSchema]string)}
+
+	h := sha256.New()
+	naming := ""
+	if g.operationNaming != nil {
+		naming = g.operationNaming.Tree.Root.String()
+	}
+	fmt.Fprintln(h, renderCacheVersion, typesTmpl, opsTmpl)
+	fmt.Fprintln(h, g.pkg, g.ignoreTypeNs, g.typeNsPrefixes, g.elementTypeSuffix, naming)
+	if t := g.typeNames; t != nil {
+		fmt.Fprintln(h, t.StripPrefixes, t.Prefix, t.Suffix)
+		for _, r := range t.Replacements {
+			fmt.Fprintln(h, r.Pattern, r.Replacement)
+		}
+	}
+	fmt.Fprintln(h, g.exportTypes, g.exportFields, g.exportEnums, g.streamBase64, g.typeMappings, g.anyType, g.omitEmpty, g.goVersion)
+	fmt.Fprintln(h, g.generateBuilders, g.generatePtrHelpers, g.generateNullable, g.flattenArrays, g.unwrapMessages, g.generateClone, g.generateEqual,
+		g.generateStringer, g.generateEnumHelpers, g.generateAsync, g.generateBatch, g.generateTestServer,
+		g.generateInterfaces, g.generateCaching, g.generateLogging, g.generateVCR)
+	keys.options = hex.EncodeToString(h.Sum(nil))
+
+	h = sha256.New()
+	g.writeNames(h)
+	keys.names = hex.EncodeToString(h.Sum(nil))
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		data, err := json.Marshal(schema)
+		if err != nil {
+			keys.schemas[schema] = ""
+			continue
+		}
+		h = sha256.New()
+		h.Write(data)
+		for _, ct := range schema.ComplexTypes {
+			writeChoiceOrders(h, ct)
+		}
+		for _, el := range schema.Elements {
+			writeChoiceOrders(h, el.ComplexType)
+		}
+		keys.schemas[schema] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	definitions := *g.wsdl
+	definitions.Types = WSDLType{}
+	if data, err := json.Marshal(&definitions); err == nil {
+		sum := sha256.Sum256(data)
+		keys.wsdl = hex.EncodeToString(sum[:])
+	}
+	return keys
+}
+
+// writeNames writes to w the parts of the schemas that the code rendered
+// for other schemas and for the operations depends on: the declared names,
+// the simple types and the Go names given to types and elements.
+func (g *GoWSDL) writeNames(w io.Writer) {
+	for _, schema := range g.wsdl.Types.Schemas {
+		fmt.Fprintln(w, "schema", schema.TargetNamespace, schema.Xmlns)
+		for _, st := range schema.SimpleType {
+			data, _ := json.Marshal(st)
+			fmt.Fprintln(w, "simpleType", string(data))
+		}
+		for _, attr := range schema.Attributes {
+			data, _ := json.Marshal(attr)
+			fmt.Fprintln(w, "attribute", string(data))
+		}
+		for _, el := range schema.Elements {
+			fmt.Fprintln(w, "element", el.Name, el.Type, el.Ref, el.ComplexType != nil, el.SimpleType != nil)
+		}
+		for _, ct := range schema.ComplexTypes {
+			fmt.Fprintln(w, "complexType", ct.Name, ct.Abstract, ct.ComplexContent.Extension.Base,
+				ct.ComplexContent.Restriction.Base, ct.SimpleContent.Extension.Base)
+			for _, elms := range [][]*XSDElement{ct.SequenceElements(), ct.Choice, ct.All} {
+				for _, el := range elms {
+					fmt.Fprintln(w, "field", el.Name, el.Ref)
+				}
+			}
+			for _, el := range ct.ComplexContent.Extension.Sequence {
+				fmt.Fprintln(w, "field", el.Name, el.Ref)
+			}
+			for _, attrs := range [][]*XSDAttribute{ct.Attributes, ct.ComplexContent.Extension.Attributes, ct.SimpleContent.Extension.Attributes} {
+				for _, attr := range attrs {
+					fmt.Fprintln(w, "field", attr.Name)
+				}
+			}
+			if g.flattenArrays && isArrayWrapper(ct) {
+				item := ct.Sequence[0]
+				fmt.Fprintln(w, "arrayItem", item.Name, item.Type, item.Ref, item.Nillable)
+			}
+		}
+	}
+	var names []string
+	for ct, name := range g.typeXMLNames {
+		names = append(names, "type "+ct.Name+" "+name)
+	}
+	for el, name := range g.elementTypeNames {
+		names = append(names, "element "+el.Name+" "+name)
+	}
+	for el, name := range g.elementRefTypes {
+		names = append(names, "ref "+el.Ref+" "+name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, name)
+	}
+}
+
+// writeChoiceOrders writes to w the positions of the choices of the
+// sequences of ct and of its local types, which are not encoded in JSON.
+func writeChoiceOrders(w io.Writer, ct *XSDComplexType) {
+	if ct == nil {
+		return
+	}
+	fmt.Fprintln(w, ct.choicesAt)
+	for _, elms := range [][]*XSDElement{ct.Sequence, ct.SequenceChoice, ct.Choice, ct.All} {
+		for _, el := range elms {
+			writeChoiceOrders(w, el.ComplexType)
+		}
+	}
+}
+
+// sum returns the key of the section kind rendered from the hashed inputs,
+// or an empty string when the cache is disabled or an input cannot be
+// hashed.
+func (k *renderKeys) sum(kind string, inputs ...string) string {
+	if k == nil {
+		return ""
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, kind, k.options)
+	for _, input := range inputs {
+		if input == "" {
+			return ""
+		}
+		fmt.Fprintln(h, input)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// operations returns the key of the operations, which depend on the element
+// declarations of every schema.
+func (k *renderKeys) operations(schemas []*XSDSchema) string {
+	if k == nil {
+		return ""
+	}
+	inputs := []string{k.names, k.wsdl}
+	for _, schema := range schemas {
+		inputs = append(inputs, k.schemas[schema])
+	}
+	return k.sum("operations", inputs...)
+}
+
+// renderEffects are what rendering a section tells the sections rendered
+// after it: the helpers and imports its code uses.
+type renderEffects struct {
+	HexBinary  bool     `json:"hexBinary,omitempty"`
+	WhiteSpace bool     `json:"whiteSpace,omitempty"`
+	IDs        bool     `json:"ids,omitempty"`
+	Lists      bool     `json:"lists,omitempty"`
+	RawXML     bool     `json:"rawXML,omitempty"`
+	Imports    []string `json:"imports,omitempty"`
+}
+
+// renderedSection is a cached section.
+type renderedSection struct {
+	Effects renderEffects `json:"effects"`
+	Code    string        `json:"code"`
+}
+
+// takeEffects returns the effects of the sections rendered so far and
+// clears them.
+func (g *GoWSDL) takeEffects() renderEffects {
+	effects := renderEffects{
+		HexBinary:  g.usesHexBinary.Swap(false),
+		WhiteSpace: g.usesWhiteSpace.Swap(false),
+		IDs:        g.usesIDs.Swap(false),
+		Lists:      g.usesLists.Swap(false),
+		RawXML:     g.usesRawXML.Swap(false),
+	}
+	effects.Imports = g.usedTypeImports()
+	g.typeImportsMu.Lock()
+	g.typeImports = nil
+	g.typeImportsMu.Unlock()
+	return effects
+}
+
+// applyEffects adds effects to those of the sections rendered so far.
+func (g *GoWSDL) applyEffects(effects renderEffects) {
+	if effects.HexBinary {
+		g.usesHexBinary.Store(true)
+	}
+	if effects.WhiteSpace {
+		g.usesWhiteSpace.Store(true)
+	}
+	if effects.IDs {
+		g.usesIDs.Store(true)
+	}
+	if effects.Lists {
+		g.usesLists.Store(true)
+	}
+	if effects.RawXML {
+		g.usesRawXML.Store(true)
+	}
+	for _, path := range effects.Imports {
+		g.useTypeImport(path)
+	}
+}
+
+// cachedRender returns the section cached under key, or renders and caches
+// it. An empty key renders the section without caching it. Sections must
+// not be rendered concurrently while the cache is enabled, since their
+// effects would be mixed.
+func (g *GoWSDL) cachedRender(key string, render func() ([]byte, error)) ([]byte, error) {
+	if key == "" {
+		return render()
+	}
+	file := filepath.Join(g.renderCache, key+".json")
+	if data, err := ioutil.ReadFile(file); err == nil {
+		section := new(renderedSection)
+		if err := json.Unmarshal(data, section); err == nil {
+			g.applyEffects(section.Effects)
+			return []byte(section.Code), nil
+		}
+	}
+
+	before := g.takeEffects()
+	code, err := render()
+	effects := g.takeEffects()
+	g.applyEffects(before)
+	g.applyEffects(effects)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(&renderedSection{Effects: effects, Code: string(code)})
+	if err == nil {
+		err = ioutil.WriteFile(file, data, 0600)
+	}
+	if err != nil {
+		log.Println("[WARN] Rendered code not cached:", err)
+	}
+	return code, nil
+}
+
+// genCachedTypes renders the types of each schema apart, reusing the cached
+// code of the unchanged ones, and then the helpers shared by all types.
+func (g *GoWSDL) genCachedTypes(tmpl *template.Template) ([]byte, error) {
+	data := new(bytes.Buffer)
+	for _, schema := range g.wsdl.Types.Schemas {
+		key := g.renderKeys.sum("schema", g.renderKeys.names, g.renderKeys.schemas[schema])
+		code, err := g.cachedRender(key, func() ([]byte, error) {
+			var b bytes.Buffer
+			err := tmpl.ExecuteTemplate(&b, "Schema", schema)
+			return b.Bytes(), err
+		})
+		if err != nil {
+			return nil, err
+		}
+		data.Write(code)
+	}
+	code, err := g.cachedRender(g.renderKeys.sum("types"), func() ([]byte, error) {
+		var b bytes.Buffer
+		err := tmpl.Execute(&b, &WSDLType{})
+		return b.Bytes(), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	data.Write(code)
+	return data.Bytes(), nil
+}
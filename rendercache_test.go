@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderCache(t *testing.T) {
+	wsdl, err := ioutil.ReadFile("fixtures/multitypes.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "orders.wsdl")
+	cacheDir := t.TempDir()
+
+	generate := func(cache string) []byte {
+		t.Helper()
+		g, err := NewGoWSDL(file, "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.SetRenderCache(cache); err != nil {
+			t.Fatal(err)
+		}
+		code, err := g.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		for _, section := range []string{"header", "types", "operations", "soap"} {
+			b.Write(code[section])
+		}
+		source, err := format.Source(b.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return source
+	}
+	check := func(step string, sections int) {
+		t.Helper()
+		expected := generate("")
+		for i := 0; i < 2; i++ {
+			if code := generate(cacheDir); !bytes.Equal(code, expected) {
+				t.Errorf("%s: got code:\n%s\nwant:\n%s", step, code, expected)
+			}
+		}
+		files, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != sections {
+			t.Errorf("%s: got %d cached sections, want %d", step, len(files), sections)
+		}
+	}
+
+	if err := ioutil.WriteFile(file, wsdl, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Both schemas, the shared helpers and the operations.
+	check("first generation", 4)
+
+	// Only the changed schema and the operations are rendered again.
+	changed := strings.Replace(string(wsdl), `name="amount" type="xs:decimal"`, `name="amount" type="xs:decimal" minOccurs="0"`, 1)
+	if changed == string(wsdl) {
+		t.Fatal("fixture not changed")
+	}
+	if err := ioutil.WriteFile(file, []byte(changed), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check("changed schema", 6)
+}
+
+func TestRenderCacheTemplateFuncs(t *testing.T) {
+	cacheDir := t.TempDir()
+	g, err := NewGoWSDL("fixtures/multitypes.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetRenderCache(cacheDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RegisterTemplateFunc("replaceReservedWords", strings.ToUpper); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// The functions may behave differently the next time.
+	files, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("got %d cached sections, want none", len(files))
+	}
+}
@@ -0,0 +1,125 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// SetSafeDownloads guards the generation from WSDL URLs supplied by
+// untrusted parties. Downloads then refuse to connect to loopback, private,
+// link-local and other non-public addresses, which is checked on the
+// addresses dialed, so that neither redirects nor DNS answers get around it.
+// Redirects and schema locations may not switch from https to another scheme,
+// and the schema locations of downloaded documents must be http or https
+// URLs, not files. Schema locations set with SetSchemaLocations are trusted.
+func (g *GoWSDL) SetSafeDownloads(enabled bool) {
+	g.safeDownloads = enabled
+}
+
+// nonPublicNetworks are the networks safe downloads do not connect to, on
+// top of the loopback, link-local, multicast and unspecified addresses.
+var nonPublicNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "172.16.0.0/12", "192.0.0.0/24",
+		"192.168.0.0/16", "198.18.0.0/15", "240.0.0.0/4", "fc00::/7",
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// nonPublicAddress reports whether safe downloads may not connect to ip.
+func nonPublicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeDial returns a dial function refusing non-public addresses. The proxy,
+// if any, is dialed whatever its address; the targets of proxied requests
+// are checked by checkSafeHost instead.
+func safeDial(proxy *url.URL) func(network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || nonPublicAddress(ip) {
+				return fmt.Errorf("connection to the non-public address %s is forbidden", host)
+			}
+			return nil
+		},
+	}
+	proxyAddr := ""
+	if proxy != nil {
+		port := proxy.Port()
+		if port == "" {
+			port = "80"
+			if proxy.Scheme == "https" {
+				port = "443"
+			}
+		}
+		proxyAddr = net.JoinHostPort(proxy.Hostname(), port)
+	}
+	return func(network, addr string) (net.Conn, error) {
+		if addr == proxyAddr {
+			return net.DialTimeout(network, addr, timeout)
+		}
+		return dialer.Dial(network, addr)
+	}
+}
+
+// checkSafeHost fails when the host of u resolves to a non-public address.
+func checkSafeHost(u *url.URL) error {
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if nonPublicAddress(ip) {
+			return fmt.Errorf("%s resolves to the non-public address %s, which is forbidden", u.Hostname(), ip)
+		}
+	}
+	return nil
+}
+
+// checkSafeScheme fails when the scheme switches from https to another one
+// on the way from from to to.
+func checkSafeScheme(from, to *url.URL) error {
+	if from.Scheme == "https" && to.Scheme != "https" {
+		return fmt.Errorf("switching from https to %s for %s is forbidden", to.Scheme, to)
+	}
+	return nil
+}
+
+// checkSafeSchemaLocation fails when the schema at loc may not be read for
+// the document at base with safe downloads.
+func checkSafeSchemaLocation(base, loc *Location) error {
+	if !base.IsURL() {
+		return nil
+	}
+	if !loc.IsURL() || (loc.u.Scheme != "http" && loc.u.Scheme != "https") {
+		return fmt.Errorf("schema location %s of the downloaded %s is not an http or https URL, which is forbidden", loc, base)
+	}
+	return checkSafeScheme(base.u, loc.u)
+}
@@ -0,0 +1,90 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNonPublicAddress(t *testing.T) {
+	for address, expected := range map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"172.20.0.1":      true,
+		"192.168.1.1":     true,
+		"169.254.169.254": true,
+		"100.64.0.1":      true,
+		"0.0.0.0":         true,
+		"::1":             true,
+		"fd00::1":         true,
+		"fe80::1":         true,
+		"93.184.216.34":   false,
+		"172.32.0.1":      false,
+		"2606:2800::1":    false,
+	} {
+		if actual := nonPublicAddress(net.ParseIP(address)); actual != expected {
+			t.Errorf("%s: got %v, want %v", address, actual, expected)
+		}
+	}
+}
+
+func TestSafeDownloads(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<?xml version="1.0"?><definitions/>`))
+	}))
+	defer srv.Close()
+
+	if _, _, err := downloadFile(srv.URL, downloadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := downloadFile(srv.URL, downloadOptions{safe: true})
+	if err == nil || !strings.Contains(err.Error(), "connection to the non-public address 127.0.0.1 is forbidden") {
+		t.Errorf("downloads from loopback addresses should be refused, got %v", err)
+	}
+
+	// The proxy is dialed whatever its address, not the proxied hosts.
+	proxy, _ := url.Parse(srv.URL)
+	_, _, err = downloadFile("http://localhost/service.wsdl", downloadOptions{safe: true, proxy: proxy})
+	if err == nil || !strings.Contains(err.Error(), "localhost resolves to the non-public address") {
+		t.Errorf("proxied downloads from loopback addresses should be refused, got %v", err)
+	}
+}
+
+func TestSafeSchemaLocations(t *testing.T) {
+	tests := []struct {
+		base, location string
+		err            string
+	}{
+		{"https://example.com/service.wsdl", "https://example.com/types.xsd", ""},
+		{"http://example.com/service.wsdl", "http://example.com/types.xsd", ""},
+		{"https://example.com/service.wsdl", "http://example.com/types.xsd", "switching from https to http"},
+		{"https://example.com/service.wsdl", "file:///etc/passwd", "is not an http or https URL"},
+		{"http://example.com/service.wsdl", "ftp://example.com/types.xsd", "is not an http or https URL"},
+		{"/wsdl/service.wsdl", "types.xsd", ""},
+	}
+	for _, test := range tests {
+		base, err := ParseLocation(test.base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loc, err := base.Parse(test.location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = checkSafeSchemaLocation(base, loc)
+		if test.err == "" && err != nil {
+			t.Errorf("%s from %s: %v", test.location, test.base, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s from %s: got error %v, want %q", test.location, test.base, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+)
+
+// SetSchemaAllowList restricts the downloads of imported and included
+// schemas to the ones matching a pattern, failing the generation for any
+// other schema before it is requested. A pattern is either a host, which may
+// start with a wildcard such as "*.example.com", or a URL prefix such as
+// "https://example.com/schemas/", matched against the schema location and
+// the imported namespace. Schema files are always read.
+func (g *GoWSDL) SetSchemaAllowList(patterns []string) {
+	g.schemaAllowList = patterns
+}
+
+// SetSchemaDenyList skips the downloads of imported and included schemas
+// matching a pattern, such as "www.w3.org", leaving their types unresolved.
+// Patterns are those of SetSchemaAllowList, and the deny list is checked
+// first.
+func (g *GoWSDL) SetSchemaDenyList(patterns []string) {
+	g.schemaDenyList = patterns
+}
+
+// schemaDownloadAllowed reports whether the schema of namespace at loc may
+// be downloaded. It returns an error for schemas missing from the allow list.
+func (g *GoWSDL) schemaDownloadAllowed(loc *Location, namespace string) (bool, error) {
+	if !loc.IsURL() {
+		return true, nil
+	}
+	if pattern := matchSchemaPattern(g.schemaDenyList, loc, namespace); pattern != "" {
+		log.Printf("[WARN] Schema %s is not downloaded, it matches the deny list pattern %q", loc, pattern)
+		return false, nil
+	}
+	if len(g.schemaAllowList) > 0 && matchSchemaPattern(g.schemaAllowList, loc, namespace) == "" {
+		return false, fmt.Errorf("schema %s matches no pattern of the allow list", loc)
+	}
+	return true, nil
+}
+
+// matchSchemaPattern returns the first of patterns matching the host or URL
+// of loc or namespace, or an empty string.
+func matchSchemaPattern(patterns []string, loc *Location, namespace string) string {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "://") {
+			if strings.HasPrefix(loc.String(), pattern) || (namespace != "" && strings.HasPrefix(namespace, pattern)) {
+				return pattern
+			}
+			continue
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(loc.u.Hostname())); ok {
+			return pattern
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMatchSchemaPattern(t *testing.T) {
+	patterns := []string{"www.w3.org", "*.mycorp.com", "https://schemas.example.com/public/"}
+	for location, expected := range map[string]string{
+		"http://www.w3.org/2001/xml.xsd":              "www.w3.org",
+		"https://xsd.MyCorp.com/orders.xsd":           "*.mycorp.com",
+		"https://mycorp.com/orders.xsd":               "",
+		"https://schemas.example.com/public/a.xsd":    "https://schemas.example.com/public/",
+		"https://schemas.example.com/private/a.xsd":   "",
+		"https://schemas.example.com:8443/public/a.x": "",
+	} {
+		loc, err := ParseLocation(location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pattern := matchSchemaPattern(patterns, loc, ""); pattern != expected {
+			t.Errorf("%s: got pattern %q, want %q", location, pattern, expected)
+		}
+	}
+
+	loc, _ := ParseLocation("https://cdn.example.net/a.xsd")
+	if pattern := matchSchemaPattern(patterns, loc, "https://schemas.example.com/public/orders"); pattern == "" {
+		t.Error("URL patterns should match the imported namespace")
+	}
+}
+
+func TestSchemaDownloadLists(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.URL.Path {
+		case "/service.wsdl":
+			w.Write([]byte(`<?xml version="1.0"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <types>
+    <xs:schema targetNamespace="http://example.org/orders">
+      <xs:import namespace="http://www.w3.org/XML/1998/namespace" schemaLocation="http://www.w3.org/2001/xml.xsd"/>
+      <xs:import namespace="http://example.org/common" schemaLocation="common.xsd"/>
+    </xs:schema>
+  </types>
+</definitions>`))
+		case "/common.xsd":
+			w.Write([]byte(`<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="http://example.org/common"/>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		allow    []string
+		requests int32
+		err      string
+	}{
+		{nil, 2, ""},
+		{[]string{"*.mycorp.com"}, 1, "matches no pattern of the allow list"},
+		{[]string{srv.URL + "/"}, 2, ""},
+	}
+	for _, test := range tests {
+		atomic.StoreInt32(&requests, 0)
+		g, err := NewGoWSDL(srv.URL+"/service.wsdl", "myservice", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.SetSchemaDenyList([]string{"www.w3.org"})
+		g.SetSchemaAllowList(test.allow)
+
+		err = g.load()
+		if test.err == "" && err != nil {
+			t.Errorf("allow %v: %v", test.allow, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("allow %v: got error %v, want %q", test.allow, err, test.err)
+		}
+		if n := atomic.LoadInt32(&requests); n != test.requests {
+			t.Errorf("allow %v: got %d requests, want %d", test.allow, n, test.requests)
+		}
+	}
+}
@@ -7,10 +7,6 @@ package gowsdl
 var soapTmpl = `
 var timeout = time.Duration(30 * time.Second)
 
-func dialTimeout(network, addr string) (net.Conn, error) {
-	return net.DialTimeout(network, addr, timeout)
-}
-
 type SOAPEnvelope struct {
 	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"` + "`" + `
 	Header *SOAPHeader
@@ -20,14 +16,14 @@ type SOAPEnvelope struct {
 type SOAPHeader struct {
 	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Header"` + "`" + `
 
-	Items []interface{} ` + "`" + `xml:",omitempty"` + "`" + `
+	Items []{{emptyInterface}} ` + "`" + `xml:",omitempty"` + "`" + `
 }
 
 type SOAPBody struct {
 	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"` + "`" + `
 
 	Fault   *SOAPFault ` + "`" + `xml:",omitempty"` + "`" + `
-	Content interface{} ` + "`" + `xml:",omitempty"` + "`" + `
+	Content {{emptyInterface}} ` + "`" + `xml:",omitempty"` + "`" + `
 }
 
 type SOAPFault struct {
@@ -81,16 +77,386 @@ type WSSPassword struct {
 	Data string ` + "`" + `xml:",chardata"` + "`" + `
 }
 
+// BasicAuth is a CredentialsProvider sending a fixed login and password.
 type BasicAuth struct {
 	Login    string
 	Password string
 }
 
+// Credentials returns the login and password of a, or no credentials when
+// a is nil.
+func (a *BasicAuth) Credentials(ctx context.Context) (Credentials, error) {
+	if a == nil {
+		return Credentials{}, nil
+	}
+	return Credentials{Username: a.Login, Password: a.Password}, nil
+}
+
+// Credentials authenticate a request, with a bearer token when Token is set
+// and with HTTP basic authentication when Username is.
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// CredentialsProvider returns the credentials of every call, so that they
+// can be rotated, for instance from a vault, without creating a new client.
+// An error fails the call.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsFunc is a CredentialsProvider calling itself.
+type CredentialsFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials returns f(ctx).
+func (f CredentialsFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
 type SOAPClient struct {
-	url     string
-	tlsCfg  *tls.Config
-	auth    *BasicAuth
-	headers []interface{}
+	url        string
+	tlsCfg     *tls.Config
+	auth       CredentialsProvider
+	headers    []{{emptyInterface}}
+	transport  http.RoundTripper
+	addressing bool
+	requireTLS bool
+	headerFaults map[string]headerFaultType
+	httpHeaders http.Header
+	requestID  func(ctx context.Context) string
+	negotiate  NegotiateProvider
+	proxy      *url.URL
+	proxyAuth  *url.Userinfo
+	dial       func(ctx context.Context, network, addr string) (net.Conn, error)
+	contentType string
+	actionInContentType bool
+	streamRequests bool
+	prefixNamespaces bool
+	canonicalXML bool
+	indent     string
+	matchLocalNames bool
+	lenientWarn func(message string)
+	timeouts   Timeouts
+	operationTimeouts map[string]time.Duration
+	defaultTimeout time.Duration
+	client     *http.Client
+}
+
+// ClientOption customizes a SOAPClient.
+type ClientOption func(*SOAPClient)
+
+// WithTransport makes the client send requests through transport instead of
+// its default one; the TLS configuration is then left to transport.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(s *SOAPClient) {
+		s.transport = transport
+	}
+}
+
+// Content types of SOAP requests.
+const (
+	SOAP11ContentType = "text/xml; charset=\"utf-8\""
+	SOAP12ContentType = "application/soap+xml; charset=utf-8"
+)
+
+// WithContentType sets the Content-Type of the requests, SOAP11ContentType
+// by default, for servers expecting another one such as SOAP12ContentType.
+// The envelope is not changed.
+func WithContentType(contentType string) ClientOption {
+	return func(s *SOAPClient) {
+		s.contentType = contentType
+	}
+}
+
+// WithActionInContentType sends the SOAPAction of the requests as the
+// action parameter of their Content-Type, as SOAP 1.2 does, instead of in
+// the SOAPAction header.
+func WithActionInContentType() ClientOption {
+	return func(s *SOAPClient) {
+		s.actionInContentType = true
+	}
+}
+
+// WithStreamingRequests makes the client encode the envelopes of its
+// requests while sending them, in chunks, instead of buffering and logging
+// them first, which keeps requests carrying large documents out of memory.
+// Encoding errors then fail the calls as transport errors.
+func WithStreamingRequests() ClientOption {
+	return func(s *SOAPClient) {
+		s.streamRequests = true
+	}
+}
+
+// WithIndentedRequests makes the client indent the elements of the envelopes
+// of its requests with indent per level, to read them more easily or for
+// parsers expecting it. Envelopes are sent without indentation by default.
+func WithIndentedRequests(indent string) ClientOption {
+	return func(s *SOAPClient) {
+		s.indent = indent
+	}
+}
+
+// WithNamespacePrefixes makes the client declare the namespaces of the
+// elements and attributes of its requests once, with prefixes, on their
+// envelopes, instead of on every element in them, for servers rejecting the
+// latter. The envelopes are then encoded in full before being sent, even
+// WithStreamingRequests.
+func WithNamespacePrefixes() ClientOption {
+	return func(s *SOAPClient) {
+		s.prefixNamespaces = true
+	}
+}
+
+// WithCanonicalXML makes the client send the envelopes of its requests in
+// their exclusive canonical form (Exclusive XML Canonicalization 1.0,
+// without comments), as signing them with WS-Security requires. Namespaces
+// are then declared on the first elements using them, even
+// WithNamespacePrefixes, and the envelopes are encoded in full before being
+// sent, even WithStreamingRequests.
+func WithCanonicalXML() ClientOption {
+	return func(s *SOAPClient) {
+		s.canonicalXML = true
+	}
+}
+
+// WithLocalNameMatching makes the client decode the elements of responses
+// by their local names when their namespaces differ from the ones of the
+// WSDL, as answered through some middlewares, instead of failing. Envelopes
+// of another SOAP version are decoded as well. Clients match the names of
+// elements exactly by default.
+func WithLocalNameMatching() ClientOption {
+	return func(s *SOAPClient) {
+		s.matchLocalNames = true
+	}
+}
+
+// WithLenientResponses makes the client match the elements of responses
+// like WithLocalNameMatching, since servers often change their responses
+// ahead of their published WSDL. warn, when not nil, is called for each
+// element whose namespace differs and for the elements and attributes of the
+// body the response types do not have, which are ignored either way.
+func WithLenientResponses(warn func(message string)) ClientOption {
+	return func(s *SOAPClient) {
+		s.matchLocalNames = true
+		s.lenientWarn = warn
+	}
+}
+
+// WithDialContext makes the client open its connections with dial instead
+// of dialing TCP with a timeout, for instance to reach a sidecar or a test
+// double. It has no effect together with WithTransport.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(s *SOAPClient) {
+		s.dial = dial
+	}
+}
+
+// Timeouts bound the phases of the HTTP exchanges of a client, such as with
+// slow services that take long to answer but must not hang forever. Zero
+// values leave the phases unbounded, but for Dial.
+type Timeouts struct {
+	// Dial bounds the opening of connections, 30 seconds by default.
+	Dial time.Duration
+	// KeepAlive is the interval between the TCP keep-alive probes of the
+	// connections, 15 seconds by default. Negative values disable them.
+	KeepAlive time.Duration
+	// TLSHandshake bounds the TLS handshakes.
+	TLSHandshake time.Duration
+	// ResponseHeader bounds the wait for the headers of the responses once
+	// the requests are written, which includes the processing of the calls.
+	ResponseHeader time.Duration
+	// ExpectContinue bounds the wait for the 100 Continue response of
+	// requests sent with an "Expect: 100-continue" header, before their
+	// body is sent anyway.
+	ExpectContinue time.Duration
+}
+
+// WithTimeouts sets the timeouts of the transport of the client, apart
+// from the deadlines of the contexts of the calls. It has no effect
+// together with WithTransport.
+func WithTimeouts(timeouts Timeouts) ClientOption {
+	return func(s *SOAPClient) {
+		s.timeouts = timeouts
+	}
+}
+
+// WithOperationTimeouts bounds the calls of the operations named in
+// timeouts, by the method names of the clients, by their durations, and the
+// calls of the other operations by fallback, so that slow operations, such
+// as the generation of reports, get longer deadlines than the others. Zero
+// durations leave calls unbounded. The deadlines of the contexts of the
+// calls still apply. HTTP GET/POST calls are bounded by fallback.
+func WithOperationTimeouts(timeouts map[string]time.Duration, fallback time.Duration) ClientOption {
+	return func(s *SOAPClient) {
+		s.operationTimeouts = make(map[string]time.Duration, len(timeouts))
+		for operation, timeout := range timeouts {
+			s.operationTimeouts[operation] = timeout
+		}
+		s.defaultTimeout = fallback
+	}
+}
+
+// WithUnixSocket makes the client connect to the unix domain socket at path
+// whatever the host of the service URL, which still sets the Host header.
+// It has no effect together with WithTransport.
+func WithUnixSocket(path string) ClientOption {
+	return WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	})
+}
+
+// WithProxy sends the requests of the client through the forward proxy at
+// proxyURL, which tunnels https ones with CONNECT. Credentials in proxyURL,
+// or set WithProxyAuth, are only sent to the proxy, as Proxy-Authorization.
+// It has no effect together with WithTransport.
+func WithProxy(proxyURL *url.URL) ClientOption {
+	return func(s *SOAPClient) {
+		s.proxy = proxyURL
+	}
+}
+
+// WithProxyAuth sets the basic auth credentials of the proxy of WithProxy,
+// in place of those of the proxy URL and apart from those of the service.
+func WithProxyAuth(username, password string) ClientOption {
+	return func(s *SOAPClient) {
+		s.proxyAuth = url.UserPassword(username, password)
+	}
+}
+
+// WithUsernameToken adds a WS-Security UsernameToken header carrying
+// username and password to every request.
+func WithUsernameToken(username, password string) ClientOption {
+	return func(s *SOAPClient) {
+		s.AddHeader(NewWSSSecurityHeader(username, password, "1"))
+	}
+}
+
+// WithAddressing adds the WS-Addressing Action, To and MessageID headers to
+// every request.
+func WithAddressing() ClientOption {
+	return func(s *SOAPClient) {
+		s.addressing = true
+	}
+}
+
+// WithHTTPHeader sets the HTTP header key to value on every request, after
+// the headers set by the client itself.
+func WithHTTPHeader(key, value string) ClientOption {
+	return func(s *SOAPClient) {
+		if s.httpHeaders == nil {
+			s.httpHeaders = make(http.Header)
+		}
+		s.httpHeaders.Set(key, value)
+	}
+}
+
+// NegotiateProvider produces the SPNEGO tokens authenticating requests with
+// Kerberos, such as in Windows domains. It keeps the Kerberos
+// implementation, which may wrap gokrb5 or SSPI, out of the generated code.
+type NegotiateProvider interface {
+	// NegotiateToken returns the base64 encoded token of a request to host,
+	// whose service principal is usually HTTP/host.
+	NegotiateToken(ctx context.Context, host string) (string, error)
+}
+
+// WithNegotiate makes the client authenticate every request with the SPNEGO
+// tokens of provider instead of its credentials.
+func WithNegotiate(provider NegotiateProvider) ClientOption {
+	return func(s *SOAPClient) {
+		s.negotiate = provider
+	}
+}
+
+// RequestIDHeader is the HTTP header carrying the IDs of WithRequestIDFunc.
+const RequestIDHeader = "X-Request-ID"
+
+// WithRequestIDFunc stamps the ID fn returns for the context of every call
+// in the RequestIDHeader HTTP header, and in the WS-Addressing MessageID of
+// clients created WithAddressing, so that SOAP traffic can be tied to the
+// logs of the caller. IDs without a URI scheme, such as bare UUIDs, are sent
+// as urn:uuid: URNs in the MessageID. Calls for which fn returns an empty ID
+// are sent without one.
+func WithRequestIDFunc(fn func(ctx context.Context) string) ClientOption {
+	return func(s *SOAPClient) {
+		s.requestID = fn
+	}
+}
+
+// CallOption customizes the HTTP request of a single call.
+type CallOption func(*http.Request)
+
+// WithCallHeader sets the HTTP header key to value on the request of a
+// single call, overriding the headers set by the client.
+func WithCallHeader(key, value string) CallOption {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+// ResponseInfo describes the HTTP response of a call, for reading what
+// servers send outside of the SOAP envelope, such as custom headers.
+type ResponseInfo struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	// Duration is the time from sending the request to reading the whole
+	// response.
+	Duration time.Duration
+}
+
+// responseInfoKey is the context key of the ResponseInfo of a call.
+type responseInfoKey struct{}
+
+// WithResponseInfo makes a call store the status, headers and timing of its
+// HTTP response in info, including when it fails with a fault or an HTTP
+// error. info is left unchanged when no response is received.
+func WithResponseInfo(info *ResponseInfo) CallOption {
+	return func(req *http.Request) {
+		*req = *req.WithContext(context.WithValue(req.Context(), responseInfoKey{}, info))
+	}
+}
+
+// recordResponse stores res in the ResponseInfo of the call of req, if
+// any, given when req was sent.
+func recordResponse(req *http.Request, res *http.Response, sent time.Time) {
+	if info, ok := req.Context().Value(responseInfoKey{}).(*ResponseInfo); ok {
+		info.StatusCode = res.StatusCode
+		info.Status = res.Status
+		info.Header = res.Header
+		info.Duration = time.Since(sent)
+	}
+}
+
+// WithRequireTLS makes calls fail with ErrTLSRequired unless the service URL
+// is an https one.
+func WithRequireTLS() ClientOption {
+	return func(s *SOAPClient) {
+		s.requireTLS = true
+	}
+}
+
+// ErrTLSRequired is returned by calls to a non-https URL through a client
+// created WithRequireTLS.
+var ErrTLSRequired = errors.New("the service policy requires TLS but the URL is not https")
+
+// ErrEmptyResponse is returned by calls of operations that have a response
+// answered with an empty body, such as a 202 Accepted or a 204 No Content.
+// The response is left as it is.
+var ErrEmptyResponse = errors.New("the service answered with an empty body")
+
+// HTTPError is returned for responses with a non-2xx status whose body is not
+// a SOAP fault.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Status, bytes.TrimSpace(e.Body))
 }
 
 // **********
@@ -124,6 +490,49 @@ func randStringBytesMaskImprSrc(n int) string {
 
 // **********
 
+// WsaNs is the WS-Addressing 1.0 namespace.
+const WsaNs = "http://www.w3.org/2005/08/addressing"
+
+// WSAHeader is a WS-Addressing message addressing property, such as Action.
+type WSAHeader struct {
+	XMLName xml.Name
+	Value   string ` + "`" + `xml:",chardata"` + "`" + `
+}
+
+// addressingHeaders returns the WS-Addressing headers of a request, with a
+// random MessageID unless requestID is set.
+func addressingHeaders(url, soapAction, requestID string) []{{emptyInterface}} {
+	messageID := requestID
+	if messageID == "" {
+		messageID = newMessageID()
+	} else if !strings.Contains(messageID, ":") {
+		messageID = "urn:uuid:" + messageID
+	}
+	return []{{emptyInterface}}{
+		&WSAHeader{XMLName: xml.Name{Space: WsaNs, Local: "Action"}, Value: soapAction},
+		&WSAHeader{XMLName: xml.Name{Space: WsaNs, Local: "To"}, Value: url},
+		&WSAHeader{XMLName: xml.Name{Space: WsaNs, Local: "MessageID"}, Value: messageID},
+	}
+}
+
+// newMessageID returns a random version 4 UUID URN.
+func newMessageID() string {
+	b := make([]byte, 16)
+	rand.New(rand.NewSource(time.Now().UnixNano())).Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	const digits = "0123456789abcdef"
+	id := make([]byte, 0, 36)
+	for i, c := range b {
+		if i == 4 || i == 6 || i == 8 || i == 10 {
+			id = append(id, '-')
+		}
+		id = append(id, digits[c>>4], digits[c&0x0f])
+	}
+	return "urn:uuid:" + string(id)
+}
+
 func NewWSSSecurityHeader(user, pass, mustUnderstand string) *WSSSecurityHeader {
 	hdr := &WSSSecurityHeader{XmlNSWsse: WssNsWSSE, MustUnderstand: mustUnderstand}
 	hdr.Token = &WSSUsernameToken{XmlNSWsu: WssNsWSU, XmlNSWsse: WssNsWSSE, Id: "UsernameToken-" + randStringBytesMaskImprSrc(9)}
@@ -186,71 +595,303 @@ func (f *SOAPFault) Error() string {
 	return f.String
 }
 
-func NewSOAPClient(url string, insecureSkipVerify bool, auth *BasicAuth) *SOAPClient {
+// HeaderFault is returned when the header of a response carries a fault
+// registered with RegisterHeaderFault. Detail holds the decoded header
+// block.
+type HeaderFault struct {
+	Name   xml.Name
+	Detail {{emptyInterface}}
+}
+
+func (f *HeaderFault) Error() string {
+	return "soap header fault " + f.Name.Local
+}
+
+func NewSOAPClient(url string, insecureSkipVerify bool, auth CredentialsProvider, opts ...ClientOption) *SOAPClient {
 	tlsCfg := &tls.Config{
 	       InsecureSkipVerify: insecureSkipVerify,
 	}
-	return NewSOAPClientWithTLSConfig(url, tlsCfg, auth)
+	return NewSOAPClientWithTLSConfig(url, tlsCfg, auth, opts...)
 }
 
-func NewSOAPClientWithTLSConfig(url string, tlsCfg *tls.Config, auth *BasicAuth) *SOAPClient {
-	return &SOAPClient{
+func NewSOAPClientWithTLSConfig(url string, tlsCfg *tls.Config, auth CredentialsProvider, opts ...ClientOption) *SOAPClient {
+	s := &SOAPClient{
 		url: url,
 		tlsCfg: tlsCfg,
 		auth: auth,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.client = s.newHTTPClient()
+	return s
+}
+
+// authenticate sets the credentials of the call bound to req on it.
+func (s *SOAPClient) authenticate(req *http.Request) error {
+	if s.negotiate != nil {
+		token, err := s.negotiate.NegotiateToken(req.Context(), req.URL.Hostname())
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Negotiate "+token)
+		return nil
+	}
+	if s.auth == nil {
+		return nil
+	}
+	creds, err := s.auth.Credentials(req.Context())
+	if err != nil {
+		return err
+	}
+	if creds.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+creds.Token)
+	} else if creds.Username != "" {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	return nil
 }
 
-func (s *SOAPClient) AddHeader(header interface{}) {
+// newHTTPClient returns the HTTP client sending the client's requests. It
+// is built once per client, so that connections are kept alive and reused
+// across calls.
+func (s *SOAPClient) newHTTPClient() *http.Client {
+	tr := s.transport
+	if tr == nil {
+		dialer := &net.Dialer{Timeout: timeout, KeepAlive: s.timeouts.KeepAlive}
+		if s.timeouts.Dial > 0 {
+			dialer.Timeout = s.timeouts.Dial
+		}
+		transport := &http.Transport{
+			TLSClientConfig:       s.tlsCfg,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   s.timeouts.TLSHandshake,
+			ResponseHeaderTimeout: s.timeouts.ResponseHeader,
+			ExpectContinueTimeout: s.timeouts.ExpectContinue,
+		}
+		if s.dial != nil {
+			transport.DialContext = s.dial
+		}
+		if s.proxy != nil {
+			proxy := *s.proxy
+			if s.proxyAuth != nil {
+				proxy.User = s.proxyAuth
+			}
+			transport.Proxy = http.ProxyURL(&proxy)
+		}
+		tr = transport
+	}
+	return &http.Client{Transport: tr}
+}
+
+// operationContext returns ctx bounded by the timeout of the calls of
+// operation, and the function releasing its resources.
+func (s *SOAPClient) operationContext(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	timeout, ok := s.operationTimeouts[operation]
+	if !ok {
+		timeout = s.defaultTimeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// callRequestID returns the request ID of a call bound to ctx, if any.
+func (s *SOAPClient) callRequestID(ctx context.Context) string {
+	if s.requestID == nil {
+		return ""
+	}
+	return s.requestID(ctx)
+}
+
+// setHTTPHeaders sets the HTTP headers configured on the client and the
+// request ID of the call, then applies the options of the call to req.
+func (s *SOAPClient) setHTTPHeaders(req *http.Request, requestID string, opts []CallOption) {
+	for key, values := range s.httpHeaders {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+}
+
+func (s *SOAPClient) AddHeader(header {{emptyInterface}}) {
 	s.headers = append(s.headers, header)
 }
 
-func (s *SOAPClient) Call(soapAction string, request, response interface{}) error {
+// headerFaultType decodes a registered header fault.
+type headerFaultType struct {
+	typeName  string
+	newDetail func() {{emptyInterface}}
+}
+
+// RegisterHeaderFault makes calls fail with a *HeaderFault when a response
+// header holds an element of the given local name, decoded into the value
+// newDetail returns. typeName is the element name that value expects,
+// which is the name of its type for elements declared with one.
+func (s *SOAPClient) RegisterHeaderFault(element, typeName string, newDetail func() {{emptyInterface}}) {
+	if s.headerFaults == nil {
+		s.headerFaults = make(map[string]headerFaultType)
+	}
+	s.headerFaults[element] = headerFaultType{typeName: typeName, newDetail: newDetail}
+}
+
+// headerFault returns the first registered fault in the header of the
+// response envelope rawbody, if any.
+func (s *SOAPClient) headerFault(rawbody []byte) error {
+	if len(s.headerFaults) == 0 {
+		return nil
+	}
+	d := xml.NewDecoder(bytes.NewReader(rawbody))
+	inHeader := false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			// Malformed envelopes are reported when decoding the response.
+			return nil
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !inHeader {
+				if t.Name.Local == "Body" {
+					return nil
+				}
+				inHeader = t.Name.Local == "Header"
+				continue
+			}
+			fault, ok := s.headerFaults[t.Name.Local]
+			if !ok {
+				if err := d.Skip(); err != nil {
+					return nil
+				}
+				continue
+			}
+			detail := fault.newDetail()
+			start := t
+			start.Name.Local = fault.typeName
+			if err := d.DecodeElement(detail, &start); err != nil {
+				return err
+			}
+			return &HeaderFault{Name: t.Name, Detail: detail}
+		case xml.EndElement:
+			if inHeader {
+				return nil
+			}
+		}
+	}
+}
+
+func (s *SOAPClient) Call(soapAction string, request, response {{emptyInterface}}, opts ...CallOption) error {
+	return s.CallContext(context.Background(), soapAction, request, response, opts...)
+}
+
+// CallContext performs the SOAP call; the HTTP request is bound to ctx.
+func (s *SOAPClient) CallContext(ctx context.Context, soapAction string, request, response {{emptyInterface}}, opts ...CallOption) error {
+	return s.call(ctx, "", soapAction, request, response, nil, opts...)
+}
+
+// responseHeader is a response header element a call decodes into value,
+// given its local name and the XML name value expects, which is the name of
+// its type for elements declared with one.
+type responseHeader struct {
+	element  string
+	typeName string
+	value    {{emptyInterface}}
+}
+
+// call performs the SOAP call of operation like CallContext, bounded by the
+// timeout of operation, and decodes the response headers of the elements of
+// headers into their values.
+func (s *SOAPClient) call(ctx context.Context, operation, soapAction string, request, response {{emptyInterface}}, headers []responseHeader, opts ...CallOption) error {
+	ctx, cancel := s.operationContext(ctx, operation)
+	defer cancel()
+
 	envelope := SOAPEnvelope{}
+	requestID := s.callRequestID(ctx)
 
-	if s.headers != nil && len(s.headers) > 0 {
-		soapHeader := &SOAPHeader{Items: make([]interface{}, len(s.headers))}
-		copy(soapHeader.Items, s.headers)
+	requestHeaders := s.headers
+	if s.addressing {
+		requestHeaders = append(requestHeaders[:len(requestHeaders):len(requestHeaders)], addressingHeaders(s.url, soapAction, requestID)...)
+	}
+	if len(requestHeaders) > 0 {
+		soapHeader := &SOAPHeader{Items: make([]{{emptyInterface}}, len(requestHeaders))}
+		copy(soapHeader.Items, requestHeaders)
 		envelope.Header = soapHeader
 	}
 
 	envelope.Body.Content = request
-	buffer := new(bytes.Buffer)
+	var body io.Reader
+	streamRequest := s.streamRequests && !s.prefixNamespaces && !s.canonicalXML
+	if !streamRequest {
+		buffer := new(bytes.Buffer)
 
-	encoder := xml.NewEncoder(buffer)
-	//encoder.Indent("  ", "    ")
+		encoder := xml.NewEncoder(buffer)
+		encoder.Indent("", s.indent)
 
-	if err := encoder.Encode(envelope); err != nil {
-		return err
-	}
+		if err := encoder.Encode(envelope); err != nil {
+			return err
+		}
 
-	if err := encoder.Flush(); err != nil {
-		return err
-	}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
 
-	log.Println(buffer.String())
+		if s.prefixNamespaces {
+			doc, err := prefixNamespaces(buffer.Bytes())
+			if err != nil {
+				return err
+			}
+			buffer = bytes.NewBuffer(doc)
+		}
+		if s.canonicalXML {
+			doc, err := canonicalXML(buffer.Bytes())
+			if err != nil {
+				return err
+			}
+			buffer = bytes.NewBuffer(doc)
+		}
 
-	req, err := http.NewRequest("POST", s.url, buffer)
+		log.Println(buffer.String())
+		body = buffer
+	}
+
+	req, err := http.NewRequest("POST", s.url, body)
 	if err != nil {
 		return err
 	}
-	if s.auth != nil {
-		req.SetBasicAuth(s.auth.Login, s.auth.Password)
+	if s.requireTLS && req.URL.Scheme != "https" {
+		return ErrTLSRequired
+	}
+	req = req.WithContext(ctx)
+	if err := s.authenticate(req); err != nil {
+		return err
 	}
 
-	req.Header.Add("Content-Type", "text/xml; charset=\"utf-8\"")
-	req.Header.Add("SOAPAction", soapAction)
+	contentType := s.contentType
+	if contentType == "" {
+		contentType = SOAP11ContentType
+	}
+	if s.actionInContentType {
+		req.Header.Add("Content-Type", contentType+"; action=\""+soapAction+"\"")
+	} else {
+		req.Header.Add("Content-Type", contentType)
+		req.Header.Add("SOAPAction", soapAction)
+	}
 
 	req.Header.Set("User-Agent", "gowsdl/0.1")
-	req.Close = true
-
-	tr := &http.Transport{
-		TLSClientConfig: s.tlsCfg,
-		Dial: dialTimeout,
+	s.setHTTPHeaders(req, requestID, opts)
+	if streamRequest {
+		// The transport closes the body, which stops the encoding, when
+		// the request fails.
+		req.Body = streamXML(envelope, s.indent)
 	}
 
-	client := &http.Client{Transport: tr}
-	res, err := client.Do(req)
+	sent := time.Now()
+	res, err := s.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -260,15 +901,42 @@ func (s *SOAPClient) Call(soapAction string, request, response interface{}) erro
 	if err != nil {
 		return err
 	}
-	if len(rawbody) == 0 {
+	recordResponse(req, res, sent)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		log.Println(string(rawbody))
+		return s.statusError(res, rawbody)
+	}
+	if len(bytes.TrimSpace(rawbody)) == 0 {
 		log.Println("empty response")
+		// Only one-way operations are answered without an envelope.
+		if response != nil {
+			return ErrEmptyResponse
+		}
+		return nil
+	}
+	if response == nil && res.StatusCode == http.StatusAccepted {
 		return nil
 	}
 
 	log.Println(string(rawbody))
+	if response == nil {
+		// One-way operations still have to surface faults.
+		response = &struct{}{}
+	}
+	rawbody, err = resolveMultiRefs(rawbody)
+	if err != nil {
+		return err
+	}
+	if err := s.headerFault(rawbody); err != nil {
+		return err
+	}
 	respEnvelope := new(SOAPEnvelope)
 	respEnvelope.Body = SOAPBody{Content: response}
-	err = xml.Unmarshal(rawbody, respEnvelope)
+	if s.matchLocalNames {
+		err = xml.NewTokenDecoder(newLocalNameReader(rawbody, response, s.lenientWarn)).Decode(respEnvelope)
+	} else {
+		err = xml.Unmarshal(rawbody, respEnvelope)
+	}
 	if err != nil {
 		return err
 	}
@@ -278,6 +946,896 @@ func (s *SOAPClient) Call(soapAction string, request, response interface{}) erro
 		return fault
 	}
 
+	return decodeResponseHeaders(rawbody, headers)
+}
+
+// localNameReader reads the tokens of a response envelope for a client
+// created WithLocalNameMatching or WithLenientResponses. It gives the
+// elements the namespaces the envelope and response types expect for their
+// local names, and reports the elements and attributes of the body these
+// types do not have.
+type localNameReader struct {
+	d      *xml.Decoder
+	warn   func(message string)
+	spaces map[string]string
+	names  map[string]bool
+	any    bool
+	depth  int
+	inBody bool
+	fault  bool
+}
+
+var xmlUnmarshalerType = reflect.TypeOf((*xml.Unmarshaler)(nil)).Elem()
+
+func newLocalNameReader(rawbody []byte, response {{emptyInterface}}, warn func(message string)) *localNameReader {
+	r := &localNameReader{
+		d:      xml.NewDecoder(bytes.NewReader(rawbody)),
+		warn:   warn,
+		spaces: make(map[string]string),
+		names:  make(map[string]bool),
+	}
+	seen := make(map[reflect.Type]bool)
+	r.collect(reflect.TypeOf(SOAPEnvelope{}), seen)
+	r.collect(reflect.TypeOf(response), seen)
+	return r
+}
+
+// collect records the element and attribute names of the fields of t and of
+// the types they hold, and the namespaces their XMLName fields expect.
+// Types decoding themselves, other than structs, accept any element.
+func (r *localNameReader) collect(t reflect.Type, seen map[reflect.Type]bool) {
+	if t == nil {
+		return
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(xmlUnmarshalerType) {
+			r.any = true
+			return
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("xml")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i:]
+		}
+		space := ""
+		if i := strings.Index(name, " "); i >= 0 {
+			space, name = name[:i], name[i+1:]
+		}
+		switch {
+		case strings.Contains(opts, ",any") || strings.Contains(opts, ",innerxml"):
+			r.any = true
+		case strings.Contains(opts, ",chardata") || strings.Contains(opts, ",comment"):
+		case f.Anonymous && tag == "":
+		case f.Name == "XMLName" && name == "":
+			r.any = true
+		default:
+			if name == "" {
+				name = f.Name
+			}
+			for _, name := range strings.Split(name, ">") {
+				r.names[name] = true
+			}
+			if space != "" {
+				r.spaces[name] = space
+			}
+		}
+		if f.Name != "XMLName" {
+			r.collect(f.Type, seen)
+		}
+	}
+}
+
+// Token returns the next token of the envelope, its elements renamed to the
+// namespaces expected of them.
+func (r *localNameReader) Token() (xml.Token, error) {
+	tok, err := r.d.Token()
+	switch t := tok.(type) {
+	case xml.StartElement:
+		r.depth++
+		switch {
+		case r.depth == 2:
+			r.inBody = t.Name.Local == "Body"
+		case r.depth == 3:
+			r.fault = t.Name.Local == "Fault"
+		}
+		content := r.depth >= 3 && r.inBody && !r.fault
+		t.Name = r.rename(t.Name, true, content)
+		if content {
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" || attr.Name.Space == "http://www.w3.org/2001/XMLSchema-instance" {
+					continue
+				}
+				if !r.names[attr.Name.Local] {
+					r.warnf("ignoring attribute %s of element %s", attr.Name.Local, t.Name.Local)
+				}
+			}
+		}
+		tok = t
+	case xml.EndElement:
+		t.Name = r.rename(t.Name, false, false)
+		tok = t
+		r.depth--
+	}
+	return tok, err
+}
+
+// rename returns name in the namespace the types expect of it, if any,
+// reporting the changed names of start elements and the unknown ones of the
+// content of the body.
+func (r *localNameReader) rename(name xml.Name, start, content bool) xml.Name {
+	space, ok := r.spaces[name.Local]
+	switch {
+	case ok && space != name.Space:
+		if start {
+			r.warnf("decoding element %s of namespace %q as one of namespace %q", name.Local, name.Space, space)
+		}
+		name.Space = space
+	case content && !r.any && !r.names[name.Local]:
+		r.warnf("ignoring element %s", name.Local)
+	}
+	return name
+}
+
+func (r *localNameReader) warnf(format string, args ...{{emptyInterface}}) {
+	if r.warn != nil {
+		r.warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// statusError returns the fault held by rawbody, the body of res, which has a
+// non-2xx status, or an *HTTPError when the body is not a fault envelope.
+// Servers usually answer faults with a 500 status.
+func (s *SOAPClient) statusError(res *http.Response, rawbody []byte) error {
+	if len(bytes.TrimSpace(rawbody)) > 0 {
+		if err, ok := s.headerFault(rawbody).(*HeaderFault); ok {
+			return err
+		}
+		envelope := &SOAPEnvelope{Body: SOAPBody{Content: &struct{}{}}}
+		if err := xml.Unmarshal(rawbody, envelope); err == nil && envelope.Body.Fault != nil {
+			return envelope.Body.Fault
+		}
+	}
+	return &HTTPError{StatusCode: res.StatusCode, Status: res.Status, Body: rawbody}
+}
+
+// decodeResponseHeaders decodes the elements of headers found in the header
+// of the response envelope rawbody into their values. Values of elements
+// the header does not hold are left as they are.
+func decodeResponseHeaders(rawbody []byte, headers []responseHeader) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	d := xml.NewDecoder(bytes.NewReader(rawbody))
+	inHeader := false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !inHeader {
+				if t.Name.Local == "Body" {
+					return nil
+				}
+				inHeader = t.Name.Local == "Header"
+				continue
+			}
+			decoded := false
+			for _, header := range headers {
+				if header.element != t.Name.Local {
+					continue
+				}
+				start := t
+				start.Name.Local = header.typeName
+				if err := d.DecodeElement(header.value, &start); err != nil {
+					return err
+				}
+				decoded = true
+				break
+			}
+			if !decoded {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if inHeader {
+				return nil
+			}
+		}
+	}
+}
+
+// streamXML returns a reader of the XML encoding of v, indented with indent,
+// which is encoded as it is read. Closing the reader stops the encoding.
+func streamXML(v {{emptyInterface}}, indent string) io.ReadCloser {
+	r, w := io.Pipe()
+	go func() {
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", indent)
+		err := encoder.Encode(v)
+		if err == nil {
+			err = encoder.Flush()
+		}
+		w.CloseWithError(err)
+	}()
+	return r
+}
+
+// xmlNamespace is the namespace bound to the reserved xml prefix.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// namespacePrefixes are the prefixes given to well-known namespaces by
+// prefixNamespaces.
+var namespacePrefixes = map[string]string{
+	"http://schemas.xmlsoap.org/soap/envelope/": "soap",
+	"http://www.w3.org/2003/05/soap-envelope":   "soap",
+	"http://www.w3.org/2001/XMLSchema-instance": "xsi",
+}
+
+// prefixNamespaces rewrites doc, an XML document encoded by encoding/xml, to
+// declare the namespaces of its elements and attributes once on its root
+// element, in the order they are used, rather than on every element. The
+// namespaces keep the prefixes they are declared with in doc, the ones of
+// namespacePrefixes, or get ns1, ns2 and so on. The XML namespace keeps its
+// reserved xml prefix, which is never declared.
+func prefixNamespaces(doc []byte) ([]byte, error) {
+	var (
+		tokens   []xml.Token
+		spaces   []string
+		declared = make(map[string]string)
+		used     = make(map[string]bool)
+	)
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		tok = xml.CopyToken(tok)
+		if start, ok := tok.(xml.StartElement); ok {
+			names := []xml.Name{start.Name}
+			for _, attr := range start.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					if _, ok := declared[attr.Value]; !ok {
+						declared[attr.Value] = attr.Name.Local
+					}
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+				default:
+					names = append(names, attr.Name)
+				}
+			}
+			for _, name := range names {
+				if name.Space != "" && name.Space != xmlNamespace && !used[name.Space] {
+					used[name.Space] = true
+					spaces = append(spaces, name.Space)
+				}
+			}
+		}
+		tokens = append(tokens, tok)
+	}
+
+	prefixes := map[string]string{xmlNamespace: "xml"}
+	taken := map[string]bool{"xml": true, "xmlns": true}
+	var root []xml.Attr
+	n := 0
+	for _, space := range spaces {
+		prefix := declared[space]
+		if prefix == "" || taken[prefix] || strings.HasPrefix(prefix, "_") {
+			prefix = namespacePrefixes[space]
+		}
+		for prefix == "" || taken[prefix] {
+			n++
+			prefix = fmt.Sprintf("ns%d", n)
+		}
+		taken[prefix] = true
+		prefixes[space] = prefix
+		root = append(root, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: space})
+	}
+	prefixed := func(name xml.Name) xml.Name {
+		if name.Space == "" {
+			return name
+		}
+		return xml.Name{Local: prefixes[name.Space] + ":" + name.Local}
+	}
+
+	buffer := new(bytes.Buffer)
+	e := xml.NewEncoder(buffer)
+	depth := 0
+	for _, tok := range tokens {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var attrs []xml.Attr
+			if depth == 0 {
+				attrs = root
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+					continue
+				}
+				attrs = append(attrs, xml.Attr{Name: prefixed(attr.Name), Value: attr.Value})
+			}
+			tok = xml.StartElement{Name: prefixed(t.Name), Attr: attrs}
+			depth++
+		case xml.EndElement:
+			tok = xml.EndElement{Name: prefixed(t.Name)}
+			depth--
+		}
+		if err := e.EncodeToken(tok); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.Flush(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// canonicalXML returns the exclusive canonical form of doc, without
+// comments: without XML declaration, with the namespaces declared on the
+// elements using them when not declared the same by an ancestor, sorted
+// attributes, start and end tags for empty elements and the canonical
+// escaping of text and attribute values.
+func canonicalXML(doc []byte) ([]byte, error) {
+	type scope struct {
+		inScope  map[string]string // prefix to namespace, as declared
+		rendered map[string]string // prefix to namespace, as output
+	}
+	buffer := new(bytes.Buffer)
+	scopes := []scope{ {
+		inScope:  map[string]string{"": "", "xml": xmlNamespace},
+		rendered: map[string]string{"": ""},
+	} }
+	afterRoot := false
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := scopes[len(scopes)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			current := scope{inScope: make(map[string]string), rendered: make(map[string]string)}
+			for prefix, space := range parent.inScope {
+				current.inScope[prefix] = space
+			}
+			for prefix, space := range parent.rendered {
+				current.rendered[prefix] = space
+			}
+			var attrs []xml.Attr
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					current.inScope[attr.Name.Local] = attr.Value
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					current.inScope[""] = attr.Value
+				default:
+					attrs = append(attrs, attr)
+				}
+			}
+
+			// Only the namespaces of the element and of its prefixed
+			// attributes are visibly utilized.
+			utilized := map[string]bool{t.Name.Space: true}
+			for _, attr := range attrs {
+				if attr.Name.Space != "" {
+					utilized[attr.Name.Space] = true
+				}
+			}
+			var prefixes []string
+			for prefix := range utilized {
+				if prefix == "xml" {
+					continue
+				}
+				if space, ok := current.inScope[prefix]; !ok {
+					return nil, fmt.Errorf("canonical xml: undeclared namespace prefix %s", prefix)
+				} else if rendered, ok := current.rendered[prefix]; !ok || rendered != space {
+					prefixes = append(prefixes, prefix)
+					current.rendered[prefix] = space
+				}
+			}
+			sort.Strings(prefixes)
+			sort.Slice(attrs, func(i, j int) bool {
+				a, b := current.inScope[attrs[i].Name.Space], current.inScope[attrs[j].Name.Space]
+				if attrs[i].Name.Space == "" {
+					a = ""
+				}
+				if attrs[j].Name.Space == "" {
+					b = ""
+				}
+				if a != b {
+					return a < b
+				}
+				return attrs[i].Name.Local < attrs[j].Name.Local
+			})
+
+			buffer.WriteString("<" + rawName(t.Name))
+			for _, prefix := range prefixes {
+				name := "xmlns"
+				if prefix != "" {
+					name += ":" + prefix
+				}
+				buffer.WriteString(" " + name + "=\"" + canonicalText(current.rendered[prefix], true) + "\"")
+			}
+			for _, attr := range attrs {
+				buffer.WriteString(" " + rawName(attr.Name) + "=\"" + canonicalText(attr.Value, true) + "\"")
+			}
+			buffer.WriteString(">")
+			scopes = append(scopes, current)
+		case xml.EndElement:
+			buffer.WriteString("</" + rawName(t.Name) + ">")
+			scopes = scopes[:len(scopes)-1]
+			afterRoot = len(scopes) == 1
+		case xml.CharData:
+			if len(scopes) > 1 {
+				buffer.WriteString(canonicalText(string(t), false))
+			}
+		case xml.ProcInst:
+			if t.Target == "xml" {
+				continue
+			}
+			if afterRoot {
+				buffer.WriteString("\n")
+			}
+			buffer.WriteString("<?" + t.Target)
+			if len(t.Inst) > 0 {
+				buffer.WriteString(" " + string(t.Inst))
+			}
+			buffer.WriteString("?>")
+			if len(scopes) == 1 && !afterRoot {
+				buffer.WriteString("\n")
+			}
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// rawName returns name, as returned by RawToken, as it is written.
+func rawName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// canonicalText escapes s as the text of an element or, when attr is set,
+// as an attribute value of a canonical document.
+func canonicalText(s string, attr bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>' && !attr:
+			b.WriteString("&gt;")
+		case r == '"' && attr:
+			b.WriteString("&quot;")
+		case r == '\t' && attr:
+			b.WriteString("&#x9;")
+		case r == '\n' && attr:
+			b.WriteString("&#xA;")
+		case r == '\r':
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// resolveMultiRefs inlines the values RPC/encoded servers, such as Axis,
+// serialize once as children of the Body carrying an id, and refer to with
+// href="#id" attributes. Elements holding an href get the attributes and
+// content of the value they refer to, and the values are dropped from the
+// Body. Envelopes without references are returned unchanged.
+func resolveMultiRefs(rawbody []byte) ([]byte, error) {
+	if !bytes.Contains(rawbody, []byte("href=")) {
+		return rawbody, nil
+	}
+
+	var tokens []xml.Token
+	d := xml.NewDecoder(bytes.NewReader(rawbody))
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, xml.CopyToken(tok))
+	}
+
+	// ends maps the start tag of every element to its end tag, values maps
+	// ids to start tags, and roots holds the start tags of Body children.
+	ends := make(map[int]int)
+	values := make(map[string]int)
+	roots := make(map[int]bool)
+	referenced := make(map[string]bool)
+	var stack []int
+	bodyDepth := -1
+	for i, tok := range tokens {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == bodyDepth+1 && bodyDepth >= 0 {
+				roots[i] = true
+			}
+			stack = append(stack, i)
+			if t.Name.Local == "Body" && bodyDepth < 0 {
+				bodyDepth = len(stack) - 1
+			}
+			if id := rawAttr(t, "id"); id != "" {
+				values[id] = i
+			}
+			if href := rawAttr(t, "href"); strings.HasPrefix(href, "#") {
+				referenced[href[1:]] = true
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, errors.New("unbalanced end element " + t.Name.Local)
+			}
+			ends[stack[len(stack)-1]] = i
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	var buf bytes.Buffer
+	var write func(from, to int, resolving map[string]bool)
+	write = func(from, to int, resolving map[string]bool) {
+		for i := from; i <= to; i++ {
+			start, ok := tokens[i].(xml.StartElement)
+			if !ok {
+				writeRawToken(&buf, tokens[i])
+				continue
+			}
+			if roots[i] && referenced[rawAttr(start, "id")] {
+				i = ends[i]
+				continue
+			}
+			href := rawAttr(start, "href")
+			id := strings.TrimPrefix(href, "#")
+			value, ok := values[id]
+			if !ok || id == href || resolving[id] {
+				writeRawToken(&buf, start)
+				continue
+			}
+
+			attrs := make([]xml.Attr, 0, len(start.Attr))
+			for _, attr := range start.Attr {
+				if attr.Name.Local != "href" {
+					attrs = append(attrs, attr)
+				}
+			}
+			for _, attr := range tokens[value].(xml.StartElement).Attr {
+				if attr.Name.Local != "id" && rawAttr(start, attr.Name.Local) == "" {
+					attrs = append(attrs, attr)
+				}
+			}
+			writeRawToken(&buf, xml.StartElement{Name: start.Name, Attr: attrs})
+
+			nested := map[string]bool{id: true}
+			for ref := range resolving {
+				nested[ref] = true
+			}
+			write(value+1, ends[value]-1, nested)
+			i = ends[i]
+			writeRawToken(&buf, tokens[i])
+		}
+	}
+	write(0, len(tokens)-1, nil)
+
+	return buf.Bytes(), nil
+}
+
+// rawAttr returns the value of the attribute of start with the given local
+// name, or "".
+func rawAttr(start xml.StartElement, local string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// writeRawToken writes tok, as read by RawToken, keeping its prefixes.
+func writeRawToken(buf *bytes.Buffer, tok xml.Token) {
+	qname := func(name xml.Name) string {
+		if name.Space == "" {
+			return name.Local
+		}
+		return name.Space + ":" + name.Local
+	}
+
+	switch t := tok.(type) {
+	case xml.StartElement:
+		buf.WriteString("<" + qname(t.Name))
+		for _, attr := range t.Attr {
+			buf.WriteString(" " + qname(attr.Name) + "=\"")
+			xml.EscapeText(buf, []byte(attr.Value))
+			buf.WriteString("\"")
+		}
+		buf.WriteString(">")
+	case xml.EndElement:
+		buf.WriteString("</" + qname(t.Name) + ">")
+	case xml.CharData:
+		xml.EscapeText(buf, t)
+	case xml.Comment:
+		buf.WriteString("<!--" + string(t) + "-->")
+	case xml.ProcInst:
+		buf.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+	case xml.Directive:
+		buf.WriteString("<!" + string(t) + ">")
+	}
+}
+{{if usesHexBinary}}
+// HexBinary is the content of a hexBinary element or attribute, which is
+// sent in upper case hexadecimal.
+type HexBinary []byte
+
+func (h HexBinary) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(hex.EncodeToString(h))), nil
+}
+
+func (h *HexBinary) UnmarshalText(text []byte) error {
+	text = bytes.TrimSpace(text)
+	b := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(b, text); err != nil {
+		return err
+	}
+	*h = b
+	return nil
+}
+
+func (h HexBinary) String() string {
+	return strings.ToUpper(hex.EncodeToString(h))
+}
+{{end}}
+{{if usesWhiteSpace}}
+// replaceWhiteSpace replaces the tabs, line feeds and carriage returns of s
+// with spaces, as the replace whiteSpace facet does.
+func replaceWhiteSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// collapseWhiteSpace also trims the spaces of s and collapses their runs
+// into one, as the collapse whiteSpace facet does.
+func collapseWhiteSpace(s string) string {
+	return strings.Join(strings.FieldsFunc(replaceWhiteSpace(s), func(r rune) bool {
+		return r == ' '
+	}), " ")
+}
+{{end}}
+{{if usesIDs}}
+// ID is the value of an xs:ID field, which identifies the struct carrying it
+// within a document.
+type ID string
+
+// IDRef is the value of an xs:IDREF field. ResolveIDRefs points it to the
+// struct carrying the ID it holds.
+type IDRef struct {
+	ID     ID
+	target {{emptyInterface}}
+}
+
+// Target returns a pointer to the struct carrying the ID of r, or nil until
+// r is resolved by ResolveIDRefs.
+func (r IDRef) Target() {{emptyInterface}} {
+	return r.target
+}
+
+func (r IDRef) MarshalText() ([]byte, error) {
+	return []byte(r.ID), nil
+}
+
+func (r *IDRef) UnmarshalText(text []byte) error {
+	*r = IDRef{ID: ID(strings.TrimSpace(string(text)))}
+	return nil
+}
+
+// MarshalXMLAttr leaves out the attributes of empty references.
+func (r IDRef) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if r.ID == "" {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: string(r.ID)}, nil
+}
+
+// MarshalXML leaves out the elements of empty references.
+func (r IDRef) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if r.ID == "" {
+		return nil
+	}
+	return e.EncodeElement(string(r.ID), start)
+}
+
+// IDRefs is the value of an xs:IDREFS field, a space separated list of
+// references.
+type IDRefs []IDRef
+
+func (r IDRefs) MarshalText() ([]byte, error) {
+	ids := make([]string, len(r))
+	for i, ref := range r {
+		ids[i] = string(ref.ID)
+	}
+	return []byte(strings.Join(ids, " ")), nil
+}
+
+func (r *IDRefs) UnmarshalText(text []byte) error {
+	ids := strings.Fields(string(text))
+	*r = make(IDRefs, len(ids))
+	for i, id := range ids {
+		(*r)[i] = IDRef{ID: ID(id)}
+	}
 	return nil
 }
+
+var (
+	idType    = reflect.TypeOf(ID(""))
+	idRefType = reflect.TypeOf(IDRef{})
+)
+
+// ResolveIDRefs points the IDRef values reachable from v, a pointer such as
+// an unmarshaled response, to the structs carrying the ID they hold. It
+// fails on the first reference to an ID missing from v, after resolving the
+// others.
+func ResolveIDRefs(v {{emptyInterface}}) error {
+	ids := make(map[ID]{{emptyInterface}})
+	var refs []*IDRef
+	visited := make(map[{{emptyInterface}}]bool)
+
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if v.IsNil() || visited[v.Interface()] {
+				return
+			}
+			visited[v.Interface()] = true
+			walk(v.Elem())
+		case reflect.Interface:
+			if !v.IsNil() {
+				walk(v.Elem())
+			}
+		case reflect.Slice, reflect.Array:
+			if v.Type().Elem().Kind() == reflect.Uint8 {
+				return
+			}
+			for i := 0; i < v.Len(); i++ {
+				walk(v.Index(i))
+			}
+		case reflect.Struct:
+			if v.Type() == idRefType {
+				if v.CanAddr() {
+					refs = append(refs, v.Addr().Interface().(*IDRef))
+				}
+				return
+			}
+			for i := 0; i < v.NumField(); i++ {
+				f := v.Type().Field(i)
+				if f.PkgPath != "" {
+					continue
+				}
+				if f.Type == idType {
+					if id := v.Field(i).Interface().(ID); id != "" && v.CanAddr() {
+						ids[id] = v.Addr().Interface()
+					}
+					continue
+				}
+				walk(v.Field(i))
+			}
+		}
+	}
+	walk(reflect.ValueOf(v))
+
+	var err error
+	for _, ref := range refs {
+		target, ok := ids[ref.ID]
+		ref.target = target
+		if !ok && ref.ID != "" && err == nil {
+			err = fmt.Errorf("no element carries the ID %q", ref.ID)
+		}
+	}
+	return err
+}
+{{end}}
+{{if usesLists}}
+// formatListItem returns the lexical representation of an xs:list item.
+func formatListItem(v {{emptyInterface}}) (string, error) {
+	if m, ok := v.(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	return fmt.Sprint(v), nil
+}
+
+// parseListItem parses the lexical representation s of an xs:list item
+// into the value v points to.
+func parseListItem(s string, v {{emptyInterface}}) error {
+	if u, ok := v.(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	rv := reflect.ValueOf(v).Elem()
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported list item type %s", rv.Type())
+	}
+	return nil
+}
+{{end}}
+{{if usesRawXML}}
+// RawXML is the content of an xs:anyType element, kept as it was received
+// with the attributes of the element.
+type RawXML struct {
+	Attrs   []xml.Attr ` + "`" + `xml:",any,attr"` + "`" + `
+	Content []byte     ` + "`" + `xml:",innerxml"` + "`" + `
+}
+
+// Decode unmarshals the content of r into v, without the attributes of r.
+// Namespace prefixes declared by the ancestors of the element of r are not
+// known to it.
+func (r *RawXML) Decode(v {{emptyInterface}}) error {
+	var buf bytes.Buffer
+	buf.WriteString("<RawXML>")
+	buf.Write(r.Content)
+	buf.WriteString("</RawXML>")
+	return xml.Unmarshal(buf.Bytes(), v)
+}
+{{end}}
 `
@@ -1,9 +1,15 @@
 package gowsdl
 
 import (
+	"bytes"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"log"
+	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"unicode"
 )
@@ -12,6 +18,28 @@ type tmplFunctions struct {
 	funcMap template.FuncMap
 }
 
+// RegisterTemplateFunc makes fn available as name to the templates of the
+// generated code and to the operation naming, which must then be set after
+// it. fn must return one value, or a value and an error, as text/template
+// requires. Registering the name of a built-in function, such as
+// replaceReservedWords or makeFieldPublic, replaces it in the generated code.
+// Code is not rendered from the render cache while functions are registered,
+// since their behavior cannot be told apart.
+func (g *GoWSDL) RegisterTemplateFunc(name string, fn interface{}) (err error) {
+	// Funcs panics on names and functions templates cannot call.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid template function %s: %v", name, r)
+		}
+	}()
+	template.New("").Funcs(template.FuncMap{name: fn})
+	if g.customFuncs == nil {
+		g.customFuncs = make(template.FuncMap)
+	}
+	g.customFuncs[name] = fn
+	return nil
+}
+
 var reservedWords = map[string]string{
 	"break":       "break_",
 	"default":     "default_",
@@ -40,28 +68,91 @@ var reservedWords = map[string]string{
 	"var":         "var_",
 }
 
+// predeclared are the identifiers of the Go universe block, which the
+// generated declarations must not shadow.
+var predeclared = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true,
+	"complex64": true, "complex128": true, "error": true, "float32": true,
+	"float64": true, "int": true, "int8": true, "int16": true, "int32": true,
+	"int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"true": true, "false": true, "iota": true, "nil": true,
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true,
+	"copy": true, "delete": true, "imag": true, "len": true, "make": true,
+	"max": true, "min": true, "new": true, "panic": true, "print": true,
+	"println": true, "real": true, "recover": true,
+}
+
+// importedPackages are the names of the packages the generated files import,
+// which the generated declarations must not take either.
+var importedPackages = map[string]bool{
+	"base64": true, "bytes": true, "context": true, "encoding": true,
+	"errors": true, "filepath": true, "fmt": true, "hex": true, "http": true,
+	"httptest": true, "io": true, "ioutil": true, "json": true, "log": true,
+	"net": true, "os": true, "rand": true, "reflect": true, "sha256": true,
+	"sort": true, "strconv": true, "strings": true, "sync": true, "time": true,
+	"tls": true, "url": true, "xml": true,
+}
+
+// builtinTypeNames are the types declared for built-in XSD types, which the
+// types of the schemas must not take.
+var builtinTypeNames = map[string]bool{
+	"HexBinary": true, "ID": true, "IDRef": true, "IDRefs": true, "RawXML": true,
+}
+
+// receiverNames are the receivers of the generated methods, which the names
+// of unexported types must not take.
+var receiverNames = map[string]bool{
+	"a": true, "b": true, "s": true, "t": true, "v": true, "service": true,
+}
+
 var xsd2GoTypes = map[string]string{
-	"string":        "string",
-	"token":         "string",
-	"float":         "float32",
-	"double":        "float64",
-	"decimal":       "float64",
-	"integer":       "int32",
-	"int":           "int32",
-	"short":         "int16",
-	"byte":          "int8",
-	"long":          "int64",
-	"boolean":       "bool",
-	"datetime":      "time.Time",
-	"date":          "time.Time",
-	"time":          "time.Time",
-	"base64binary":  "[]byte",
-	"hexbinary":     "[]byte",
-	"unsignedint":   "uint32",
-	"unsignedshort": "uint16",
-	"unsignedbyte":  "byte",
-	"unsignedlong":  "uint64",
-	"anytype":       "interface{}",
+	"string":             "string",
+	"normalizedstring":   "string",
+	"token":              "string",
+	"language":           "string",
+	"name":               "string",
+	"ncname":             "string",
+	"nmtoken":            "string",
+	"nmtokens":           "string",
+	"entity":             "string",
+	"entities":           "string",
+	"notation":           "string",
+	"qname":              "string",
+	"anyuri":             "string",
+	"anysimpletype":      "string",
+	"duration":           "string",
+	"gyear":              "string",
+	"gyearmonth":         "string",
+	"gmonth":             "string",
+	"gmonthday":          "string",
+	"gday":               "string",
+	"float":              "float32",
+	"double":             "float64",
+	"decimal":            "float64",
+	"integer":            "int32",
+	"int":                "int32",
+	"short":              "int16",
+	"byte":               "int8",
+	"long":               "int64",
+	"nonpositiveinteger": "int64",
+	"negativeinteger":    "int64",
+	"boolean":            "bool",
+	"datetime":           "time.Time",
+	"date":               "time.Time",
+	"time":               "time.Time",
+	"base64binary":       "[]byte",
+	"hexbinary":          "HexBinary",
+	"id":                 "ID",
+	"idref":              "IDRef",
+	"idrefs":             "IDRefs",
+	"unsignedint":        "uint32",
+	"unsignedshort":      "uint16",
+	"unsignedbyte":       "byte",
+	"unsignedlong":       "uint64",
+	"nonnegativeinteger": "uint64",
+	"positiveinteger":    "uint64",
+	"anytype":            "interface{}",
 }
 
 func createTmplFunctions(g *GoWSDL) *tmplFunctions {
@@ -93,6 +184,51 @@ func createTmplFunctions(g *GoWSDL) *tmplFunctions {
 		return r[0]
 	}
 
+	// Types declared by the schemas shadow the built-in types of the same
+	// name, such as a Name complex type.
+	declared := make(map[string]bool)
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, st := range schema.SimpleType {
+			declared[st.Name] = true
+		}
+		for _, ct := range schema.ComplexTypes {
+			declared[ct.Name] = true
+		}
+	}
+
+	// builtinType returns the Go type of the built-in XSD type local, or an
+	// empty string, and records the use of the RawXML, HexBinary and ID types
+	// and of the imports of type mappings.
+	builtinType := func(local string) string {
+		if declared[local] {
+			return ""
+		}
+		if m, ok := g.typeMappings[strings.ToLower(local)]; ok {
+			g.useTypeImport(m.Import)
+			return m.GoType
+		}
+		t := xsd2GoTypes[strings.ToLower(local)]
+		if t == "interface{}" {
+			switch g.anyType {
+			case AnyTypeString:
+				t = "string"
+			case AnyTypeRaw:
+				t = "*RawXML"
+			default:
+				t = g.emptyInterface()
+			}
+		}
+		switch t {
+		case "*RawXML":
+			g.usesRawXML.Store(true)
+		case "HexBinary":
+			g.usesHexBinary.Store(true)
+		case "ID", "IDRef", "IDRefs":
+			g.usesIDs.Store(true)
+		}
+		return t
+	}
+
 	toGoTypeNs := func(xsdType string, ns string) string {
 		log.Printf("xsdType: %s, ns: %s", xsdType, ns)
 		// Handles name space, ie. xsd:string, xs:string
@@ -102,7 +238,13 @@ func createTmplFunctions(g *GoWSDL) *tmplFunctions {
 			t = r[1]
 		}
 
-		value := xsd2GoTypes[strings.ToLower(t)]
+		if t == "" {
+			// Untyped attributes and list/union simple types
+			// default to their lexical representation.
+			return "string"
+		}
+
+		value := builtinType(t)
 		if value != "" {
 			return value
 		}
@@ -110,13 +252,34 @@ func createTmplFunctions(g *GoWSDL) *tmplFunctions {
 		if !g.ignoreTypeNs && ns != "" {
 			t = ns + t
 		}
-		return "*" + replaceReservedWords(makePublic(t))
+		return "*" + g.typeName(replaceReservedWords(t))
 	}
 
 	toGoType := func(xsdType string) string {
 		return toGoTypeNs(xsdType, "")
 	}
 
+	// fieldType returns the Go type of an element field, taking its
+	// cardinality and nillability into account. Optional nillable elements
+	// take a *Nullable, which is omitted when nil.
+	fieldType := func(xsdType, minOccurs, maxOccurs string, nillable bool) string {
+		t := toGoType(xsdType)
+		if g.streamBase64 && strings.EqualFold(removeNS(xsdType), "base64Binary") {
+			t = "*Base64Stream"
+		}
+		if maxOccurs == "unbounded" {
+			return "[]" + t
+		}
+		if nillable && g.generateNullable && !strings.HasPrefix(t, "*") &&
+			!strings.HasPrefix(t, "[]") && t != g.emptyInterface() {
+			if minOccurs == "0" {
+				return "*Nullable[" + t + "]"
+			}
+			return "Nullable[" + t + "]"
+		}
+		return t
+	}
+
 	// TODO(c4milo): Add namespace support instead of stripping it
 	stripns := func(xsdType string) string {
 		r := strings.Split(xsdType, ":")
@@ -127,11 +290,173 @@ func createTmplFunctions(g *GoWSDL) *tmplFunctions {
 		return t
 	}
 
-	makePublic := func(identifier string) string {
-		if !g.exportAllTypes {
-			return identifier
+	// omitEmpty returns the ",omitempty" tag option for an element when the
+	// configured policy asks for it.
+	omitEmpty := func(minOccurs string) string {
+		switch g.omitEmpty {
+		case OmitEmptyNever:
+			return ""
+		case OmitEmptyOptional:
+			if minOccurs != "0" {
+				return ""
+			}
+		}
+		return ",omitempty"
+	}
+
+	// fieldOmitEmpty returns the omitEmpty option of an element field of
+	// goType. Nullable fields go without, since their zero value is sent as
+	// nil and omitempty does not apply to structs.
+	fieldOmitEmpty := func(goType, minOccurs string) string {
+		if strings.HasPrefix(strings.TrimPrefix(goType, "*"), "Nullable[") {
+			return ""
+		}
+		return omitEmpty(minOccurs)
+	}
+
+	attrOmitEmpty := func(use string) string {
+		if use == "required" {
+			return omitEmpty("1")
+		}
+		return omitEmpty("0")
+	}
+
+	// elementTypeName returns the Go name of the type declared by a global
+	// element.
+	elementTypeName := func(el *XSDElement) string {
+		if name, ok := g.elementTypeNames[el]; ok {
+			return name
+		}
+		return el.Name
+	}
+
+	// refType returns the Go type of an element reference.
+	// Global elements declared with a named type are not generated, the
+	// references to them take that type unless another element of the
+	// name declares its own.
+	elementTypes := make(map[string]string)
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, el := range schema.Elements {
+			if t, ok := elementTypes[el.Name]; el.Type == "" || (ok && t != el.Type) {
+				elementTypes[el.Name] = ""
+			} else if !ok {
+				elementTypes[el.Name] = el.Type
+			}
+		}
+	}
+
+	refType := func(el *XSDElement) string {
+		if name, ok := g.elementRefTypes[el]; ok {
+			return toGoType(name)
+		}
+		if t := elementTypes[removeNS(el.Ref)]; t != "" {
+			return toGoType(t)
+		}
+		return toGoType(el.Ref)
+	}
+
+	// arrayItem returns the repeated element of the ArrayOf wrapper type of
+	// the element el when arrays are flattened, or nil. Wrapper types whose
+	// name is declared by several schemas are not flattened.
+	complexTypes := make(map[string]*XSDComplexType)
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			if _, ok := complexTypes[ct.Name]; ok {
+				complexTypes[ct.Name] = nil
+			} else {
+				complexTypes[ct.Name] = ct
+			}
+		}
+	}
+
+	arrayItem := func(el *XSDElement) *XSDElement {
+		if !g.flattenArrays || el.Type == "" || el.Nillable || occurs(el.MaxOccurs, 1) != 1 {
+			return nil
+		}
+		ct := complexTypes[removeNS(el.Type)]
+		if ct == nil || !isArrayWrapper(ct) {
+			return nil
+		}
+		return ct.Sequence[0]
+	}
+
+	// arrayItemType returns the Go type of the field of a flattened element
+	// whose wrapper type repeats item.
+	arrayItemType := func(item *XSDElement) string {
+		if item.Ref != "" {
+			return "[]" + refType(item)
+		}
+		return fieldType(item.Type, item.MinOccurs, "unbounded", item.Nillable)
+	}
+
+	// xmlTypeName returns the XML name of a complex type whose Go name got
+	// a namespace prefix.
+	xmlTypeName := func(ct *XSDComplexType) string {
+		if name, ok := g.typeXMLNames[ct]; ok {
+			return name
+		}
+		return ct.Name
+	}
+
+	// makeTypePublic, makeFieldPublic and enumConstant export the names of
+	// types, struct fields and enumeration constants as configured.
+	makeTypePublic := g.typeName
+	makeFieldPublic := g.fieldName
+
+	enumConstant := func(typeName, value string) string {
+		if g.exportEnums {
+			return makePublic(replaceReservedWords(typeName)) + makePublic(replaceReservedWords(value))
+		}
+		return unshadowIdentifier(replaceReservedWords(typeName) + replaceReservedWords(value))
+	}
+
+	// operationName returns the name of the client method of operation.
+	operationName := func(op *WSDLOperation) string {
+		name, ok := g.operationNames[op]
+		if !ok {
+			name = op.Name
+		}
+		return replaceReservedWords(makeTypePublic(name))
+	}
+
+	// hasField reports whether the struct generated for a complex type
+	// declares a field with the given name.
+	hasField := func(ct *XSDComplexType, name string) bool {
+		if ct == nil {
+			return false
+		}
+		elements := func(elms []*XSDElement) bool {
+			for _, el := range elms {
+				if el.Ref != "" && makeFieldPublic(replaceReservedWords(removeNS(el.Ref))) == name {
+					return true
+				}
+				if el.Ref == "" && makeFieldPublic(replaceReservedWords(el.Name)) == name {
+					return true
+				}
+			}
+			return false
+		}
+		attributes := func(attrs []*XSDAttribute) bool {
+			for _, attr := range attrs {
+				if makeFieldPublic(attr.Name) == name {
+					return true
+				}
+			}
+			return false
+		}
+		if ext := ct.ComplexContent.Extension; ext.Base != "" {
+			for _, el := range ext.Sequence {
+				if el.Ref == "" && makeFieldPublic(replaceReservedWords(el.Name)) == name {
+					return true
+				}
+			}
+			return attributes(ext.Attributes)
+		}
+		if ext := ct.SimpleContent.Extension; ext.Base != "" {
+			return name == "Value" || attributes(ext.Attributes)
 		}
-		return makePublic(identifier)
+		return elements(ct.Sequence) || elements(ct.Choice) ||
+			elements(ct.SequenceChoice) || elements(ct.All) || attributes(ct.Attributes)
 	}
 
 	comment := func(text string) string {
@@ -160,6 +485,197 @@ func createTmplFunctions(g *GoWSDL) *tmplFunctions {
 		return ""
 	}
 
+	// whiteSpace returns how the whiteSpace facet of a string simple type
+	// restricted by r normalizes its values, given by the facet or implied by
+	// a normalizedString or token base: "replace", "collapse", or an empty
+	// string when they are preserved. It records the use of the
+	// normalization helpers.
+	whiteSpace := func(r XSDRestriction) string {
+		if toGoType(r.Base) != "string" {
+			return ""
+		}
+		value := r.WhiteSpace.Value
+		if value == "" {
+			switch strings.ToLower(stripns(r.Base)) {
+			case "normalizedstring":
+				value = "replace"
+			case "token":
+				value = "collapse"
+			}
+		}
+		if value != "replace" && value != "collapse" {
+			return ""
+		}
+		g.usesWhiteSpace.Store(true)
+		return value
+	}
+
+	// simpleGoType returns the Go type of the built-in or simple type
+	// xsdType, or an empty string for complex types.
+	simpleGoType := func(xsdType string) string {
+		local := stripns(xsdType)
+		if t := builtinType(local); t != "" {
+			if strings.EqualFold(local, "anyType") {
+				return ""
+			}
+			return t
+		}
+		if len(g.index().simpleTypes[local]) > 0 {
+			return makeTypePublic(replaceReservedWords(local))
+		}
+		return ""
+	}
+
+	// listItem describes the items of an xs:list simple type, or returns nil
+	// when their type is unknown. It records the use of the list helpers.
+	listItem := func(list XSDList) *listItem {
+		var item *listItem
+		if st := list.SimpleType; st != nil {
+			if t := simpleGoType(st.Restriction.Base); t != "" {
+				item = &listItem{Type: t, Enumeration: st.Restriction.Enumeration}
+			}
+		} else if t := simpleGoType(list.ItemType); t != "" {
+			item = &listItem{Type: t}
+			if sts := g.index().simpleTypes[stripns(list.ItemType)]; len(sts) > 0 {
+				item.Enumeration = sts[len(sts)-1].Restriction.Enumeration
+			}
+		}
+		if item != nil {
+			g.usesLists.Store(true)
+		}
+		return item
+	}
+
+	// valueKind returns the kind of the values of the Go type t of a simple
+	// value: "string", "number", "bool", "list", or an empty string for the
+	// other types.
+	var valueKind func(t string, depth int) string
+	valueKind = func(t string, depth int) string {
+		switch {
+		case t == "string" || t == "ID":
+			return "string"
+		case t == "bool":
+			return "bool"
+		case t == "byte" || strings.HasPrefix(t, "int") || strings.HasPrefix(t, "uint") || strings.HasPrefix(t, "float"):
+			return "number"
+		case strings.HasPrefix(t, "[]") || t == "HexBinary" || t == "IDRefs":
+			return "list"
+		}
+		if depth > maxRecursion {
+			return ""
+		}
+		for _, schema := range g.wsdl.Types.Schemas {
+			for _, st := range schema.SimpleType {
+				if makeTypePublic(replaceReservedWords(st.Name)) != t {
+					continue
+				}
+				if listItem(st.List) != nil {
+					return "list"
+				}
+				return valueKind(strings.TrimPrefix(toGoType(st.Restriction.Base), "*"), depth+1)
+			}
+		}
+		return ""
+	}
+
+	// newAttrField describes the field of attr. Its value is held directly
+	// when the attribute is required, and through a pointer when it is
+	// optional and of a declared simple type or has a default value, so that
+	// its absence can be told apart.
+	newAttrField := func(attr *XSDAttribute) *attrField {
+		t := toGoType(attr.Type)
+		f := &attrField{Name: attr.Name, Field: makeFieldPublic(attr.Name), Value: strings.TrimPrefix(t, "*")}
+		kind := valueKind(f.Value, 0)
+		if attr.Use == "required" {
+			f.Type = f.Value
+			switch {
+			case kind == "string":
+				f.Missing = "t." + f.Field + ` == ""`
+			case kind == "list":
+				f.Missing = "len(t." + f.Field + ") == 0"
+			case f.Value == "IDRef":
+				f.Missing = "t." + f.Field + `.ID == ""`
+			}
+			return f
+		}
+
+		value := attr.Default
+		if value == "" {
+			value = attr.Fixed
+		}
+		switch kind {
+		case "string":
+			if value != "" {
+				f.Default = strconv.Quote(value)
+			}
+		case "number":
+			if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+				f.Default = strings.TrimPrefix(value, "+")
+			}
+		case "bool":
+			switch value {
+			case "true", "1":
+				f.Default = "true"
+			case "false", "0":
+				f.Default = "false"
+			}
+		}
+		f.Type = t
+		if f.Default != "" && !strings.HasPrefix(t, "*") {
+			f.Type = "*" + t
+		}
+		return f
+	}
+
+	// attrType returns the Go type of the field of attr.
+	attrType := func(attr *XSDAttribute) string {
+		return newAttrField(attr).Type
+	}
+
+	// attrFields describes the fields of the attributes of ct, as declared
+	// by its content.
+	attrFields := func(ct *XSDComplexType) []*attrField {
+		attrs := ct.Attributes
+		if ct.ComplexContent.Extension.Base != "" {
+			attrs = ct.ComplexContent.Extension.Attributes
+		} else if ct.SimpleContent.Extension.Base != "" {
+			attrs = ct.SimpleContent.Extension.Attributes
+		}
+		var fields []*attrField
+		for _, attr := range attrs {
+			if attr.Name != "" {
+				fields = append(fields, newAttrField(attr))
+			}
+		}
+		return fields
+	}
+
+	// simplePart describes the value carried by message when its part is
+	// of a simple type, either directly or through its element.
+	simplePart := func(message string) *messagePart {
+		message = stripns(message)
+		for _, msg := range g.index().messages[message] {
+			if len(msg.Parts) == 0 {
+				continue
+			}
+			part := msg.Parts[0]
+			if part.Type != "" {
+				if t := simpleGoType(part.Type); t != "" {
+					return &messagePart{Name: part.Name, Type: t}
+				}
+				return nil
+			}
+			el, schema := g.lookupElement(part.Element)
+			if el == nil || el.Type == "" {
+				return nil
+			}
+			if t := simpleGoType(el.Type); t != "" {
+				return &messagePart{Space: schema.TargetNamespace, Name: el.Name, Type: t}
+			}
+		}
+		return nil
+	}
+
 	// Given a message, finds its type.
 	//
 	// I'm not very proud of this function but
@@ -168,11 +684,7 @@ func createTmplFunctions(g *GoWSDL) *tmplFunctions {
 	findType := func(message string) string {
 		message = stripns(message)
 
-		for _, msg := range g.wsdl.Messages {
-			if msg.Name != message {
-				continue
-			}
-
+		for _, msg := range g.index().messages[message] {
 			// Assumes document/literal wrapped WS-I
 			if len(msg.Parts) == 0 {
 				// Message does not have parts. This could be a Port
@@ -182,53 +694,410 @@ func createTmplFunctions(g *GoWSDL) *tmplFunctions {
 				continue
 			}
 
+			// Simple values are not sent as a generated type.
+			if simplePart(message) != nil {
+				return ""
+			}
+
 			part := msg.Parts[0]
 			if part.Type != "" {
 				return stripns(part.Type)
 			}
 
-			elRef := stripns(part.Element)
+			if name, ok := g.elementType(part.Element); ok {
+				return name
+			}
+		}
+		return ""
+	}
+
+	// Returns the local name of the element carrying message on the wire.
+	findElement := func(message string) string {
+		message = stripns(message)
+		for _, msg := range g.index().messages[message] {
+			if len(msg.Parts) == 0 {
+				continue
+			}
+			if msg.Parts[0].Element != "" {
+				return stripns(msg.Parts[0].Element)
+			}
+			return msg.Parts[0].Name
+		}
+		return ""
+	}
+
+	// Returns a comment describing why a message is ignored, if it is.
+	messageComment := func(message string) string {
+		message = stripns(message)
+		for _, msg := range g.index().messages[message] {
+			if len(msg.Parts) == 0 {
+				return fmt.Sprintf("// gowsdl: unsupported message %s without parts was ignored\n", msg.Name)
+			}
+		}
+		return ""
+	}
 
-			for _, schema := range g.wsdl.Types.Schemas {
-				for _, el := range schema.Elements {
-					if strings.EqualFold(elRef, el.Name) {
-						if el.Type != "" {
-							return stripns(el.Type)
+	// httpOperation describes how operation is sent through the HTTP
+	// binding named binding.
+	httpOperation := func(operation *WSDLOperation, binding string) *httpOperation {
+		op := new(httpOperation)
+		for _, b := range g.wsdl.Binding {
+			if b.Name != binding {
+				continue
+			}
+			for _, bop := range b.Operations {
+				if bop.Name != operation.Name {
+					continue
+				}
+				op.Location = bop.HTTPOperation.Location
+				op.XMLOutput = bop.Output.MIMEXML != nil
+				switch {
+				case bop.Input.URLReplacement != nil:
+					op.Encoding = "HTTPURLReplacement"
+				case bop.Input.URLEncoded != nil:
+					op.Encoding = "HTTPURLEncoded"
+				case bop.Input.MIMEContent != nil && bop.Input.MIMEContent.Type == "application/x-www-form-urlencoded":
+					op.Encoding = "HTTPFormEncoded"
+				}
+			}
+		}
+
+		message := stripns(operation.Input.Message)
+		for _, msg := range g.index().messages[message] {
+			for _, part := range msg.Parts {
+				param, ok := newHTTPParam(g, part, toGoType)
+				if !ok {
+					op.Encoding = ""
+					return op
+				}
+				param.Arg = replaceReservedWords(param.Arg)
+				op.Params = append(op.Params, param)
+			}
+		}
+		return op
+	}
+
+	// soapArray describes ct when it restricts soapenc:Array to items of a
+	// single dimension, or returns nil.
+	soapArray := func(ct *XSDComplexType) *soapArray {
+		r := ct.ComplexContent.Restriction
+		if localName(r.Base) != "Array" {
+			return nil
+		}
+		array := &soapArray{ItemName: "item"}
+		var itemType string
+		for _, attr := range r.Attributes {
+			i := strings.LastIndex(attr.ArrayType, "[")
+			if i < 0 {
+				continue
+			}
+			if strings.Contains(attr.ArrayType[i:], ",") {
+				// Multidimensional arrays, such as xsd:int[,].
+				return nil
+			}
+			itemType = attr.ArrayType[:i]
+		}
+		if len(r.Sequence) > 0 {
+			el := r.Sequence[0]
+			if el.Name != "" {
+				array.ItemName = el.Name
+			}
+			if itemType == "" {
+				itemType = el.Type
+			}
+		}
+		if itemType == "" || strings.Contains(itemType, "[") {
+			return nil
+		}
+		array.ItemType = toGoType(itemType)
+		if xsd2GoTypes[strings.ToLower(localName(itemType))] == "" {
+			array.ItemXMLName = localName(itemType)
+		}
+		return array
+	}
+
+	// headerGoType returns header with the Go type of its element.
+	headerGoType := func(header headerElement) headerElement {
+		if goType := builtinType(header.Type); goType != "" {
+			header.Type = goType
+		} else {
+			header.Type = makeTypePublic(replaceReservedWords(header.Type))
+		}
+		return header
+	}
+
+	// headerFaults lists the soap:headerfault declarations of the operations
+	// of binding, once per fault element.
+	headerFaults := func(binding string) []headerElement {
+		var faults []headerElement
+		seen := make(map[string]bool)
+		for _, b := range g.wsdl.Binding {
+			if b.Name != binding {
+				continue
+			}
+			for _, bop := range b.Operations {
+				for _, headers := range [][]*WSDLSOAPHeader{bop.Input.SOAPHeader, bop.Output.SOAPHeader} {
+					for _, header := range headers {
+						for _, hf := range header.HeadersFault {
+							fault, ok := newHeaderElement(g, hf.Message, hf.Part)
+							if !ok || seen[fault.Element] {
+								continue
+							}
+							seen[fault.Element] = true
+							faults = append(faults, headerGoType(fault))
 						}
-						return el.Name
 					}
 				}
 			}
 		}
-		return ""
+		return faults
+	}
+
+	// responseHeaders lists the soap:header declarations of the output of
+	// the operation named operation of binding, once per element, with the
+	// types of the fields holding them, which are nil when absent. Headers
+	// declared with a type rather than an element are left out.
+	responseHeaders := func(operation, binding string) []headerElement {
+		var headers []headerElement
+		seen := make(map[string]bool)
+		for _, b := range g.wsdl.Binding {
+			if b.Name != binding {
+				continue
+			}
+			for _, bop := range b.Operations {
+				if bop.Name != operation {
+					continue
+				}
+				for _, h := range bop.Output.SOAPHeader {
+					header, ok := newHeaderElement(g, h.Message, h.Part)
+					if !ok || seen[header.Element] {
+						continue
+					}
+					seen[header.Element] = true
+					header = headerGoType(header)
+					if !strings.HasPrefix(header.Type, "*") && !strings.HasPrefix(header.Type, "[]") {
+						header.Type = "*" + header.Type
+					}
+					header.Field = makePublic(replaceReservedWords(header.Element))
+					if header.Field == "Response" {
+						header.Field = "ResponseHeader"
+					}
+					headers = append(headers, header)
+				}
+			}
+		}
+		return headers
+	}
+
+	// elementField returns the name and Go type of the field generated for
+	// the element el of a complex type, or false for elements of anonymous
+	// complex types, which are generated as nested structs.
+	elementField := func(el *XSDElement) (name, goType string, ok bool) {
+		switch {
+		case el.Ref != "":
+			goType = refType(el)
+			if el.MaxOccurs == "unbounded" {
+				goType = "[]" + goType
+			}
+			return makeFieldPublic(replaceReservedWords(removeNS(el.Ref))), goType, true
+		case el.Type != "":
+			if item := arrayItem(el); item != nil {
+				goType = arrayItemType(item)
+			} else {
+				goType = fieldType(el.Type, el.MinOccurs, el.MaxOccurs, el.Nillable)
+			}
+			return makeFieldPublic(replaceReservedWords(el.Name)), goType, true
+		case el.SimpleType != nil:
+			return makeFieldPublic(el.Name), fieldType(el.SimpleType.Restriction.Base, el.MinOccurs, "", el.Nillable), true
+		}
+		return "", "", false
+	}
+
+	// messageValue describes the value the methods of the clients send or
+	// return for message instead of a generated type: its simple part or,
+	// when messages are unwrapped, the only element of its wrapper type.
+	messageValue := func(message string) *messagePart {
+		if part := simplePart(message); part != nil {
+			part.Field = "Value"
+			return part
+		}
+		if !g.unwrapMessages {
+			return nil
+		}
+		for _, msg := range g.index().messages[stripns(message)] {
+			if len(msg.Parts) != 1 {
+				return nil
+			}
+			el, schema := g.lookupElement(msg.Parts[0].Element)
+			if el == nil {
+				return nil
+			}
+			ct := el.ComplexType
+			if ct == nil && el.Type != "" {
+				ct = complexTypes[removeNS(el.Type)]
+			}
+			if ct == nil || !isMessageWrapper(ct) {
+				return nil
+			}
+			var elements []*XSDElement
+			for _, elms := range [][]*XSDElement{ct.SequenceElements(), ct.Choice, ct.All} {
+				elements = append(elements, elms...)
+			}
+			name, goType, ok := elementField(elements[0])
+			if !ok {
+				return nil
+			}
+			return &messagePart{
+				Space:   schema.TargetNamespace,
+				Name:    el.Name,
+				Type:    goType,
+				Wrapper: g.typeName(replaceReservedWords(findType(message))),
+				Field:   name,
+			}
+		}
+		return nil
 	}
 
-	// TODO(c4milo): Add support for namespaces instead of striping them out
-	// TODO(c4milo): improve runtime complexity if performance turns out to be an issue.
-	findSOAPAction := func(operation, portType string) string {
-		for _, binding := range g.wsdl.Binding {
-			if stripns(binding.Type) != portType {
+	// exampleFields lists the fields of the element carried by message, for
+	// populating requests in generated examples.
+	exampleFields := func(message string) []exampleField {
+		var elements []*XSDElement
+		for _, msg := range g.index().messages[stripns(message)] {
+			if len(msg.Parts) == 0 {
 				continue
 			}
+			el, schema := g.lookupElement(msg.Parts[0].Element)
+			if el == nil {
+				break
+			}
+			ct := el.ComplexType
+			if ct == nil && el.Type != "" {
+				for _, t := range schema.ComplexTypes {
+					if t.Name == stripns(el.Type) {
+						ct = t
+					}
+				}
+			}
+			if ct != nil {
+				elements = append(ct.Sequence, ct.All...)
+			}
+		}
 
-			for _, soapOp := range binding.Operations {
-				if soapOp.Name == operation {
-					return soapOp.SOAPOperation.SOAPAction
+		var fields []exampleField
+		for _, el := range elements {
+			var f exampleField
+			var ok bool
+			if f.Name, f.Type, ok = elementField(el); !ok {
+				continue
+			}
+			switch f.Type {
+			case "string":
+				f.Value = `""`
+			case "bool":
+				f.Value = "false"
+			case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64", "byte":
+				f.Value = "0"
+			}
+			fields = append(fields, f)
+		}
+		return fields
+	}
+
+	// hasSimpleParts reports whether a generated client sends or receives
+	// a message of a simple type. HTTP clients send their input parts as
+	// parameters and only decode XML outputs.
+	hasSimpleParts := func() bool {
+		for _, c := range g.portClients() {
+			for _, op := range c.PortType.Operations {
+				if c.HTTPVerb != "" {
+					if httpOperation(op, c.Binding).XMLOutput && simplePart(op.Output.Message) != nil {
+						return true
+					}
+					continue
+				}
+				if simplePart(op.Input.Message) != nil || simplePart(op.Output.Message) != nil {
+					return true
 				}
 			}
 		}
-		return ""
+		return false
 	}
 
-	findServiceAddress := func(name string) string {
-		for _, service := range g.wsdl.Service {
-			for _, port := range service.Ports {
-				if port.Name == name {
-					return port.SOAPAddress.Location
+	// sharedOperations counts the SOAP clients with a method of each name.
+	// The count is computed once, since it is looked up for every operation.
+	var (
+		sharedOnce  sync.Once
+		sharedCount map[string]int
+	)
+	sharedOperations := func() map[string]int {
+		sharedOnce.Do(func() {
+			sharedCount = make(map[string]int)
+			for _, c := range g.portClients() {
+				if c.HTTPVerb != "" {
+					continue
+				}
+				seen := make(map[string]bool)
+				for _, op := range c.PortType.Operations {
+					name := operationName(op)
+					if !seen[name] {
+						seen[name] = true
+						sharedCount[name]++
+					}
 				}
 			}
+		})
+		return sharedCount
+	}
+
+	// operationKey names an operation of the client portType in the
+	// Operations registry: by its method name, or qualified by the client
+	// when several clients share the name.
+	operationKey := func(portType string, op *WSDLOperation) string {
+		name := operationName(op)
+		if sharedOperations()[name] > 1 {
+			return portType + "." + name
 		}
-		return ""
+		return name
+	}
+
+	// actionName names the SOAPAction constant of an operation of the client
+	// portType, qualified like its registry key.
+	actionName := func(portType string, op *WSDLOperation) string {
+		name := operationName(op)
+		if sharedOperations()[name] > 1 {
+			return portType + name + "Action"
+		}
+		return name + "Action"
+	}
+
+	// resultName names the type of the results of an operation of the
+	// client portType with their response headers, qualified like its
+	// SOAPAction constant.
+	resultName := func(portType string, op *WSDLOperation) string {
+		name := operationName(op)
+		if sharedOperations()[name] > 1 {
+			return portType + name + "Result"
+		}
+		return name + "Result"
+	}
+
+	// messageElement returns the name of the element carrying message.
+	messageElement := func(message string) xml.Name {
+		message = stripns(message)
+		for _, msg := range g.index().messages[message] {
+			if len(msg.Parts) == 0 {
+				continue
+			}
+			part := msg.Parts[0]
+			if part.Element == "" {
+				return xml.Name{Local: part.Name}
+			}
+			if el, schema := g.lookupElement(part.Element); el != nil {
+				return xml.Name{Space: schema.TargetNamespace, Local: el.Name}
+			}
+			return resolveQName(nil, g.wsdl, part.Element)
+		}
+		return xml.Name{}
 	}
 
 	return &tmplFunctions{
@@ -238,19 +1107,381 @@ func createTmplFunctions(g *GoWSDL) *tmplFunctions {
 			"removeNS":             removeNS,
 			"toGoTypeNs":           toGoTypeNs,
 			"toGoType":             toGoType,
+			"fieldType":            fieldType,
 			"stripns":              stripns,
 			"comment":              comment,
-			"makePublic":           makePublic,
-			"makeFieldPublic":      makePublic,
+			"makePublic":           makeTypePublic,
+			"makeFieldPublic":      makeFieldPublic,
+			"enumConstant":         enumConstant,
+			"xmlTypeName":          xmlTypeName,
+			"elementTypeName":      elementTypeName,
+			"refType":              refType,
+			"arrayItem":            arrayItem,
+			"arrayItemType":        arrayItemType,
+			"operationName":        operationName,
 			"goString":             goString,
+			"unsupported":          unsupported,
+			"hasField":             hasField,
+			"omitEmpty":            omitEmpty,
+			"fieldOmitEmpty":       fieldOmitEmpty,
+			"attrOmitEmpty":        attrOmitEmpty,
 			"dict":                 dict,
 			"findType":             findType,
-			"findSOAPAction":       findSOAPAction,
-			"findServiceAddress":   findServiceAddress,
+			"findSOAPAction":       g.soapAction,
+			"responseHeaders":      responseHeaders,
+			"resultName":           resultName,
+			"messageComment":       messageComment,
+			"findElement":          findElement,
+			"exampleFields":        exampleFields,
+			"exampleName":          exampleName,
+			"summary":              summary,
+			"httpOperation":        httpOperation,
+			"headerFaults":         headerFaults,
+			"simplePart":           simplePart,
+			"messageValue":         messageValue,
+			"operationKey":         operationKey,
+			"actionName":           actionName,
+			"messageElement":       messageElement,
+			"hasSimpleParts":       hasSimpleParts,
+			"soapArray":            soapArray,
+			"whiteSpace":           whiteSpace,
+			"listItem":             listItem,
+			"attrType":             attrType,
+			"attrFields":           attrFields,
+
+			"generateBuilders":       func() bool { return g.generateBuilders },
+			"generatePointerHelpers": func() bool { return g.generatePtrHelpers },
+			"generateNullable":       func() bool { return g.generateNullable },
+			"streamBase64":           func() bool { return g.streamBase64 },
+			"usesHexBinary":          g.usesHexBinary.Load,
+			"usesWhiteSpace":         g.usesWhiteSpace.Load,
+			"usesIDs":                g.usesIDs.Load,
+			"usesLists":              g.usesLists.Load,
+			"usesRawXML":             g.usesRawXML.Load,
+			"typeImports":            g.usedTypeImports,
+			"generateClone":          func() bool { return g.generateClone },
+			"generateEqual":          func() bool { return g.generateEqual },
+			"generateStringer":       func() bool { return g.generateStringer },
+			"generateEnumHelpers":    func() bool { return g.generateEnumHelpers },
+			"generateAsync":          func() bool { return g.generateAsync },
+			"generateBatch":          func() bool { return g.generateBatch },
+			"generateTestServer":     func() bool { return g.generateTestServer },
+			"generateInterfaces":     func() bool { return g.generateInterfaces },
+			"generateCaching":        func() bool { return g.generateCaching },
+			"generateLogging":        func() bool { return g.generateLogging },
+			"clientTypeName":         clientTypeName,
+			"generateVCR":            func() bool { return g.generateVCR },
+			"generateExamples":       func() bool { return g.generateExamples },
+			"fileHeader":             func() string { return g.fileHeader },
+			"generics":               func() bool { return g.goVersionAtLeast(genericsGoVersion) },
+			"emptyInterface":         g.emptyInterface,
+			"hasHTTPClients":         func() bool { return g.hasClients(true) },
+			"hasSOAPClients":         func() bool { return g.hasClients(false) },
 		},
 	}
 }
 
+// hasClients reports whether any client is generated for an http:binding
+// port, or for any other port when httpBinding is false.
+func (g *GoWSDL) hasClients(httpBinding bool) bool {
+	for _, c := range g.portClients() {
+		if (c.HTTPVerb != "") == httpBinding {
+			return true
+		}
+	}
+	return false
+}
+
+// clientTypeName names the concrete client implementing the interface of
+// portType: the unexported port type name with a Client suffix.
+func clientTypeName(portType string) string {
+	name := []rune(portType)
+	if len(name) == 0 {
+		return "client"
+	}
+	name[0] = unicode.ToLower(name[0])
+	return string(name) + "Client"
+}
+
+// exampleName names the example of a client method as go vet expects it:
+// ExampleT_M, or a package example when underscores in either name would
+// make that ambiguous.
+func exampleName(client, method string) string {
+	if !strings.Contains(client+method, "_") {
+		return "Example" + client + "_" + method
+	}
+	suffix := []rune(strings.Replace(client+method, "_", "", -1))
+	suffix[0] = unicode.ToLower(suffix[0])
+	return "Example_" + string(suffix)
+}
+
+// summary returns the first sentence of doc on one line, to document
+// services and operations in lists.
+func summary(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if i := strings.Index(doc, ". "); i >= 0 {
+		doc = doc[:i+1]
+	}
+	return doc
+}
+
+// isArrayWrapper reports whether ct holds nothing but a sequence of one
+// element of a named type or reference that may be repeated, as the
+// ArrayOf types of many services do.
+func isArrayWrapper(ct *XSDComplexType) bool {
+	if len(ct.Sequence) != 1 || ct.Abstract || ct.Mixed ||
+		len(ct.Choice)+len(ct.SequenceChoice)+len(ct.All)+len(ct.Attributes) > 0 ||
+		len(ct.Any)+len(ct.ChoiceAny)+len(ct.Groups)+len(ct.AttributeGroups) > 0 || ct.AnyAttribute != nil ||
+		ct.ComplexContent.Extension.Base != "" || ct.ComplexContent.Restriction.Base != "" ||
+		ct.SimpleContent.Extension.Base != "" {
+		return false
+	}
+	item := ct.Sequence[0]
+	if item.Type == "" && item.Ref == "" {
+		return false
+	}
+	max := occurs(item.MaxOccurs, 1)
+	return max < 0 || max > 1
+}
+
+// isMessageWrapper reports whether ct holds nothing but one element, which
+// unwrapped operations take or return instead.
+func isMessageWrapper(ct *XSDComplexType) bool {
+	return len(ct.SequenceElements())+len(ct.Choice)+len(ct.All) == 1 && !ct.Abstract && !ct.Mixed &&
+		len(ct.Attributes)+len(ct.Any)+len(ct.ChoiceAny)+len(ct.Groups)+len(ct.AttributeGroups) == 0 &&
+		ct.AnyAttribute == nil && ct.ComplexContent.Extension.Base == "" &&
+		ct.ComplexContent.Restriction.Base == "" && ct.SimpleContent.Extension.Base == ""
+}
+
+// httpOperation describes an operation of an HTTP GET/POST binding.
+// Encoding names the generated HTTPEncoding constant used for the input
+// parts, or is empty when they cannot be sent as HTTP parameters.
+type httpOperation struct {
+	Location  string
+	Encoding  string
+	XMLOutput bool
+	Params    []httpParam
+}
+
+// httpParam is an input part of an HTTP operation, passed to the generated
+// method as Arg of type Type and sent as the Value expression.
+type httpParam struct {
+	Name  string
+	Arg   string
+	Type  string
+	Value string
+}
+
+// httpParamLocals are the identifiers HTTP operation methods use, which
+// arguments must not shadow.
+var httpParamLocals = map[string]bool{
+	"service": true, "response": true, "err": true, "opts": true,
+	"context": true, "fmt": true, "url": true, "time": true,
+}
+
+// newHTTPParam returns the parameter for part. Only parts of simple types
+// can be sent; ok is false otherwise.
+func newHTTPParam(g *GoWSDL, part *WSDLPart, toGoType func(string) string) (param httpParam, ok bool) {
+	if part.Type == "" {
+		return param, false
+	}
+	arg := []rune(part.Name)
+	if len(arg) == 0 {
+		return param, false
+	}
+	arg[0] = unicode.ToLower(arg[0])
+	param = httpParam{Name: part.Name, Arg: string(arg)}
+	if httpParamLocals[param.Arg] || predeclared[param.Arg] {
+		param.Arg += "Param"
+	}
+
+	t := localName(part.Type)
+	switch strings.ToLower(t) {
+	case "string", "normalizedstring", "token", "anyuri", "qname", "base64binary", "hexbinary",
+		"id", "idref", "idrefs":
+		// Binary parts are passed already encoded.
+		param.Type, param.Value = "string", param.Arg
+		return param, true
+	case "datetime":
+		param.Type, param.Value = "time.Time", param.Arg+".Format(time.RFC3339)"
+		return param, true
+	case "date":
+		param.Type, param.Value = "time.Time", param.Arg+`.Format("2006-01-02")`
+		return param, true
+	case "time":
+		param.Type, param.Value = "time.Time", param.Arg+`.Format("15:04:05")`
+		return param, true
+	}
+	if goType := xsd2GoTypes[strings.ToLower(t)]; goType != "" {
+		if goType == "interface{}" {
+			return param, false
+		}
+		param.Type, param.Value = goType, "fmt.Sprint("+param.Arg+")"
+		return param, true
+	}
+	if len(g.index().simpleTypes[t]) > 0 {
+		param.Type = strings.TrimPrefix(toGoType(part.Type), "*")
+		param.Value = "fmt.Sprint(" + param.Arg + ")"
+		return param, true
+	}
+	return param, false
+}
+
+// soapArray is a SOAP encoded array of ItemType values, sent as ItemName
+// elements. ItemXMLName is the element name the item type expects when
+// decoding, or empty for built-in types.
+type soapArray struct {
+	ItemType    string
+	ItemName    string
+	ItemXMLName string
+}
+
+// headerElement is a message part carried in a SOAP header, such as a
+// fault a response header may carry: the local name of its element, and the
+// Go type it decodes into with the XML name that type expects. Field names
+// the field of an operation result holding it.
+type headerElement struct {
+	Element  string
+	TypeName string
+	Type     string
+	Field    string
+}
+
+// newHeaderElement resolves the part of message a soap:header or
+// soap:headerfault refers to. Only parts declared with an element can be
+// recognized in a header; ok is false otherwise.
+func newHeaderElement(g *GoWSDL, message, partName string) (header headerElement, ok bool) {
+	for _, msg := range g.index().messages[localName(message)] {
+		for _, part := range msg.Parts {
+			if part.Name != partName || part.Element == "" {
+				continue
+			}
+			header.Element = localName(part.Element)
+			header.TypeName = header.Element
+			if el, _ := g.lookupElement(part.Element); el != nil && el.Type != "" {
+				header.TypeName = localName(el.Type)
+			}
+			header.Type = header.TypeName
+			if name, ok := g.elementType(part.Element); ok {
+				header.Type = name
+			}
+			// Prefixed complex types are decoded under their XML name.
+			for _, schema := range g.wsdl.Types.Schemas {
+				for _, ct := range schema.ComplexTypes {
+					if ct.Name == header.TypeName && g.typeXMLNames[ct] != "" {
+						header.TypeName = g.typeXMLNames[ct]
+					}
+				}
+			}
+			return header, true
+		}
+	}
+	return header, false
+}
+
+// attrField is the field of an attribute, of Go type Type holding values of
+// Go type Value. Default is the Go literal of the default value of optional
+// attributes, and Missing the condition reporting required attributes that
+// are not set, if they can be told.
+type attrField struct {
+	Name    string
+	Field   string
+	Type    string
+	Value   string
+	Default string
+	Missing string
+}
+
+// listItem is the item of an xs:list simple type, of Go type Type, and the
+// values it is restricted to, if any.
+type listItem struct {
+	Type        string
+	Enumeration []XSDRestrictionValue
+}
+
+// messagePart is a message value of Go type Type, sent as the element Name
+// of namespace Space. Values of simple types are sent as the content of the
+// element, and unwrapped values as the field Field of the Wrapper type of
+// the element.
+type messagePart struct {
+	Space   string
+	Name    string
+	Type    string
+	Wrapper string
+	Field   string
+}
+
+// lookupElement returns the global element a message part refers to by the
+// QName qname, with the schema declaring it. Elements of the namespace of
+// qname are preferred to elements of the same local name in other schemas,
+// and exact matches to matches ignoring case.
+func (g *GoWSDL) lookupElement(qname string) (*XSDElement, *XSDSchema) {
+	if qname == "" {
+		return nil, nil
+	}
+	name := resolveQName(nil, g.wsdl, qname)
+	for _, sameSpace := range []bool{true, false} {
+		for _, e := range g.index().elements[name.Local] {
+			if !sameSpace || name.Space == "" || e.schema.TargetNamespace == name.Space {
+				return e.el, e.schema
+			}
+		}
+		// Names differing in case only are looked up by scanning.
+		for _, schema := range g.wsdl.Types.Schemas {
+			if sameSpace && name.Space != "" && schema.TargetNamespace != name.Space {
+				continue
+			}
+			for _, el := range schema.Elements {
+				if strings.EqualFold(el.Name, name.Local) {
+					return el, schema
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// elementType returns the Go name of the type of the global element a
+// message part refers to, and whether the element was found.
+func (g *GoWSDL) elementType(qname string) (string, bool) {
+	el, schema := g.lookupElement(qname)
+	if el == nil {
+		return "", false
+	}
+	if el.Type != "" {
+		return resolveQName(schema, g.wsdl, el.Type).Local, true
+	}
+	if name, ok := g.elementTypeNames[el]; ok {
+		return name, true
+	}
+	return el.Name, true
+}
+
+// exampleField is a request field shown in a generated example. Value holds
+// a literal for Type, or is empty when none can be written.
+type exampleField struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// unsupported renders a comment carrying an XSD construct the generator
+// does not model, so consumers can see what was dropped. attrs holds
+// name/value pairs; empty values are left out.
+func unsupported(tag string, attrs ...string) string {
+	var b bytes.Buffer
+	b.WriteString("// gowsdl: unsupported <xs:")
+	b.WriteString(tag)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i+1] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, " %s=%q", attrs[i], attrs[i+1])
+	}
+	b.WriteString("/>\n")
+	return b.String()
+}
+
 func goString(s string) string {
 	return strings.Replace(s, "\"", "\\\"", -1)
 }
@@ -271,6 +1502,26 @@ func dict(values ...interface{}) (map[string]interface{}, error) {
 	return resultDict, nil
 }
 
+// exportIdentifier returns identifier exported when export is set, and as
+// declared otherwise, apart from the predeclared identifiers it must not
+// shadow.
+func exportIdentifier(identifier string, export bool) string {
+	if !export {
+		return unshadowIdentifier(identifier)
+	}
+	return makePublic(identifier)
+}
+
+// unshadowIdentifier appends an underscore to the identifiers of Go
+// predeclared types, constants and functions and to the names of imported
+// packages.
+func unshadowIdentifier(identifier string) string {
+	if predeclared[identifier] || importedPackages[identifier] {
+		return identifier + "_"
+	}
+	return identifier
+}
+
 func makePublic(identifier string) string {
 	field := []rune(identifier)
 	if len(field) == 0 {
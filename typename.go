@@ -0,0 +1,162 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// TypeNameTransforms rename the Go types generated for the schemas, so that
+// they follow the conventions of the code using them. Their XML names are
+// not affected.
+type TypeNameTransforms struct {
+	// StripPrefixes are removed from the start of the names, such as the
+	// ns1_ or ArrayOf of generated schemas. Only the first one found is.
+	StripPrefixes []string
+	// Replacements are applied in order to the stripped names.
+	Replacements []TypeNameReplacement
+	// Prefix and Suffix are added to the names, around the prefixes of their
+	// namespaces, such as the name of the service.
+	Prefix, Suffix string
+}
+
+// A TypeNameReplacement replaces the matches of Pattern in type names with
+// Replacement, in which $1 stands for the first submatch and so on.
+type TypeNameReplacement struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// SetTypeNameTransforms renames the Go types of the named simple and complex
+// types of the schemas and of their global elements with t. A type whose
+// new name is taken by another type keeps its name, which is logged.
+func (g *GoWSDL) SetTypeNameTransforms(t TypeNameTransforms) {
+	g.typeNames = &t
+}
+
+// apply returns name transformed, prefixed with the prefix of its namespace.
+// t may be nil.
+func (t *TypeNameTransforms) apply(nsPrefix, name string) string {
+	if t == nil {
+		return nsPrefix + name
+	}
+	transformed := name
+	for _, prefix := range t.StripPrefixes {
+		if prefix != "" && strings.HasPrefix(transformed, prefix) {
+			transformed = transformed[len(prefix):]
+			break
+		}
+	}
+	for _, r := range t.Replacements {
+		transformed = r.Pattern.ReplaceAllString(transformed, r.Replacement)
+	}
+	if transformed == "" {
+		// Names cannot be stripped to nothing.
+		transformed = name
+	}
+	return t.Prefix + nsPrefix + transformed + t.Suffix
+}
+
+// renameTypes renames the named types of every schema after the prefixes of
+// their namespaces and the type name transforms, and updates the references
+// to them. It returns the original names of the renamed complex types,
+// which remain their XML names.
+func (g *GoWSDL) renameTypes() map[*XSDComplexType]string {
+	var prefixes map[string]string
+	if len(g.typeNsPrefixes) > 0 {
+		prefixes = typeNamespacePrefixes(g.wsdl.Types.Schemas, g.typeNsPrefixes)
+	}
+
+	type typeKey struct{ ns, name string }
+	type renaming struct {
+		typeKey
+		prefixed, renamed string
+	}
+	var renamings []*renaming
+	for _, schema := range g.wsdl.Types.Schemas {
+		ns := schema.TargetNamespace
+		var typeNames []string
+		for _, st := range schema.SimpleType {
+			typeNames = append(typeNames, st.Name)
+		}
+		for _, ct := range schema.ComplexTypes {
+			typeNames = append(typeNames, ct.Name)
+		}
+		for _, name := range typeNames {
+			renamings = append(renamings, &renaming{typeKey{ns, name}, prefixes[ns] + name, g.typeNames.apply(prefixes[ns], name)})
+		}
+	}
+
+	// Types renamed by the transforms keep their names when their new names
+	// are taken by types that are not, or by types renamed before.
+	names := make(map[typeKey]string)
+	taken := make(map[string]string)
+	for _, transformed := range []bool{false, true} {
+		for _, r := range renamings {
+			if (r.renamed != r.prefixed) != transformed {
+				continue
+			}
+			if original, ok := taken[r.renamed]; ok && original != r.name {
+				log.Printf("[WARN] Type %s of namespace %s keeps its name, %s is the name of type %s", r.name, r.ns, r.renamed, original)
+				r.renamed = r.prefixed
+			}
+			taken[r.renamed] = r.name
+			names[r.typeKey] = r.renamed
+		}
+	}
+	return g.wsdl.renameTypes(func(ns, name string) string {
+		return names[typeKey{ns, name}]
+	})
+}
+
+// renameTypes renames the named types of every schema with rename, given
+// their namespaces and names, and updates the references to them. It
+// returns the original names of the renamed complex types, which remain
+// their XML names.
+func (w *WSDL) renameTypes(rename func(ns, name string) string) map[*XSDComplexType]string {
+	xmlNames := make(map[*XSDComplexType]string)
+	renamed := make(map[xml.Name]string)
+	for _, schema := range w.Types.Schemas {
+		for _, st := range schema.SimpleType {
+			if name := rename(schema.TargetNamespace, st.Name); name != st.Name {
+				renamed[xml.Name{Space: schema.TargetNamespace, Local: st.Name}] = name
+				st.Name = name
+			}
+		}
+		for _, ct := range schema.ComplexTypes {
+			if name := rename(schema.TargetNamespace, ct.Name); name != ct.Name {
+				renamed[xml.Name{Space: schema.TargetNamespace, Local: ct.Name}] = name
+				xmlNames[ct] = ct.Name
+				ct.Name = name
+			}
+		}
+	}
+	if len(renamed) == 0 {
+		return xmlNames
+	}
+
+	for _, schema := range w.Types.Schemas {
+		r := &typeRenamer{schema: schema, wsdl: w, renamed: renamed}
+		for _, st := range schema.SimpleType {
+			r.simpleType(st)
+		}
+		for _, ct := range schema.ComplexTypes {
+			r.complexType(ct)
+		}
+		r.elements(schema.Elements)
+		r.attributes(schema.Attributes)
+	}
+	r := &typeRenamer{wsdl: w, renamed: renamed}
+	for _, msg := range w.Messages {
+		for _, part := range msg.Parts {
+			part.Type = r.rename(part.Type)
+		}
+	}
+
+	return xmlNames
+}
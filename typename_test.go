@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTypeNameTransforms(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/typename.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetTypeNameTransforms(TypeNameTransforms{
+		StripPrefixes: []string{"ns0_", "ns1_"},
+		Replacements:  []TypeNameReplacement{{Pattern: regexp.MustCompile(`^ArrayOf(.+)$`), Replacement: "${1}List"}},
+		Prefix:        "Shop",
+	})
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"type ShopStatus string",
+		"type ShopOrder struct {\n\tXMLName xml.Name `xml:\"urn:shop ns1_Order\"`",
+		"Status *ShopStatus `xml:\"status,omitempty\"`",
+		"Lines *ShopLineList `xml:\"lines,omitempty\"`",
+		"type ShopLineList struct {\n\tXMLName xml.Name `xml:\"urn:shop ArrayOfLine\"`",
+		"Line []*ShopLine `xml:\"Line,omitempty\"`",
+		// Line takes the name ns1_Line is stripped to.
+		"type ShopLine struct {",
+		"type Ns1_Line struct {",
+		"type ShopGetOrder struct {\n\tXMLName xml.Name `xml:\"urn:shop GetOrder\"`",
+		"func (service *Shop) GetOrder(request *ShopGetOrder, opts ...CallOption) (*ShopOrder, error) {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestTypeNameTransforms_Apply(t *testing.T) {
+	transforms := &TypeNameTransforms{
+		StripPrefixes: []string{"ns1_", "ns"},
+		Replacements: []TypeNameReplacement{
+			{Pattern: regexp.MustCompile(`Type$`), Replacement: ""},
+			{Pattern: regexp.MustCompile(`_`), Replacement: ""},
+		},
+		Suffix: "V2",
+	}
+	for _, test := range []struct {
+		nsPrefix, name, want string
+	}{
+		{"", "ns1_OrderType", "OrderV2"},
+		{"", "nsOrder_Line", "OrderLineV2"},
+		{"Billing", "ns1_Address", "BillingAddressV2"},
+		// Names are not stripped to nothing.
+		{"", "Type", "TypeV2"},
+	} {
+		if got := transforms.apply(test.nsPrefix, test.name); got != test.want {
+			t.Errorf("%s%s: got %s, want %s", test.nsPrefix, test.name, got, test.want)
+		}
+	}
+	if got := (*TypeNameTransforms)(nil).apply("Billing", "Address"); got != "BillingAddress" {
+		t.Errorf("got %s without transforms, want BillingAddress", got)
+	}
+}
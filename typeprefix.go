@@ -0,0 +1,220 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SetTypeNamespacePrefixes maps target namespaces to the prefix put in front
+// of the names of their simple and complex types, so that types of the same
+// name in different namespaces get distinct Go names. An empty prefix keeps
+// the names of a namespace as they are. Namespaces missing from prefixes
+// get a prefix derived from the namespace, which is logged. The XML names
+// of the types are not affected.
+func (g *GoWSDL) SetTypeNamespacePrefixes(prefixes map[string]string) {
+	g.typeNsPrefixes = prefixes
+}
+
+// typeNamespacePrefixes completes prefixes with a distinct prefix for every
+// other target namespace declaring types.
+func typeNamespacePrefixes(schemas []*XSDSchema, prefixes map[string]string) map[string]string {
+	all := make(map[string]string, len(prefixes))
+	used := make(map[string]bool)
+	for ns, prefix := range prefixes {
+		all[ns] = prefix
+		used[prefix] = true
+	}
+
+	var missing []string
+	for _, schema := range schemas {
+		ns := schema.TargetNamespace
+		if _, ok := all[ns]; ok || ns == "" || len(schema.SimpleType)+len(schema.ComplexTypes) == 0 {
+			continue
+		}
+		all[ns] = ""
+		missing = append(missing, ns)
+	}
+	sort.Strings(missing)
+
+	for _, ns := range missing {
+		base := namespacePrefix(ns)
+		prefix := base
+		for i := 2; used[prefix]; i++ {
+			prefix = base + strconv.Itoa(i)
+		}
+		used[prefix] = true
+		all[ns] = prefix
+		log.Printf("[INFO] No type prefix configured for namespace %s, using %s", ns, prefix)
+	}
+	return all
+}
+
+// namespacePrefix derives a prefix from the last segment of a namespace,
+// such as Orders for urn:example:orders or Tempuri for http://tempuri.org/.
+func namespacePrefix(ns string) string {
+	segments := strings.FieldsFunc(ns, func(r rune) bool {
+		return r == '/' || r == ':'
+	})
+	segment := ""
+	if len(segments) > 0 {
+		segment = segments[len(segments)-1]
+	}
+	for _, label := range strings.Split(segment, ".") {
+		if label != "www" {
+			segment = label
+			break
+		}
+	}
+
+	prefix := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, segment)
+	if prefix == "" || unicode.IsDigit(rune(prefix[0])) {
+		prefix = "Ns" + prefix
+	}
+	return strings.ToUpper(prefix[:1]) + prefix[1:]
+}
+
+// typeRenamer updates the type references of a schema, or of the WSDL
+// messages when schema is nil, to the renamed types. It also records in
+// refTypes the Go types of the element references to the elements of refs.
+type typeRenamer struct {
+	schema   *XSDSchema
+	wsdl     *WSDL
+	renamed  map[xml.Name]string
+	refs     map[xml.Name]string
+	refTypes map[*XSDElement]string
+}
+
+// rename returns the reference qname to the new name of its type, keeping
+// its namespace prefix.
+func (r *typeRenamer) rename(qname string) string {
+	if qname == "" {
+		return qname
+	}
+	if local, ok := r.renamed[resolveQName(r.schema, r.wsdl, qname)]; ok {
+		if i := strings.Index(qname, ":"); i >= 0 {
+			return qname[:i+1] + local
+		}
+		return local
+	}
+	return qname
+}
+
+// resolveQName resolves a QName found in schema, or in the WSDL when schema
+// is nil. Prefixes the schema does not declare are looked up in the WSDL
+// definitions, and unprefixed names belong to the target namespace of the
+// schema.
+func resolveQName(schema *XSDSchema, w *WSDL, qname string) xml.Name {
+	var name xml.Name
+	i := strings.Index(qname, ":")
+	if i < 0 {
+		name.Local = qname
+		if schema != nil {
+			name.Space = schema.TargetNamespace
+		}
+		return name
+	}
+
+	name.Local = qname[i+1:]
+	if schema != nil {
+		name.Space = schema.Xmlns[qname[:i]]
+	}
+	if name.Space == "" {
+		name.Space = w.Xmlns[qname[:i]]
+	}
+	return name
+}
+
+func (r *typeRenamer) simpleType(st *XSDSimpleType) {
+	if st == nil {
+		return
+	}
+	st.Restriction.Base = r.rename(st.Restriction.Base)
+	st.List.ItemType = r.rename(st.List.ItemType)
+	r.simpleType(st.List.SimpleType)
+	if st.Union.MemberTypes != "" {
+		members := strings.Fields(st.Union.MemberTypes)
+		for i, member := range members {
+			members[i] = r.rename(member)
+		}
+		st.Union.MemberTypes = strings.Join(members, " ")
+	}
+	for _, member := range st.Union.SimpleType {
+		r.simpleType(member)
+	}
+}
+
+func (r *typeRenamer) complexType(ct *XSDComplexType) {
+	if ct == nil {
+		return
+	}
+	r.elements(ct.Sequence)
+	r.elements(ct.Choice)
+	r.elements(ct.SequenceChoice)
+	r.elements(ct.All)
+	r.attributes(ct.Attributes)
+	r.extension(&ct.ComplexContent.Extension)
+	r.extension(&ct.SimpleContent.Extension)
+	ct.ComplexContent.Restriction.Base = r.rename(ct.ComplexContent.Restriction.Base)
+	r.attributes(ct.ComplexContent.Restriction.Attributes)
+	r.elements(ct.ComplexContent.Restriction.Sequence)
+	for _, group := range ct.Groups {
+		r.elementValues(group.Sequence)
+		r.elementValues(group.Choice)
+		r.elementValues(group.All)
+	}
+}
+
+func (r *typeRenamer) extension(ext *XSDExtension) {
+	ext.Base = r.rename(ext.Base)
+	r.attributes(ext.Attributes)
+	r.elementValues(ext.Sequence)
+}
+
+func (r *typeRenamer) elements(elms []*XSDElement) {
+	for _, elm := range elms {
+		r.element(elm)
+	}
+}
+
+func (r *typeRenamer) elementValues(elms []XSDElement) {
+	for i := range elms {
+		r.element(&elms[i])
+	}
+}
+
+func (r *typeRenamer) element(elm *XSDElement) {
+	elm.Type = r.rename(elm.Type)
+	if name, ok := r.refs[resolveQName(r.schema, r.wsdl, elm.Ref)]; ok && elm.Ref != "" {
+		r.refTypes[elm] = name
+	}
+	r.complexType(elm.ComplexType)
+	r.simpleType(elm.SimpleType)
+	for _, group := range elm.Groups {
+		r.elementValues(group.Sequence)
+		r.elementValues(group.Choice)
+		r.elementValues(group.All)
+	}
+}
+
+func (r *typeRenamer) attributes(attrs []*XSDAttribute) {
+	for _, attr := range attrs {
+		attr.Type = r.rename(attr.Type)
+		if i := strings.Index(attr.ArrayType, "["); i > 0 {
+			attr.ArrayType = r.rename(attr.ArrayType[:i]) + attr.ArrayType[i:]
+		}
+		r.simpleType(attr.SimpleType)
+	}
+}
@@ -0,0 +1,49 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeNamespacePrefixes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/typeprefix.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetTypeNamespacePrefixes(map[string]string{"urn:example:orders": "Order"})
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := typeCheck(t, resp)
+	for _, expected := range []string{
+		"type OrderCode string",
+		"type BillingCode string",
+		"type OrderAddress struct {",
+		"XMLName xml.Name `xml:\"urn:example:orders Address\"`",
+		"type BillingAddress struct {",
+		"XMLName xml.Name `xml:\"urn:example:billing Address\"`",
+		"Shipping *OrderAddress `xml:\"Shipping,omitempty\"`",
+		"Billing *BillingAddress `xml:\"Billing,omitempty\"`",
+		"Code *BillingCode `xml:\"Code,omitempty\"`",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("missing %q in generated code", expected)
+		}
+	}
+
+	for ns, expected := range map[string]string{
+		"urn:example:billing":        "Billing",
+		"http://www.tempuri.org/":    "Tempuri",
+		"http://example.org/2004/07": "Ns07",
+	} {
+		if prefix := namespacePrefix(ns); prefix != expected {
+			t.Errorf("%s: got prefix %s wanted %s", ns, prefix, expected)
+		}
+	}
+}
@@ -8,15 +8,158 @@ var typesTmpl = `
 {{define "SimpleType"}}
 	{{$type := replaceReservedWords .Name | makePublic}}
 	{{if .Doc}} {{.Doc | comment}} {{end}}
-	type {{$type}} {{toGoType .Restriction.Base}}
+	{{$item := listItem .List}}
+	{{if $item}}
+		type {{$type}} []{{$item.Type}}
+
+		// MarshalText joins the items of v with spaces.
+		func (v {{$type}}) MarshalText() ([]byte, error) {
+			items := make([]string, len(v))
+			for i, item := range v {
+				text, err := formatListItem(item)
+				if err != nil {
+					return nil, err
+				}
+				{{if $item.Enumeration}}
+					if !valid{{$type}}Item(text) {
+						return nil, fmt.Errorf("invalid {{$type}} item %q", text)
+					}
+				{{end}}
+				items[i] = text
+			}
+			return []byte(strings.Join(items, " ")), nil
+		}
+
+		// UnmarshalText splits text into the items of v at white space.
+		func (v *{{$type}}) UnmarshalText(text []byte) error {
+			fields := strings.Fields(string(text))
+			list := make({{$type}}, len(fields))
+			for i, field := range fields {
+				{{if $item.Enumeration}}
+					if !valid{{$type}}Item(field) {
+						return fmt.Errorf("invalid {{$type}} item %q", field)
+					}
+				{{end}}
+				if err := parseListItem(field, &list[i]); err != nil {
+					return fmt.Errorf("invalid {{$type}} item %q: %v", field, err)
+				}
+			}
+			*v = list
+			return nil
+		}
+		{{if $item.Enumeration}}
+
+		func valid{{$type}}Item(s string) bool {
+			switch s {
+			case {{range $i, $e := $item.Enumeration}}{{if $i}}, {{end}}"{{goString $e.Value}}"{{end}}:
+				return true
+			}
+			return false
+		}
+		{{end}}
+	{{else}}
+		{{if .List.ItemType}}{{unsupported "list" "itemType" .List.ItemType}}{{else if .List.SimpleType}}{{unsupported "list"}}{{end}}
+		{{if or .Union.MemberTypes .Union.SimpleType}}{{unsupported "union" "memberTypes" .Union.MemberTypes}}{{end}}
+		type {{$type}} {{toGoType .Restriction.Base}}
+	{{end}}
+	{{if eq (toGoType .Restriction.Base) "HexBinary"}}
+		func (v {{$type}}) MarshalText() ([]byte, error) {
+			return HexBinary(v).MarshalText()
+		}
+
+		func (v *{{$type}}) UnmarshalText(text []byte) error {
+			return (*HexBinary)(v).UnmarshalText(text)
+		}
+
+		func (v {{$type}}) String() string {
+			return HexBinary(v).String()
+		}
+	{{end}}
+	{{with whiteSpace .Restriction}}
+		// UnmarshalText {{if eq . "collapse"}}collapses{{else}}replaces{{end}} the white space of {{$type}} values
+		// as its whiteSpace facet requires.
+		func (v *{{$type}}) UnmarshalText(text []byte) error {
+			*v = {{$type}}({{.}}WhiteSpace(string(text)))
+			return nil
+		}
+	{{end}}
+	{{template "UnsupportedFacets" .Restriction}}
 	{{if .Restriction.Enumeration}}
 	const (
 		{{with .Restriction}}
 			{{range .Enumeration}}
 				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{$type}}{{$value := replaceReservedWords .Value}}{{$value | makePublic}} {{$type}} = "{{goString .Value}}" {{end}}
+				{{enumConstant $.Name .Value}} {{$type}} = "{{goString .Value}}" {{end}}
 		{{end}}
 	)
+	{{if and generateEnumHelpers (eq (toGoType .Restriction.Base) "string")}}
+		// All{{$type}}Values returns every enumerated {{$type}} value.
+		func All{{$type}}Values() []{{$type}} {
+			return []{{$type}}{
+				{{range .Restriction.Enumeration}}
+					{{enumConstant $.Name .Value}},
+				{{end}}
+			}
+		}
+
+		// Parse{{$type}} converts s into a {{$type}}, failing when s is not one
+		// of the enumerated values.
+		func Parse{{$type}}(s string) ({{$type}}, error) {
+			v := {{$type}}(s)
+			if !v.IsValid() {
+				return "", fmt.Errorf("invalid {{$type}} value %q", s)
+			}
+			return v, nil
+		}
+
+		func (v {{$type}}) String() string {
+			return string(v)
+		}
+
+		// IsValid reports whether v is one of the enumerated {{$type}} values.
+		func (v {{$type}}) IsValid() bool {
+			for _, x := range All{{$type}}Values() {
+				if x == v {
+					return true
+				}
+			}
+			return false
+		}
+	{{end}}
+	{{end}}
+{{end}}
+
+{{define "UnsupportedFacets"}}
+	{{with .Pattern.Value}}{{unsupported "pattern" "value" .}}{{end}}
+	{{with .MinInclusive.Value}}{{unsupported "minInclusive" "value" .}}{{end}}
+	{{with .MaxInclusive.Value}}{{unsupported "maxInclusive" "value" .}}{{end}}
+	{{with .MinExclusive.Value}}{{unsupported "minExclusive" "value" .}}{{end}}
+	{{with .MaxExclusive.Value}}{{unsupported "maxExclusive" "value" .}}{{end}}
+	{{with .TotalDigits.Value}}{{unsupported "totalDigits" "value" .}}{{end}}
+	{{with .FractionDigits.Value}}{{unsupported "fractionDigits" "value" .}}{{end}}
+	{{with .Length.Value}}{{unsupported "length" "value" .}}{{end}}
+	{{with .MinLength.Value}}{{unsupported "minLength" "value" .}}{{end}}
+	{{with .MaxLength.Value}}{{unsupported "maxLength" "value" .}}{{end}}
+{{end}}
+
+{{define "UnsupportedContent"}}
+	{{range .Any}}
+		{{unsupported "any" "namespace" .Namespace "processContents" .ProcessContents "minOccurs" .MinOccurs "maxOccurs" .MaxOccurs}}
+	{{end}}
+	{{range .ChoiceAny}}
+		{{unsupported "any" "namespace" .Namespace "processContents" .ProcessContents "minOccurs" .MinOccurs "maxOccurs" .MaxOccurs}}
+	{{end}}
+	{{range .Groups}}
+		{{unsupported "group" "ref" .Ref}}
+	{{end}}
+	{{range .AttributeGroups}}
+		{{unsupported "attributeGroup" "ref" .Ref}}
+	{{end}}
+	{{with .AnyAttribute}}
+		{{unsupported "anyAttribute" "namespace" .Namespace "processContents" .ProcessContents}}
+	{{end}}
+	{{if and .ComplexContent.Restriction.Base (not (soapArray .))}}
+		{{unsupported "restriction" "base" .ComplexContent.Restriction.Base}}
 	{{end}}
 {{end}}
 
@@ -33,7 +176,7 @@ var typesTmpl = `
 {{define "Attributes"}}
 	{{range .}}
 		{{if .Doc}} {{.Doc | comment}} {{end}}
-		{{ .Name | makeFieldPublic}} {{toGoType .Type}} ` + "`" + `xml:"{{.Name}},attr,omitempty"` + "`" + `
+		{{ .Name | makeFieldPublic}} {{attrType .}} ` + "`" + `xml:"{{.Name}},attr{{attrOmitEmpty .Use}}"` + "`" + `
 	{{end}}
 {{end}}
 
@@ -42,43 +185,186 @@ var typesTmpl = `
 {{end}}
 
 {{define "ComplexTypeInline"}}
-	{{replaceReservedWords .Name | makePublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}struct {
+	{{replaceReservedWords .Name | makeFieldPublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}struct {
 	{{with .ComplexType}}
 		{{if ne .ComplexContent.Extension.Base ""}}
 			{{template "ComplexContent" .ComplexContent}}
 		{{else if ne .SimpleContent.Extension.Base ""}}
 			{{template "SimpleContent" .SimpleContent}}
 		{{else}}
-			{{template "Elements" .Sequence}}
+			{{template "Elements" .SequenceElements}}
 			{{template "Elements" .Choice}}
-			{{template "Elements" .SequenceChoice}}
 			{{template "Elements" .All}}
 			{{template "Attributes" .Attributes}}
 		{{end}}
+		{{template "UnsupportedContent" .}}
+	{{end}}
+	} ` + "`" + `xml:"{{.Name}}{{omitEmpty .MinOccurs}}"` + "`" + `
+{{end}}
+
+{{define "TypeMethods"}}
+	{{if generateBuilders}}
+		{{template "Builder" .}}
+	{{end}}
+	{{$name := .Name}}
+	{{$type := .Type}}
+	{{$attrs := attrFields .Type}}
+	{{range $attrs}}
+		{{if and .Default (not (hasField $type (print "Get" .Field)))}}
+			// Get{{.Field}} returns the {{.Name}} attribute of t, or its default value
+			// when it is not set.
+			func (t *{{$name}}) Get{{.Field}}() {{.Value}} {
+				if t == nil || t.{{.Field}} == nil {
+					return {{.Default}}
+				}
+				return *t.{{.Field}}
+			}
+		{{end}}
+	{{end}}
+	{{if not (hasField .Type "Validate")}}
+		{{$checked := false}}
+		{{range $attrs}}{{if .Missing}}{{$checked = true}}{{end}}{{end}}
+		{{if $checked}}
+			// Validate reports the required attributes of t that are not set.
+			func (t *{{$name}}) Validate() error {
+				if t == nil {
+					return nil
+				}
+				var missing []string
+				{{range $attrs}}
+					{{if .Missing}}
+						if {{.Missing}} {
+							missing = append(missing, "{{.Name}}")
+						}
+					{{end}}
+				{{end}}
+				if len(missing) > 0 {
+					return errors.New("{{$name}}: missing required attributes " + strings.Join(missing, ", "))
+				}
+				return nil
+			}
+		{{end}}
+	{{end}}
+	{{if and generateClone (not (hasField .Type "Clone"))}}
+		// Clone returns a deep copy of t.
+		func (t *{{.Name}}) Clone() *{{.Name}} {
+			if t == nil {
+				return nil
+			}
+			return deepCopy(t).(*{{.Name}})
+		}
+	{{end}}
+	{{if and generateEqual (not (hasField .Type "Equal"))}}
+		// Equal reports whether t and other hold the same data.
+		func (t *{{.Name}}) Equal(other *{{.Name}}) bool {
+			return deepEqual(t, other)
+		}
+	{{end}}
+	{{if and generateStringer (not (hasField .Type "String"))}}
+		func (t *{{.Name}}) String() string {
+			return Dump(t)
+		}
+	{{end}}
+{{end}}
+
+{{define "Builder"}}
+	{{$builder := printf "%sBuilder" .Name}}
+	// {{$builder}} builds {{.Name}} values one field at a time.
+	type {{$builder}} struct {
+		v *{{.Name}}
+	}
+
+	// New{{$builder}} returns a builder for {{.Name}}.
+	func New{{$builder}}() *{{$builder}} {
+		return &{{$builder}}{v: new({{.Name}})}
+	}
+
+	{{with .Type}}
+		{{if ne .ComplexContent.Extension.Base ""}}
+			{{template "BuilderElements" dict "Builder" $builder "Elements" .ComplexContent.Extension.Sequence}}
+			{{template "BuilderAttributes" dict "Builder" $builder "Attributes" .ComplexContent.Extension.Attributes}}
+		{{else if ne .SimpleContent.Extension.Base ""}}
+			func (b *{{$builder}}) Value(v {{toGoType .SimpleContent.Extension.Base}}) *{{$builder}} {
+				b.v.Value = v
+				return b
+			}
+			{{template "BuilderAttributes" dict "Builder" $builder "Attributes" .SimpleContent.Extension.Attributes}}
+		{{else}}
+			{{template "BuilderElements" dict "Builder" $builder "Elements" .SequenceElements}}
+			{{template "BuilderElements" dict "Builder" $builder "Elements" .Choice}}
+			{{template "BuilderElements" dict "Builder" $builder "Elements" .All}}
+			{{template "BuilderAttributes" dict "Builder" $builder "Attributes" .Attributes}}
+		{{end}}
+	{{end}}
+
+	// Build returns the {{.Name}} assembled so far.
+	func (b *{{$builder}}) Build() *{{.Name}} {
+		return b.v
+	}
+{{end}}
+
+{{define "BuilderElements"}}
+	{{$builder := .Builder}}
+	{{range .Elements}}
+		{{if ne .Ref ""}}
+			{{$field := removeNS .Ref | replaceReservedWords | makeFieldPublic}}
+			func (b *{{$builder}}) {{$field}}(v {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{refType .}}) *{{$builder}} {
+				b.v.{{$field}} = v
+				return b
+			}
+		{{else if not .Type}}
+			{{if .SimpleType}}
+				{{$field := .Name | makeFieldPublic}}
+				func (b *{{$builder}}) {{$field}}(v {{fieldType .SimpleType.Restriction.Base .MinOccurs "" .Nillable}}) *{{$builder}} {
+					b.v.{{$field}} = v
+					return b
+				}
+			{{end}}
+		{{else}}
+			{{$field := replaceReservedWords .Name | makeFieldPublic}}
+			func (b *{{$builder}}) {{$field}}(v {{with arrayItem .}}{{arrayItemType .}}{{else}}{{fieldType .Type .MinOccurs .MaxOccurs .Nillable}}{{end}}) *{{$builder}} {
+				b.v.{{$field}} = v
+				return b
+			}
+		{{end}}
+	{{end}}
+{{end}}
+
+{{define "BuilderAttributes"}}
+	{{$builder := .Builder}}
+	{{range .Attributes}}
+		{{$field := .Name | makeFieldPublic}}
+		func (b *{{$builder}}) {{$field}}(v {{attrType .}}) *{{$builder}} {
+			b.v.{{$field}} = v
+			return b
+		}
 	{{end}}
-	} ` + "`" + `xml:"{{.Name}},omitempty"` + "`" + `
 {{end}}
 
 {{define "Elements"}}
 	{{range .}}
 		{{if ne .Ref ""}}
-			{{removeNS .Ref | replaceReservedWords  | makePublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{.Ref | toGoType}} ` + "`" + `xml:"{{.Ref | removeNS}},omitempty"` + "`" + `
+			{{removeNS .Ref | replaceReservedWords | makeFieldPublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{refType .}} ` + "`" + `xml:"{{.Ref | removeNS}}{{omitEmpty .MinOccurs}}"` + "`" + `
 		{{else}}
 		{{if not .Type}}
 			{{if .SimpleType}}
 				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{ .Name | makeFieldPublic}} {{toGoType .SimpleType.Restriction.Base}} ` + "`" + `xml:"{{.Name}},omitempty"` + "`" + `
+				{{$type := fieldType .SimpleType.Restriction.Base .MinOccurs "" .Nillable}}{{ .Name | makeFieldPublic}} {{$type}} ` + "`" + `xml:"{{.Name}}{{fieldOmitEmpty $type .MinOccurs}}"` + "`" + `
 			{{else}}
 				{{template "ComplexTypeInline" .}}
 			{{end}}
+		{{else if arrayItem .}}
+			{{$item := arrayItem .}}
+			{{if .Doc}}{{.Doc | comment}} {{end}}
+			{{replaceReservedWords .Name | makeFieldPublic}} {{arrayItemType $item}} ` + "`" + `xml:"{{.Name}}>{{with $item.Ref}}{{removeNS .}}{{else}}{{$item.Name}}{{end}}{{omitEmpty .MinOccurs}}"` + "`" + `
 		{{else}}
 			{{if .Doc}}{{.Doc | comment}} {{end}}
-			{{replaceReservedWords .Name | makeFieldPublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{.Type | toGoType}} ` + "`" + `xml:"{{.Name}},omitempty"` + "`" + ` {{end}}
+			{{$type := fieldType .Type .MinOccurs .MaxOccurs .Nillable}}{{replaceReservedWords .Name | makeFieldPublic}} {{$type}} ` + "`" + `xml:"{{.Name}}{{fieldOmitEmpty $type .MinOccurs}}"` + "`" + ` {{end}}
 		{{end}}
 	{{end}}
 {{end}}
 
-{{range .Schemas}}
+{{define "Schema"}}
 	{{ $targetNamespace := .TargetNamespace }}
 
 	{{range .SimpleType}}
@@ -89,21 +375,23 @@ var typesTmpl = `
 		{{if not .Type}}
 			{{/* ComplexTypeLocal */}}
 			{{$name := .Name}}
+			{{$typeName := elementTypeName . | replaceReservedWords | makePublic}}
 			{{with .ComplexType}}
-				type {{$name | replaceReservedWords | makePublic}} struct {
+				type {{$typeName}} struct {
 					XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{$name}}\"`" + `
 					{{if ne .ComplexContent.Extension.Base ""}}
 						{{template "ComplexContent" .ComplexContent}}
 					{{else if ne .SimpleContent.Extension.Base ""}}
 						{{template "SimpleContent" .SimpleContent}}
 					{{else}}
-						{{template "Elements" .Sequence}}
+						{{template "Elements" .SequenceElements}}
 						{{template "Elements" .Choice}}
-						{{template "Elements" .SequenceChoice}}
 						{{template "Elements" .All}}
 						{{template "Attributes" .Attributes}}
 					{{end}}
+					{{template "UnsupportedContent" .}}
 				}
+				{{template "TypeMethods" dict "Name" $typeName "Type" .}}
 			{{end}}
 		{{end}}
 	{{end}}
@@ -111,20 +399,479 @@ var typesTmpl = `
 	{{range .ComplexTypes}}
 		{{/* ComplexTypeGlobal */}}
 		{{$name := replaceReservedWords .Name | makePublic}}
+		{{with soapArray .}}
+		// {{$name}} is a SOAP encoded array, sent as {{.ItemName}} elements.
+		type {{$name}} []{{.ItemType}}
+
+		// MarshalXML encodes the items as {{.ItemName}} elements.
+		func (a {{$name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+			if err := e.EncodeToken(start); err != nil {
+				return err
+			}
+			for _, item := range a {
+				if err := e.EncodeElement(item, xml.StartElement{Name: xml.Name{Local: {{printf "%q" .ItemName}}}}); err != nil {
+					return err
+				}
+			}
+			return e.EncodeToken(start.End())
+		}
+
+		// UnmarshalXML decodes every child element as an item, whatever its
+		// name.
+		func (a *{{$name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			for {
+				tok, err := d.Token()
+				if err != nil {
+					return err
+				}
+				switch t := tok.(type) {
+				case xml.StartElement:
+					var item {{.ItemType}}
+					{{if .ItemXMLName}}t.Name.Local = {{printf "%q" .ItemXMLName}}{{end}}
+					if err := d.DecodeElement(&item, &t); err != nil {
+						return err
+					}
+					*a = append(*a, item)
+				case xml.EndElement:
+					return nil
+				}
+			}
+		}
+		{{else}}
 		type {{$name}} struct {
-			XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{.Name}}\"`" + `
+			XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{xmlTypeName .}}\"`" + `
 			{{if ne .ComplexContent.Extension.Base ""}}
 				{{template "ComplexContent" .ComplexContent}}
 			{{else if ne .SimpleContent.Extension.Base ""}}
 				{{template "SimpleContent" .SimpleContent}}
 			{{else}}
-				{{template "Elements" .Sequence}}
+				{{template "Elements" .SequenceElements}}
 				{{template "Elements" .Choice}}
-				{{template "Elements" .SequenceChoice}}
 				{{template "Elements" .All}}
 				{{template "Attributes" .Attributes}}
 			{{end}}
+			{{template "UnsupportedContent" .}}
 		}
+		{{template "TypeMethods" dict "Name" $name "Type" .}}
+		{{end}}
 	{{end}}
 {{end}}
+
+{{range .Schemas}}
+	{{template "Schema" .}}
+{{end}}
+
+{{if generatePointerHelpers}}
+	// Ptr returns a pointer to a copy of v, which is handy for filling
+	// optional fields in struct literals.
+	func Ptr[T any](v T) *T {
+		return &v
+	}
+
+	// Deref returns the value p points to, or def when p is nil.
+	func Deref[T any](p *T, def T) T {
+		if p == nil {
+			return def
+		}
+		return *p
+	}
+{{end}}
+
+{{if generateClone}}
+	// deepCopy returns a deep copy of v. Unexported struct fields, such as the
+	// internals of time.Time, are copied by value.
+	func deepCopy(v {{emptyInterface}}) {{emptyInterface}} {
+		if v == nil {
+			return nil
+		}
+		return copyValue(reflect.ValueOf(v)).Interface()
+	}
+
+	func copyValue(v reflect.Value) reflect.Value {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if v.IsNil() {
+				return reflect.Zero(v.Type())
+			}
+			c := reflect.New(v.Type().Elem())
+			c.Elem().Set(copyValue(v.Elem()))
+			return c
+		case reflect.Interface:
+			if v.IsNil() {
+				return reflect.Zero(v.Type())
+			}
+			c := reflect.New(v.Type()).Elem()
+			c.Set(copyValue(v.Elem()))
+			return c
+		case reflect.Slice:
+			if v.IsNil() {
+				return reflect.Zero(v.Type())
+			}
+			c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				c.Index(i).Set(copyValue(v.Index(i)))
+			}
+			return c
+		case reflect.Map:
+			if v.IsNil() {
+				return reflect.Zero(v.Type())
+			}
+			c := reflect.MakeMapWithSize(v.Type(), v.Len())
+			for _, k := range v.MapKeys() {
+				c.SetMapIndex(k, copyValue(v.MapIndex(k)))
+			}
+			return c
+		case reflect.Struct:
+			c := reflect.New(v.Type()).Elem()
+			c.Set(v)
+			for i := 0; i < v.NumField(); i++ {
+				if c.Field(i).CanSet() {
+					c.Field(i).Set(copyValue(v.Field(i)))
+				}
+			}
+			return c
+		}
+		return v
+	}
+{{end}}
+
+{{if generateEqual}}
+	var xmlNameType = reflect.TypeOf(xml.Name{})
+
+	// deepEqual compares a and b structurally. Nil pointers only equal other
+	// nil pointers, values of types with an Equal(T) bool method, such as
+	// time.Time, are compared with it, structs with unexported fields are
+	// compared with reflect.DeepEqual, nil and empty slices are considered
+	// equal and XMLName fields are ignored.
+	func deepEqual(a, b {{emptyInterface}}) bool {
+		return equalValue(reflect.ValueOf(a), reflect.ValueOf(b))
+	}
+
+	func equalValue(a, b reflect.Value) bool {
+		if !a.IsValid() || !b.IsValid() {
+			return a.IsValid() == b.IsValid()
+		}
+		if a.Type() != b.Type() {
+			return false
+		}
+		if a.Kind() != reflect.Ptr && a.Kind() != reflect.Interface {
+			if m, ok := a.Type().MethodByName("Equal"); ok && m.Type.NumIn() == 2 && m.Type.In(1) == a.Type() &&
+				m.Type.NumOut() == 1 && m.Type.Out(0).Kind() == reflect.Bool {
+				return a.Method(m.Index).Call([]reflect.Value{b})[0].Bool()
+			}
+		}
+
+		switch a.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if a.IsNil() || b.IsNil() {
+				return a.IsNil() == b.IsNil()
+			}
+			return equalValue(a.Elem(), b.Elem())
+		case reflect.Slice, reflect.Array:
+			if a.Len() != b.Len() {
+				return false
+			}
+			for i := 0; i < a.Len(); i++ {
+				if !equalValue(a.Index(i), b.Index(i)) {
+					return false
+				}
+			}
+			return true
+		case reflect.Map:
+			if a.Len() != b.Len() {
+				return false
+			}
+			for _, k := range a.MapKeys() {
+				if !equalValue(a.MapIndex(k), b.MapIndex(k)) {
+					return false
+				}
+			}
+			return true
+		case reflect.Struct:
+			for i := 0; i < a.NumField(); i++ {
+				if a.Type().Field(i).PkgPath != "" {
+					return reflect.DeepEqual(a.Interface(), b.Interface())
+				}
+			}
+			for i := 0; i < a.NumField(); i++ {
+				if a.Type().Field(i).Type == xmlNameType {
+					continue
+				}
+				if !equalValue(a.Field(i), b.Field(i)) {
+					return false
+				}
+			}
+			return true
+		}
+		return a.Interface() == b.Interface()
+	}
+{{end}}
+
+{{if generateStringer}}
+	const maxDumpDepth = 32
+
+	// dumpPackage tells the types of the generated package from those of
+	// others, which are dumped with their String or MarshalText methods.
+	type dumpPackage struct{}
+
+	var dumpPackagePath = reflect.TypeOf(dumpPackage{}).PkgPath()
+
+	// Dump returns a compact, nil-safe textual representation of v meant for
+	// logging and debugging. Zero-valued fields and XMLName are left out.
+	// Values of other packages implementing fmt.Stringer or
+	// encoding.TextMarshaler are written with those.
+	func Dump(v {{emptyInterface}}) string {
+		buf := new(bytes.Buffer)
+		dumpValue(buf, reflect.ValueOf(v), 0)
+		return buf.String()
+	}
+
+	func dumpValue(buf *bytes.Buffer, v reflect.Value, depth int) {
+		if !v.IsValid() {
+			buf.WriteString("nil")
+			return
+		}
+		if depth > maxDumpDepth {
+			buf.WriteString("...")
+			return
+		}
+		if v.CanInterface() {
+			if t, ok := v.Interface().(time.Time); ok {
+				buf.WriteString(t.Format(time.RFC3339Nano))
+				return
+			}
+		}
+		if dumpForeign(buf, v) {
+			return
+		}
+
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if v.IsNil() {
+				buf.WriteString("nil")
+				return
+			}
+			dumpValue(buf, v.Elem(), depth+1)
+		case reflect.Slice, reflect.Array:
+			if v.Type().Elem().Kind() == reflect.Uint8 {
+				fmt.Fprintf(buf, "[%d bytes]", v.Len())
+				return
+			}
+			buf.WriteByte('[')
+			for i := 0; i < v.Len(); i++ {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				dumpValue(buf, v.Index(i), depth+1)
+			}
+			buf.WriteByte(']')
+		case reflect.Map:
+			buf.WriteString("map[")
+			for i, k := range v.MapKeys() {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				dumpValue(buf, k, depth+1)
+				buf.WriteString(": ")
+				dumpValue(buf, v.MapIndex(k), depth+1)
+			}
+			buf.WriteByte(']')
+		case reflect.Struct:
+			buf.WriteString(v.Type().Name())
+			buf.WriteByte('{')
+			first := true
+			for i := 0; i < v.NumField(); i++ {
+				f := v.Type().Field(i)
+				if f.Name == "XMLName" || v.Field(i).IsZero() {
+					continue
+				}
+				if !first {
+					buf.WriteString(", ")
+				}
+				first = false
+				buf.WriteString(f.Name)
+				buf.WriteString(": ")
+				dumpValue(buf, v.Field(i), depth+1)
+			}
+			buf.WriteByte('}')
+		case reflect.String:
+			fmt.Fprintf(buf, "%q", v.String())
+		default:
+			// fmt prints the value held, even by unexported fields.
+			fmt.Fprint(buf, v)
+		}
+	}
+
+	// dumpForeign writes v with its String or MarshalText method when it is
+	// of a type of another package, whose state is usually unexported, and
+	// reports whether it did.
+	func dumpForeign(buf *bytes.Buffer, v reflect.Value) bool {
+		t := v.Type()
+		if t.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return false
+			}
+			t = t.Elem()
+		}
+		if t.PkgPath() == "" || t.PkgPath() == dumpPackagePath || !v.CanInterface() {
+			return false
+		}
+		switch x := v.Interface().(type) {
+		case fmt.Stringer:
+			buf.WriteString(x.String())
+		case encoding.TextMarshaler:
+			text, err := x.MarshalText()
+			if err != nil {
+				return false
+			}
+			buf.Write(text)
+		default:
+			return false
+		}
+		return true
+	}
+{{end}}
+
+{{if generateNullable}}
+	const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+	// Nullable holds the value of a nillable element. An invalid Nullable is
+	// marshaled as an empty element carrying xsi:nil="true". Optional
+	// nillable elements are *Nullable fields, which are omitted when nil.
+	type Nullable[T any] struct {
+		Value T
+		Valid bool
+	}
+
+	// NewNullable returns a valid Nullable holding v.
+	func NewNullable[T any](v T) Nullable[T] {
+		return Nullable[T]{Value: v, Valid: true}
+	}
+
+	// Ptr returns a pointer to the value, or nil when n is not valid.
+	func (n Nullable[T]) Ptr() *T {
+		if !n.Valid {
+			return nil
+		}
+		v := n.Value
+		return &v
+	}
+
+	func (n Nullable[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		if n.Valid {
+			return e.EncodeElement(n.Value, start)
+		}
+		start.Attr = append(start.Attr, xml.Attr{
+			Name:  xml.Name{Space: xsiNamespace, Local: "nil"},
+			Value: "true",
+		})
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		return e.EncodeToken(start.End())
+	}
+
+	func (n *Nullable[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		for _, attr := range start.Attr {
+			if attr.Name.Space == xsiNamespace && attr.Name.Local == "nil" && (attr.Value == "true" || attr.Value == "1") {
+				var zero T
+				n.Value, n.Valid = zero, false
+				return d.Skip()
+			}
+		}
+		if err := d.DecodeElement(&n.Value, &start); err != nil {
+			return err
+		}
+		n.Valid = true
+		return nil
+	}
+{{end}}
+
+{{if streamBase64}}
+	// Base64Stream is the content of a base64Binary element. It is encoded
+	// from Reader while the element is marshaled, and an unmarshaled one
+	// decodes the text of the element as it is read.
+	type Base64Stream struct {
+		Reader io.Reader
+	}
+
+	// NewBase64Stream returns a Base64Stream sending the content of r.
+	func NewBase64Stream(r io.Reader) *Base64Stream {
+		return &Base64Stream{Reader: r}
+	}
+
+	// Read reads the decoded content of b.
+	func (b *Base64Stream) Read(p []byte) (int, error) {
+		if b.Reader == nil {
+			return 0, io.EOF
+		}
+		return b.Reader.Read(p)
+	}
+
+	func (b *Base64Stream) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		if b.Reader != nil {
+			// Chunks of a multiple of 3 bytes encode without padding.
+			buf := make([]byte, 3*1024)
+			text := make([]byte, base64.StdEncoding.EncodedLen(len(buf)))
+			for {
+				n, err := io.ReadFull(b.Reader, buf)
+				if n > 0 {
+					base64.StdEncoding.Encode(text, buf[:n])
+					if err := e.EncodeToken(xml.CharData(text[:base64.StdEncoding.EncodedLen(n)])); err != nil {
+						return err
+					}
+				}
+				if err == io.EOF || err == io.ErrUnexpectedEOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return e.EncodeToken(start.End())
+	}
+
+	func (b *Base64Stream) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		var text strings.Builder
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return err
+			}
+			switch t := tok.(type) {
+			case xml.CharData:
+				text.Write(t)
+			case xml.StartElement:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case xml.EndElement:
+				b.Reader = base64.NewDecoder(base64.StdEncoding, base64Text{strings.NewReader(text.String())})
+				return nil
+			}
+		}
+	}
+
+	// base64Text drops the spaces and tabs of base64 text, which base64
+	// decoders do not ignore unlike line breaks.
+	type base64Text struct {
+		r io.Reader
+	}
+
+	func (t base64Text) Read(p []byte) (int, error) {
+		n, err := t.r.Read(p)
+		kept := 0
+		for _, c := range p[:n] {
+			if c != ' ' && c != '\t' {
+				p[kept] = c
+				kept++
+			}
+		}
+		return kept, err
+	}
+{{end}}
 `
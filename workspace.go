@@ -0,0 +1,276 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultWorkspaceFile is the name of the workspace files.
+const DefaultWorkspaceFile = "gowsdl.yaml"
+
+// A Workspace describes services generated in one run, such as:
+//
+//	defaults:
+//	  cache: true
+//	  typeMappings: [decimal=github.com/shopspring/decimal.Decimal]
+//	services:
+//	  - name: orders
+//	    source: wsdl/orders.wsdl
+//	    package: orders
+//	    output: gen/orders
+//	  - name: billing
+//	    source: https://example.com/billing?wsdl
+//	    package: billing
+//	    output: gen/billing
+//	    options:
+//	      schemaDenyList: [www.w3.org]
+//
+// The defaults and the options of the services are Generator fields, named
+// regardless of case, and the options override the defaults. The schemas
+// the services share are downloaded once per run.
+type Workspace struct {
+	// Dir is the directory the relative paths of the workspace are resolved
+	// against, that of its file.
+	Dir      string
+	Services []WorkspaceService
+}
+
+// WorkspaceService is a service of a workspace.
+type WorkspaceService struct {
+	// Name identifies the service in logs and errors, and defaults to the
+	// package.
+	Name string
+	// Source is the file path or URL of the WSDL.
+	Source string
+	// Package is the package of the generated code.
+	Package string
+	// Output is the directory of the generated code, which is written to a
+	// file named after the package. It defaults to a directory named after
+	// the package.
+	Output string
+	// Generator holds the defaults and options of the service, whose
+	// WsdlPath, Pkg and OutFile are set from its source, package and output.
+	Generator Generator
+}
+
+// workspaceFile is the content of a workspace file, whose options are
+// decoded once the defaults they override are.
+type workspaceFile struct {
+	Defaults yamlNode `yaml:"defaults"`
+	Services []struct {
+		Name    string   `yaml:"name"`
+		Source  string   `yaml:"source"`
+		Package string   `yaml:"package"`
+		Output  string   `yaml:"output"`
+		Options yamlNode `yaml:"options"`
+	} `yaml:"services"`
+}
+
+// LoadWorkspace reads the workspace file at path.
+func LoadWorkspace(path string) (*Workspace, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := ParseWorkspace(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+// ParseWorkspace parses the content of a workspace file, whose relative
+// paths are resolved against dir.
+func ParseWorkspace(data []byte, dir string) (*Workspace, error) {
+	node, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	file := new(workspaceFile)
+	if err := decodeYAML(reflect.ValueOf(file), node, ""); err != nil {
+		return nil, err
+	}
+
+	defaults := Generator{MakePublic: true, GraphFormat: string(GraphDOT)}
+	if err := decodeYAML(reflect.ValueOf(&defaults), file.Defaults.node, "defaults"); err != nil {
+		return nil, err
+	}
+	w := &Workspace{Dir: dir}
+	names := make(map[string]bool)
+	for i, s := range file.Services {
+		path := fmt.Sprintf("services[%d]", i)
+		service := WorkspaceService{Name: s.Name, Source: s.Source, Package: s.Package, Output: s.Output, Generator: defaults}
+		if service.Source == "" || service.Package == "" {
+			return nil, fmt.Errorf("%s: the source and package of services are required", path)
+		}
+		if service.Name == "" {
+			service.Name = service.Package
+		}
+		if names[service.Name] {
+			return nil, fmt.Errorf("%s: duplicate service %s", path, service.Name)
+		}
+		names[service.Name] = true
+		if service.Output == "" {
+			service.Output = service.Package
+		}
+		if err := decodeYAML(reflect.ValueOf(&service.Generator), s.Options.node, path+".options"); err != nil {
+			return nil, err
+		}
+		w.resolvePaths(&service)
+		w.Services = append(w.Services, service)
+	}
+	if len(w.Services) == 0 {
+		return nil, errors.New("no services")
+	}
+	return w, nil
+}
+
+// resolvePaths sets the generator of service, resolving its paths against
+// the directory of the workspace.
+func (w *Workspace) resolvePaths(service *WorkspaceService) {
+	r := &service.Generator
+	r.WsdlPath = service.Source
+	if loc, err := ParseLocation(service.Source); err != nil || loc.IsFile() {
+		r.WsdlPath = w.path(service.Source)
+	}
+	r.Pkg = service.Package
+	r.OutFile = filepath.Join(w.path(service.Output), service.Package+".go")
+	for _, file := range []*string{&r.NetrcFile, &r.RenderCache, &r.OpenAPIFile, &r.JSONSchemaFile, &r.ProtoFile, &r.GraphFile, &r.SnapshotFile, &r.CompatFile} {
+		if *file != "" {
+			*file = w.path(*file)
+		}
+	}
+}
+
+// path resolves the relative path p against the directory of w.
+func (w *Workspace) path(p string) string {
+	if filepath.IsAbs(p) || isWindowsPath(p) {
+		return p
+	}
+	return filepath.Join(w.Dir, filepath.FromSlash(p))
+}
+
+// Generate generates the services of w in order, sharing the schemas they
+// download. The services following a failed one are still generated, and
+// the failures are reported together.
+func (w *Workspace) Generate() error {
+	docs := newDocumentCache()
+	var failed []string
+	for i := range w.Services {
+		service := &w.Services[i]
+		log.Printf("[INFO] Generating service %s (%d/%d)", service.Name, i+1, len(w.Services))
+		r := service.Generator
+		r.docs = docs
+		if err := r.Generate(); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", service.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d services failed: %s", len(failed), len(w.Services), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// documentCache keeps the documents downloaded by the generators of a
+// workspace, so that the schemas their services share are downloaded once.
+// Documents are keyed on their URL and the options they are downloaded
+// with, see documentKey.
+type documentCache struct {
+	mu   sync.Mutex
+	docs map[string]cachedDocument
+}
+
+// cachedDocument is a downloaded document with the URL it was read from,
+// which differs from the URL downloaded after redirects.
+type cachedDocument struct {
+	data     []byte
+	resolved *url.URL
+}
+
+func newDocumentCache() *documentCache {
+	return &documentCache{docs: make(map[string]cachedDocument)}
+}
+
+// documentKey returns the key of the document downloaded from rawURL with
+// opts, so that a generator does not read the copy of another one which
+// downloaded it with other credentials, proxy, headers or safety checks.
+// Negotiate providers are told apart by their type.
+func documentKey(rawURL string, opts downloadOptions) string {
+	h := sha256.New()
+	fmt.Fprintln(h, rawURL, opts.ignoreTLS, opts.safe, opts.redirectAuth, opts.proxy)
+	fmt.Fprintf(h, "%T\n", opts.negotiate)
+	if opts.auth != nil {
+		fmt.Fprintln(h, opts.auth.Login, opts.auth.Password)
+	}
+	fmt.Fprintln(h, opts.hostAuth.env)
+	if a := opts.hostAuth.fallback; a != nil {
+		fmt.Fprintln(h, a.Login, a.Password)
+	}
+	machines := make([]string, 0, len(opts.hostAuth.machines))
+	for machine := range opts.hostAuth.machines {
+		machines = append(machines, machine)
+	}
+	sort.Strings(machines)
+	for _, machine := range machines {
+		a := opts.hostAuth.machines[machine]
+		fmt.Fprintln(h, machine, a.Login, a.Password)
+	}
+	opts.headers.Write(h)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the document stored under key, if any. c may be nil.
+func (c *documentCache) get(key string) (cachedDocument, bool) {
+	if c == nil {
+		return cachedDocument{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, ok := c.docs[key]
+	return doc, ok
+}
+
+// tee returns r, reading a downloaded document, which is stored under key
+// once it is read to its end. c may be nil.
+func (c *documentCache) tee(key string, r io.ReadCloser, resolved *url.URL) io.ReadCloser {
+	if c == nil {
+		return r
+	}
+	return &documentTee{ReadCloser: r, c: c, key: key, resolved: resolved}
+}
+
+// documentTee keeps the document it reads in its cache.
+type documentTee struct {
+	io.ReadCloser
+	c        *documentCache
+	key      string
+	resolved *url.URL
+	buf      bytes.Buffer
+}
+
+func (t *documentTee) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.buf.Write(p[:n])
+	if err == io.EOF {
+		t.c.mu.Lock()
+		t.c.docs[t.key] = cachedDocument{data: t.buf.Bytes(), resolved: t.resolved}
+		t.c.mu.Unlock()
+	}
+	return n, err
+}
@@ -0,0 +1,127 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseWorkspace(t *testing.T) {
+	doc := `
+defaults:
+  makePublic: false
+  omitEmpty: optional
+  typeMappings: [decimal=github.com/shopspring/decimal.Decimal]
+services:
+  - name: orders
+    source: wsdl/orders.wsdl
+    package: orders
+    output: gen/orders
+  - source: https://example.com/billing?wsdl
+    package: billing
+    options:
+      makePublic: true
+      schemaDenyList:
+        - www.w3.org
+      openAPIFile: docs/billing.json
+`
+	w, err := ParseWorkspace([]byte(doc), "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Services) != 2 {
+		t.Fatalf("got %d services, want 2", len(w.Services))
+	}
+	orders, billing := w.Services[0].Generator, w.Services[1].Generator
+	if orders.WsdlPath != filepath.FromSlash("/work/wsdl/orders.wsdl") || orders.Pkg != "orders" ||
+		orders.OutFile != filepath.FromSlash("/work/gen/orders/orders.go") || orders.MakePublic || orders.OmitEmpty != "optional" {
+		t.Errorf("got orders generator %+v", orders)
+	}
+	if w.Services[1].Name != "billing" || billing.WsdlPath != "https://example.com/billing?wsdl" ||
+		billing.OutFile != filepath.FromSlash("/work/billing/billing.go") || !billing.MakePublic ||
+		billing.OpenAPIFile != filepath.FromSlash("/work/docs/billing.json") {
+		t.Errorf("got billing service %+v", w.Services[1])
+	}
+	if expected := []string{"www.w3.org"}; !reflect.DeepEqual(billing.SchemaDenyList, expected) || orders.SchemaDenyList != nil {
+		t.Errorf("got schema deny lists %v and %v, want none and %v", orders.SchemaDenyList, billing.SchemaDenyList, expected)
+	}
+	if expected := []string{"decimal=github.com/shopspring/decimal.Decimal"}; !reflect.DeepEqual(billing.TypeMappings, expected) {
+		t.Errorf("got type mappings %v, want the defaults %v", billing.TypeMappings, expected)
+	}
+
+	for doc, expected := range map[string]string{
+		"services: []\n":              "no services",
+		"services:\n  - package: a\n": "services[0]: the source and package of services are required",
+		"services:\n  - {source: a.wsdl, package: a}\n  - {source: b.wsdl, package: a}\n":  "services[1]: duplicate service a",
+		"services:\n  - source: a.wsdl\n    package: a\n    options: {makePublik: true}\n": `services[0].options: unknown key "makePublik"`,
+		"defaults:\n  progress: yes\nservices:\n  - {source: a.wsdl, package: a}\n":        "defaults.progress: cannot be set in YAML",
+	} {
+		if _, err := ParseWorkspace([]byte(doc), "/work"); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: got error %v, want %q", doc, err, expected)
+		}
+	}
+}
+
+func TestWorkspaceGenerate(t *testing.T) {
+	quietLog(t)
+	var schemaDownloads int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wsdl/orders.wsdl", "/wsdl/billing.wsdl":
+			w.Write([]byte(fetchedWSDL))
+		case "/types/orders.xsd":
+			atomic.AddInt32(&schemaDownloads, 1)
+			w.Write([]byte(fetchedSchema))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	// The audited service downloads the shared schema safely, which must not
+	// read the copy downloaded without the checks.
+	audited := strings.Replace(fetchedWSDL, "../types/orders.xsd", srv.URL+"/types/orders.xsd", 1)
+	if err := ioutil.WriteFile(filepath.Join(dir, "audited.wsdl"), []byte(audited), 0600); err != nil {
+		t.Fatal(err)
+	}
+	doc := "services:\n" +
+		"  - {source: '" + srv.URL + "/wsdl/orders.wsdl', package: orders}\n" +
+		"  - {source: '" + srv.URL + "/wsdl/missing.wsdl', package: missing}\n" +
+		"  - {source: '" + srv.URL + "/wsdl/billing.wsdl', package: billing}\n" +
+		"  - {source: audited.wsdl, package: audited, options: {safeDownloads: true}}\n"
+	file := filepath.Join(dir, DefaultWorkspaceFile)
+	if err := ioutil.WriteFile(file, []byte(doc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := LoadWorkspace(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Generate()
+	if err == nil || !strings.Contains(err.Error(), "2 of 4 services failed: missing: ") ||
+		!strings.Contains(err.Error(), "; audited: ") || !strings.Contains(err.Error(), "non-public address") {
+		t.Errorf("got error %v, want the missing and audited services to fail", err)
+	}
+	for _, pkg := range []string{"orders", "billing"} {
+		code, err := ioutil.ReadFile(filepath.Join(dir, pkg, pkg+".go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(code), "package "+pkg) || !strings.Contains(string(code), "type Order struct") {
+			t.Errorf("got %s code:\n%s", pkg, code)
+		}
+	}
+	if n := atomic.LoadInt32(&schemaDownloads); n != 1 {
+		t.Errorf("the shared schema was downloaded %d times, want once", n)
+	}
+}
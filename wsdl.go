@@ -20,6 +20,7 @@ type WSDL struct {
 	PortTypes       []*WSDLPortType `xml:"http://schemas.xmlsoap.org/wsdl/ portType"`
 	Binding         []*WSDLBinding  `xml:"http://schemas.xmlsoap.org/wsdl/ binding"`
 	Service         []*WSDLService  `xml:"http://schemas.xmlsoap.org/wsdl/ service"`
+	Policies        []*WSPolicy     `xml:"Policy"`
 }
 
 // UnmarshalXML implements interface xml.Unmarshaler for XSDSchema.
@@ -59,19 +60,33 @@ Loop:
 				if err := d.DecodeElement(&w.Doc, &t); err != nil {
 					return err
 				}
+			case t.Name.Local == "Policy":
+				x := new(WSPolicy)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				w.Policies = append(w.Policies, x)
 			case t.Name.Space == wsdlNamespace:
 				switch t.Name.Local {
 				case "types":
-					if err := d.DecodeElement(&w.Types, &t); err != nil {
+					// The schemas of several types sections are merged.
+					x := new(WSDLType)
+					if err := d.DecodeElement(x, &t); err != nil {
 						return err
 					}
 					for prefix, namespace := range w.Xmlns {
-						for _, s := range w.Types.Schemas {
+						for _, s := range x.Schemas {
 							if _, ok := s.Xmlns[prefix]; !ok {
 								s.Xmlns[prefix] = namespace
 							}
 						}
 					}
+					if w.Types.Doc == "" {
+						w.Types.Doc = x.Doc
+					} else if x.Doc != "" {
+						w.Types.Doc += "\n" + x.Doc
+					}
+					w.Types.Schemas = append(w.Types.Schemas, x.Schemas...)
 				case "message":
 					x := new(WSDLMessage)
 					if err := d.DecodeElement(x, &t); err != nil {
@@ -153,6 +168,10 @@ type WSDLInput struct {
 	Doc        string            `xml:"documentation"`
 	SOAPBody   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
 	SOAPHeader []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+
+	URLEncoded     *WSDLHTTPURLEncoded     `xml:"http://schemas.xmlsoap.org/wsdl/http/ urlEncoded"`
+	URLReplacement *WSDLHTTPURLReplacement `xml:"http://schemas.xmlsoap.org/wsdl/http/ urlReplacement"`
+	MIMEContent    *WSDLMIMEContent        `xml:"http://schemas.xmlsoap.org/wsdl/mime/ content"`
 }
 
 // WSDLOutput represents a WSDL output message.
@@ -162,6 +181,8 @@ type WSDLOutput struct {
 	Doc        string            `xml:"documentation"`
 	SOAPBody   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
 	SOAPHeader []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+
+	MIMEXML *WSDLMIMEXML `xml:"http://schemas.xmlsoap.org/wsdl/mime/ mimeXml"`
 }
 
 // WSDLOperation represents the contract of an entire operation or function.
@@ -172,6 +193,7 @@ type WSDLOperation struct {
 	Output        WSDLOutput        `xml:"output"`
 	Faults        []*WSDLFault      `xml:"fault"`
 	SOAPOperation WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	HTTPOperation WSDLHTTPOperation `xml:"http://schemas.xmlsoap.org/wsdl/http/ operation"`
 }
 
 // WSDLPortType defines the service, operations that can be performed and the messages involved.
@@ -188,6 +210,35 @@ type WSDLSOAPBinding struct {
 	Transport string `xml:"transport,attr"`
 }
 
+// WSDLHTTPBinding represents an HTTP GET or POST binding.
+type WSDLHTTPBinding struct {
+	Verb string `xml:"verb,attr"`
+}
+
+// WSDLHTTPOperation holds the location of an HTTP bound operation, relative
+// to the address of its port.
+type WSDLHTTPOperation struct {
+	Location string `xml:"location,attr"`
+}
+
+// WSDLHTTPURLEncoded marks HTTP input parts as sent in the query string.
+type WSDLHTTPURLEncoded struct{}
+
+// WSDLHTTPURLReplacement marks HTTP input parts as replacing the (part)
+// placeholders of the operation location.
+type WSDLHTTPURLReplacement struct{}
+
+// WSDLMIMEContent describes a message sent as a MIME type.
+type WSDLMIMEContent struct {
+	Part string `xml:"part,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// WSDLMIMEXML describes a message sent as a bare XML document.
+type WSDLMIMEXML struct {
+	Part string `xml:"part,attr"`
+}
+
 // WSDLSOAPOperation represents a service operation in SOAP terms.
 type WSDLSOAPOperation struct {
 	SOAPAction string `xml:"soapAction,attr"`
@@ -234,13 +285,23 @@ type WSDLSOAPAddress struct {
 	Location string `xml:"location,attr"`
 }
 
+// WSDLHTTPAddress defines the base location of an HTTP bound port.
+type WSDLHTTPAddress struct {
+	Location string `xml:"location,attr"`
+}
+
 // WSDLBinding defines only a SOAP binding and its operations
 type WSDLBinding struct {
 	Name        string           `xml:"name,attr"`
 	Type        string           `xml:"type,attr"`
 	Doc         string           `xml:"documentation"`
 	SOAPBinding WSDLSOAPBinding  `xml:"http://schemas.xmlsoap.org/wsdl/soap/ binding"`
+	HTTPBinding WSDLHTTPBinding  `xml:"http://schemas.xmlsoap.org/wsdl/http/ binding"`
 	Operations  []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+
+	Policies         []*WSPolicy          `xml:"Policy"`
+	PolicyReferences []*WSPolicyReference `xml:"PolicyReference"`
+	UsingAddressing  *WSDLUsingAddressing `xml:"UsingAddressing"`
 }
 
 // WSDLPort defines the properties for a SOAP port only.
@@ -249,6 +310,7 @@ type WSDLPort struct {
 	Binding     string          `xml:"binding,attr"`
 	Doc         string          `xml:"documentation"`
 	SOAPAddress WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap/ address"`
+	HTTPAddress WSDLHTTPAddress `xml:"http://schemas.xmlsoap.org/wsdl/http/ address"`
 }
 
 // WSDLService defines the list of SOAP services associated with the WSDL.
@@ -0,0 +1,421 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a change between two versions of a WSDL.
+type ChangeKind string
+
+// Kinds of changes.
+const (
+	OperationAdded   ChangeKind = "operation added"
+	OperationRemoved ChangeKind = "operation removed"
+	OperationChanged ChangeKind = "operation changed"
+	TypeAdded        ChangeKind = "type added"
+	TypeRemoved      ChangeKind = "type removed"
+	TypeChanged      ChangeKind = "type changed"
+	FieldAdded       ChangeKind = "field added"
+	FieldRemoved     ChangeKind = "field removed"
+	FieldChanged     ChangeKind = "field changed"
+	EnumValueAdded   ChangeKind = "enum value added"
+	EnumValueRemoved ChangeKind = "enum value removed"
+)
+
+// A Change is a change of the model of a WSDL.
+type Change struct {
+	Kind ChangeKind
+	// Path names what changed: an operation as PortType.Operation, followed
+	// by .input, .output, .fault.Name or .action when only those changed; a
+	// type as {namespace}name, or element {namespace}name for global
+	// elements; a field as the path of its type followed by the names of its
+	// elements, or @name for attributes; an enumeration as the path of its
+	// type or field.
+	Path string
+	// Old and New describe what changed before and after, such as the types
+	// of a field, and are empty when it was added or removed.
+	Old, New string
+	// Breaking tells whether clients generated from the WSDL before may fail
+	// against the WSDL after, such as when operations, types, fields or enum
+	// values are removed, required fields are added or types change.
+	// Optional fields and enum values added are not breaking.
+	Breaking bool
+}
+
+func (c Change) String() string {
+	s := string(c.Kind) + " " + c.Path
+	switch {
+	case c.Old != "" && c.New != "":
+		s += ": " + c.Old + " -> " + c.New
+	case c.Old != "":
+		s += ": " + c.Old
+	case c.New != "":
+		s += ": " + c.New
+	}
+	if c.Breaking {
+		s += " (breaking)"
+	}
+	return s
+}
+
+// A WSDLDiff lists the changes between two versions of a WSDL, sorted by
+// path.
+type WSDLDiff struct {
+	Changes []Change
+}
+
+// Breaking reports whether any of the changes is breaking.
+func (d *WSDLDiff) Breaking() bool {
+	for _, c := range d.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// String lists the changes one per line, such as for a changelog.
+func (d *WSDLDiff) String() string {
+	var b strings.Builder
+	for _, c := range d.Changes {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// DiffWSDL loads the WSDLs of before and after and returns the changes of
+// their operations and types, down to the fields of the types and the values
+// of enumerations. Unlike the snapshots compared by Drift, which tell what
+// changed, the diff tells how.
+func DiffWSDL(before, after *GoWSDL) (*WSDLDiff, error) {
+	if err := before.load(); err != nil {
+		return nil, err
+	}
+	if err := after.load(); err != nil {
+		return nil, err
+	}
+	d := new(WSDLDiff)
+	d.diffOperations(before.operationModels(), after.operationModels())
+	d.diffTypes(before.typeModels(), after.typeModels())
+	sort.SliceStable(d.Changes, func(i, j int) bool {
+		return d.Changes[i].Path < d.Changes[j].Path
+	})
+	return d, nil
+}
+
+// operationModel describes an operation as its messages and SOAP actions.
+type operationModel map[string]string
+
+// typeModel describes a type or global element as its kind and base, its
+// fields and its enumeration.
+type typeModel struct {
+	kind   string
+	base   string
+	fields map[string]fieldModel
+	enum   []string
+}
+
+// fieldModel describes an element or attribute of a type.
+type fieldModel struct {
+	typ       string
+	attribute bool
+	minOccurs int
+	// maxOccurs is -1 when unbounded.
+	maxOccurs int
+	nillable  bool
+	enum      []string
+}
+
+func (m *typeModel) String() string {
+	if m.base == "" {
+		return m.kind
+	}
+	return m.kind + " " + m.base
+}
+
+func (f fieldModel) String() string {
+	if f.attribute {
+		if f.minOccurs > 0 {
+			return f.typ + " required"
+		}
+		return f.typ
+	}
+	max := strconv.Itoa(f.maxOccurs)
+	if f.maxOccurs < 0 {
+		max = "unbounded"
+	}
+	s := fmt.Sprintf("%s [%d..%s]", f.typ, f.minOccurs, max)
+	if f.nillable {
+		s += " nillable"
+	}
+	return s
+}
+
+// relaxes reports whether f accepts whatever before did, its type unchanged.
+func (f fieldModel) relaxes(before fieldModel) bool {
+	maxRelaxed := f.maxOccurs < 0 || before.maxOccurs >= 0 && f.maxOccurs >= before.maxOccurs
+	return f.typ == before.typ && f.minOccurs <= before.minOccurs && maxRelaxed && (f.nillable || !before.nillable)
+}
+
+// operationModels returns the models of the operations of g, named
+// PortType.Operation.
+func (g *GoWSDL) operationModels() map[string]operationModel {
+	models := make(map[string]operationModel)
+	for _, pt := range g.wsdl.PortTypes {
+		for _, op := range pt.Operations {
+			m := operationModel{"input": g.messageParts(op.Input.Message), "output": g.messageParts(op.Output.Message)}
+			for _, fault := range op.Faults {
+				m["fault."+fault.Name] = g.messageParts(fault.Message)
+			}
+			var actions []string
+			for _, binding := range g.wsdl.Binding {
+				if localName(binding.Type) != pt.Name {
+					continue
+				}
+				for _, bop := range binding.Operations {
+					if bop.Name == op.Name && bop.SOAPOperation.SOAPAction != "" {
+						actions = append(actions, bop.SOAPOperation.SOAPAction)
+					}
+				}
+			}
+			m["action"] = strings.Join(actions, ",")
+			models[pt.Name+"."+op.Name] = m
+		}
+	}
+	return models
+}
+
+// typeModels returns the models of the named types and global elements of
+// the schemas of g, named as in snapshots.
+func (g *GoWSDL) typeModels() map[string]*typeModel {
+	models := make(map[string]*typeModel)
+	for _, schema := range g.wsdl.Types.Schemas {
+		ns := "{" + schema.TargetNamespace + "}"
+		for _, ct := range schema.ComplexTypes {
+			models[ns+ct.Name] = complexTypeModel("complexType", ct)
+		}
+		for _, st := range schema.SimpleType {
+			models[ns+st.Name] = &typeModel{kind: "simpleType", base: simpleTypeBase(st), enum: enumValues(st)}
+		}
+		for _, el := range schema.Elements {
+			m := &typeModel{kind: "element", base: el.Type}
+			switch {
+			case el.ComplexType != nil:
+				m = complexTypeModel("element", el.ComplexType)
+			case el.SimpleType != nil:
+				m.base, m.enum = simpleTypeBase(el.SimpleType), enumValues(el.SimpleType)
+			}
+			models["element "+ns+el.Name] = m
+		}
+	}
+	return models
+}
+
+// complexTypeModel returns the model of ct, whose elements with local
+// complex types have their fields named after theirs.
+func complexTypeModel(kind string, ct *XSDComplexType) *typeModel {
+	m := &typeModel{kind: kind, fields: make(map[string]fieldModel)}
+	switch {
+	case ct.ComplexContent.Extension.Base != "":
+		m.base = ct.ComplexContent.Extension.Base
+	case ct.ComplexContent.Restriction.Base != "":
+		m.base = ct.ComplexContent.Restriction.Base
+	case ct.SimpleContent.Extension.Base != "":
+		m.base = ct.SimpleContent.Extension.Base
+	}
+	addComplexFields(m.fields, "", ct)
+	return m
+}
+
+// addComplexFields adds the fields of ct to fields, their names prefixed.
+func addComplexFields(fields map[string]fieldModel, prefix string, ct *XSDComplexType) {
+	var elements []*XSDElement
+	elements = append(elements, ct.SequenceElements()...)
+	elements = append(elements, ct.Choice...)
+	elements = append(elements, ct.All...)
+	elements = append(elements, ct.ComplexContent.Restriction.Sequence...)
+	for i := range ct.ComplexContent.Extension.Sequence {
+		elements = append(elements, &ct.ComplexContent.Extension.Sequence[i])
+	}
+	for _, el := range elements {
+		name, typ := el.Name, el.Type
+		if el.Ref != "" {
+			name, typ = localName(el.Ref), "ref "+el.Ref
+		}
+		f := fieldModel{typ: typ, minOccurs: occurs(el.MinOccurs, 1), maxOccurs: occurs(el.MaxOccurs, 1), nillable: el.Nillable}
+		switch {
+		case el.ComplexType != nil:
+			f.typ = "complexType"
+			addComplexFields(fields, prefix+name+".", el.ComplexType)
+		case el.SimpleType != nil:
+			f.typ, f.enum = simpleTypeBase(el.SimpleType), enumValues(el.SimpleType)
+		}
+		fields[prefix+name] = f
+	}
+
+	var attributes []*XSDAttribute
+	attributes = append(attributes, ct.Attributes...)
+	attributes = append(attributes, ct.ComplexContent.Extension.Attributes...)
+	attributes = append(attributes, ct.SimpleContent.Extension.Attributes...)
+	attributes = append(attributes, ct.ComplexContent.Restriction.Attributes...)
+	for _, attr := range attributes {
+		name, typ := attr.Name, attr.Type
+		if attr.Ref != "" {
+			name, typ = localName(attr.Ref), "ref "+attr.Ref
+		}
+		f := fieldModel{typ: typ, attribute: true, maxOccurs: 1}
+		if attr.Use == "required" {
+			f.minOccurs = 1
+		}
+		if attr.SimpleType != nil {
+			f.typ, f.enum = simpleTypeBase(attr.SimpleType), enumValues(attr.SimpleType)
+		}
+		fields[prefix+"@"+name] = f
+	}
+}
+
+// simpleTypeBase describes the base of st.
+func simpleTypeBase(st *XSDSimpleType) string {
+	switch {
+	case st.List.ItemType != "":
+		return "list of " + st.List.ItemType
+	case st.Union.MemberTypes != "":
+		return "union of " + st.Union.MemberTypes
+	}
+	return st.Restriction.Base
+}
+
+// enumValues returns the values of the enumeration of st.
+func enumValues(st *XSDSimpleType) []string {
+	var values []string
+	for _, v := range st.Restriction.Enumeration {
+		values = append(values, v.Value)
+	}
+	return values
+}
+
+// occurs parses minOccurs or maxOccurs, returning -1 when unbounded.
+func occurs(value string, def int) int {
+	if value == "unbounded" {
+		return -1
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func (d *WSDLDiff) add(c Change) {
+	d.Changes = append(d.Changes, c)
+}
+
+// diffOperations adds the changes of the operations, whose messages, faults
+// and actions cannot change without breaking clients.
+func (d *WSDLDiff) diffOperations(before, after map[string]operationModel) {
+	added, removed, kept := compareKeys(before, after)
+	for _, name := range added {
+		d.add(Change{Kind: OperationAdded, Path: name})
+	}
+	for _, name := range removed {
+		d.add(Change{Kind: OperationRemoved, Path: name, Breaking: true})
+	}
+	for _, name := range kept {
+		was, now := before[name], after[name]
+		added, removed, changed := compareEntries(was, now)
+		aspects := append(append(added, removed...), changed...)
+		sort.Strings(aspects)
+		for _, aspect := range aspects {
+			d.add(Change{Kind: OperationChanged, Path: name + "." + aspect, Old: was[aspect], New: now[aspect], Breaking: true})
+		}
+	}
+}
+
+// diffTypes adds the changes of the types and of their fields.
+func (d *WSDLDiff) diffTypes(before, after map[string]*typeModel) {
+	added, removed, kept := compareKeys(before, after)
+	for _, name := range added {
+		d.add(Change{Kind: TypeAdded, Path: name, New: after[name].String()})
+	}
+	for _, name := range removed {
+		d.add(Change{Kind: TypeRemoved, Path: name, Old: before[name].String(), Breaking: true})
+	}
+	for _, name := range kept {
+		was, now := before[name], after[name]
+		if was.kind != now.kind || was.base != now.base {
+			d.add(Change{Kind: TypeChanged, Path: name, Old: was.String(), New: now.String(), Breaking: true})
+		}
+		d.diffEnum(name, was.enum, now.enum)
+		d.diffFields(name, was.fields, now.fields)
+	}
+}
+
+// diffFields adds the changes of the fields of the type typeName.
+func (d *WSDLDiff) diffFields(typeName string, before, after map[string]fieldModel) {
+	added, removed, kept := compareKeys(before, after)
+	for _, name := range added {
+		f := after[name]
+		d.add(Change{Kind: FieldAdded, Path: typeName + "." + name, New: f.String(), Breaking: f.minOccurs > 0})
+	}
+	for _, name := range removed {
+		d.add(Change{Kind: FieldRemoved, Path: typeName + "." + name, Old: before[name].String(), Breaking: true})
+	}
+	for _, name := range kept {
+		was, now := before[name], after[name]
+		if was.String() != now.String() {
+			d.add(Change{Kind: FieldChanged, Path: typeName + "." + name, Old: was.String(), New: now.String(), Breaking: !now.relaxes(was)})
+		}
+		d.diffEnum(typeName+"."+name, was.enum, now.enum)
+	}
+}
+
+// diffEnum adds the changes of the enumeration of path. Values added to an
+// enumeration are not breaking, unless they restrict a type that was not
+// one, and values removed are, unless the type is no longer an enumeration.
+func (d *WSDLDiff) diffEnum(path string, before, after []string) {
+	values := func(enum []string) map[string]bool {
+		m := make(map[string]bool, len(enum))
+		for _, v := range enum {
+			m[v] = true
+		}
+		return m
+	}
+	added, removed, _ := compareKeys(values(before), values(after))
+	for _, v := range added {
+		d.add(Change{Kind: EnumValueAdded, Path: path, New: v, Breaking: len(before) == 0})
+	}
+	for _, v := range removed {
+		d.add(Change{Kind: EnumValueRemoved, Path: path, Old: v, Breaking: len(after) > 0})
+	}
+}
+
+// compareKeys returns the sorted keys of the maps before and after, keyed by
+// strings, that were added, removed and kept.
+func compareKeys(before, after interface{}) (added, removed, kept []string) {
+	beforeMap, afterMap := reflect.ValueOf(before), reflect.ValueOf(after)
+	for _, k := range afterMap.MapKeys() {
+		if beforeMap.MapIndex(k).IsValid() {
+			kept = append(kept, k.String())
+		} else {
+			added = append(added, k.String())
+		}
+	}
+	for _, k := range beforeMap.MapKeys() {
+		if !afterMap.MapIndex(k).IsValid() {
+			removed = append(removed, k.String())
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(kept)
+	return added, removed, kept
+}
@@ -0,0 +1,189 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const diffWSDL = `<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"
+             xmlns:soap="http://schemas.xmlsoap.org/wsdl/soap/"
+             xmlns:xs="http://www.w3.org/2001/XMLSchema"
+             xmlns:tns="urn:orders"
+             targetNamespace="urn:orders">
+  <types>
+    <xs:schema targetNamespace="urn:orders">
+      <xs:simpleType name="Status">
+        <xs:restriction base="xs:string">
+          <xs:enumeration value="open"/>
+          <xs:enumeration value="closed"/>
+        </xs:restriction>
+      </xs:simpleType>
+      <xs:complexType name="Order">
+        <xs:sequence>
+          <xs:element name="id" type="xs:long"/>
+          <xs:element name="note" type="xs:string" minOccurs="0"/>
+          <xs:element name="lines">
+            <xs:complexType>
+              <xs:sequence>
+                <xs:element name="sku" type="xs:string"/>
+              </xs:sequence>
+            </xs:complexType>
+          </xs:element>
+        </xs:sequence>
+        <xs:attribute name="status" type="tns:Status"/>
+      </xs:complexType>
+      <xs:element name="GetOrder" type="xs:long"/>
+      <xs:element name="GetOrderResponse" type="tns:Order"/>
+    </xs:schema>
+  </types>
+  <message name="GetOrderRequest">
+    <part name="parameters" element="tns:GetOrder"/>
+  </message>
+  <message name="GetOrderResponse">
+    <part name="parameters" element="tns:GetOrderResponse"/>
+  </message>
+  <portType name="Orders">
+    <operation name="GetOrder">
+      <input message="tns:GetOrderRequest"/>
+      <output message="tns:GetOrderResponse"/>
+    </operation>
+  </portType>
+  <binding name="OrdersBinding" type="tns:Orders">
+    <soap:binding style="document" transport="http://schemas.xmlsoap.org/soap/http"/>
+    <operation name="GetOrder">
+      <soap:operation soapAction="urn:GetOrder"/>
+    </operation>
+  </binding>
+</definitions>
+`
+
+// diffGoWSDL returns the GoWSDL of diffWSDL, changed by replacing the
+// old/new pairs of replacements.
+func diffGoWSDL(t *testing.T, replacements ...string) *GoWSDL {
+	file := filepath.Join(t.TempDir(), "orders.wsdl")
+	wsdl := strings.NewReplacer(replacements...).Replace(diffWSDL)
+	if err := ioutil.WriteFile(file, []byte(wsdl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGoWSDL(file, "orders", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestDiffWSDL(t *testing.T) {
+	tests := []struct {
+		name         string
+		replacements []string
+		want         []Change
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name:         "enum extended",
+			replacements: []string{`<xs:enumeration value="closed"/>`, `<xs:enumeration value="closed"/><xs:enumeration value="held"/>`},
+			want:         []Change{{Kind: EnumValueAdded, Path: "{urn:orders}Status", New: "held"}},
+		},
+		{
+			name:         "enum value removed",
+			replacements: []string{`<xs:enumeration value="closed"/>`, ``},
+			want:         []Change{{Kind: EnumValueRemoved, Path: "{urn:orders}Status", Old: "closed", Breaking: true}},
+		},
+		{
+			name: "fields added",
+			replacements: []string{`<xs:element name="sku" type="xs:string"/>`, `<xs:element name="sku" type="xs:string"/>
+                <xs:element name="quantity" type="xs:int"/>
+                <xs:element name="gift" type="xs:boolean" minOccurs="0"/>`},
+			want: []Change{
+				{Kind: FieldAdded, Path: "{urn:orders}Order.lines.gift", New: "xs:boolean [0..1]"},
+				{Kind: FieldAdded, Path: "{urn:orders}Order.lines.quantity", New: "xs:int [1..1]", Breaking: true},
+			},
+		},
+		{
+			name:         "field removed",
+			replacements: []string{`<xs:attribute name="status" type="tns:Status"/>`, ``},
+			want:         []Change{{Kind: FieldRemoved, Path: "{urn:orders}Order.@status", Old: "tns:Status", Breaking: true}},
+		},
+		{
+			name:         "field relaxed",
+			replacements: []string{`<xs:element name="id" type="xs:long"/>`, `<xs:element name="id" type="xs:long" minOccurs="0" maxOccurs="unbounded"/>`},
+			want:         []Change{{Kind: FieldChanged, Path: "{urn:orders}Order.id", Old: "xs:long [1..1]", New: "xs:long [0..unbounded]"}},
+		},
+		{
+			name:         "field type changed",
+			replacements: []string{`<xs:element name="id" type="xs:long"/>`, `<xs:element name="id" type="xs:string"/>`},
+			want:         []Change{{Kind: FieldChanged, Path: "{urn:orders}Order.id", Old: "xs:long [1..1]", New: "xs:string [1..1]", Breaking: true}},
+		},
+		{
+			name: "operation changed",
+			replacements: []string{
+				`soapAction="urn:GetOrder"`, `soapAction="urn:v2:GetOrder"`,
+				`<xs:element name="GetOrder" type="xs:long"/>`, `<xs:element name="GetOrder" type="xs:string"/>`,
+			},
+			want: []Change{
+				{Kind: OperationChanged, Path: "Orders.GetOrder.action", Old: "urn:GetOrder", New: "urn:v2:GetOrder", Breaking: true},
+				{Kind: TypeChanged, Path: "element {urn:orders}GetOrder", Old: "element xs:long", New: "element xs:string", Breaking: true},
+			},
+		},
+		{
+			name: "operation added and type removed",
+			replacements: []string{
+				`<operation name="GetOrder">
+      <input`, `<operation name="FindOrder">
+      <input message="tns:GetOrderRequest"/>
+      <output message="tns:GetOrderResponse"/>
+    </operation>
+    <operation name="GetOrder">
+      <input`,
+				`<xs:simpleType name="Status">`, `<xs:simpleType name="State">`,
+				`type="tns:Status"`, `type="tns:State"`,
+			},
+			want: []Change{
+				{Kind: OperationAdded, Path: "Orders.FindOrder"},
+				{Kind: FieldChanged, Path: "{urn:orders}Order.@status", Old: "tns:Status", New: "tns:State", Breaking: true},
+				{Kind: TypeAdded, Path: "{urn:orders}State", New: "simpleType xs:string"},
+				{Kind: TypeRemoved, Path: "{urn:orders}Status", Old: "simpleType xs:string", Breaking: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff, err := DiffWSDL(diffGoWSDL(t), diffGoWSDL(t, test.replacements...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(diff.Changes, test.want) {
+				t.Errorf("got changes\n%s\nwant\n%s", diff, &WSDLDiff{Changes: test.want})
+			}
+			breaking := false
+			for _, c := range test.want {
+				breaking = breaking || c.Breaking
+			}
+			if diff.Breaking() != breaking {
+				t.Errorf("got Breaking() %v, want %v", diff.Breaking(), breaking)
+			}
+		})
+	}
+}
+
+func TestWSDLDiff_String(t *testing.T) {
+	diff := &WSDLDiff{Changes: []Change{
+		{Kind: OperationAdded, Path: "Orders.FindOrder"},
+		{Kind: FieldChanged, Path: "{urn:orders}Order.id", Old: "xs:long [1..1]", New: "xs:string [1..1]", Breaking: true},
+	}}
+	want := "operation added Orders.FindOrder\n" +
+		"field changed {urn:orders}Order.id: xs:long [1..1] -> xs:string [1..1] (breaking)\n"
+	if got := diff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -150,6 +150,141 @@ type XSDComplexType struct {
 	ComplexContent XSDComplexContent `xml:"complexContent"`
 	SimpleContent  XSDSimpleContent  `xml:"simpleContent"`
 	Attributes     []*XSDAttribute   `xml:"attribute"`
+
+	// Constructs that are not modeled in generated code.
+	Any             []*XSDAny            `xml:"sequence>any"`
+	ChoiceAny       []*XSDAny            `xml:"choice>any"`
+	Groups          []*XSDGroup          `xml:"sequence>group"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *XSDAnyAttribute     `xml:"anyAttribute"`
+
+	// choicesAt holds, for each element of SequenceChoice, the number of
+	// elements of Sequence that precede it in the schema.
+	choicesAt []int
+}
+
+// UnmarshalXML implements interface xml.Unmarshaler for XSDComplexType,
+// recording the order of the elements of its sequence and of the choices
+// nested in it.
+func (ct *XSDComplexType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type complexType XSDComplexType
+	v := struct {
+		Sequence *xsdSequence `xml:"sequence"`
+		*complexType
+	}{complexType: (*complexType)(ct)}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	if seq := v.Sequence; seq != nil {
+		ct.Sequence = seq.elements
+		ct.SequenceChoice = seq.choices
+		ct.Any = seq.any
+		ct.Groups = seq.groups
+		ct.choicesAt = seq.choicesAt
+	}
+	return nil
+}
+
+// SequenceElements returns the elements of the sequence of ct and of the
+// choices nested in it, in schema order, which is the order of the fields
+// generated for them and so of the elements marshaled from those.
+func (ct *XSDComplexType) SequenceElements() []*XSDElement {
+	if len(ct.SequenceChoice) == 0 {
+		return ct.Sequence
+	}
+	elements := make([]*XSDElement, 0, len(ct.Sequence)+len(ct.SequenceChoice))
+	if len(ct.choicesAt) != len(ct.SequenceChoice) {
+		return append(append(elements, ct.Sequence...), ct.SequenceChoice...)
+	}
+	next := 0
+	for i, el := range ct.SequenceChoice {
+		for ; next < ct.choicesAt[i] && next < len(ct.Sequence); next++ {
+			elements = append(elements, ct.Sequence[next])
+		}
+		elements = append(elements, el)
+	}
+	return append(elements, ct.Sequence[next:]...)
+}
+
+// xsdSequence is the sequence of a complex type, whose elements, wildcards
+// and groups are kept apart from the elements of the choices nested in it.
+type xsdSequence struct {
+	elements  []*XSDElement
+	choices   []*XSDElement
+	choicesAt []int
+	any       []*XSDAny
+	groups    []*XSDGroup
+}
+
+// UnmarshalXML implements interface xml.Unmarshaler for xsdSequence.
+func (s *xsdSequence) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "element":
+				x := new(XSDElement)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				s.elements = append(s.elements, x)
+			case "choice":
+				var x struct {
+					Elements []*XSDElement `xml:"element"`
+				}
+				if err := d.DecodeElement(&x, &t); err != nil {
+					return err
+				}
+				for _, el := range x.Elements {
+					s.choices = append(s.choices, el)
+					s.choicesAt = append(s.choicesAt, len(s.elements))
+				}
+			case "any":
+				x := new(XSDAny)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				s.any = append(s.any, x)
+			case "group":
+				x := new(XSDGroup)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				s.groups = append(s.groups, x)
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// XSDAny represents an element wildcard.
+type XSDAny struct {
+	Namespace       string `xml:"namespace,attr"`
+	ProcessContents string `xml:"processContents,attr"`
+	MinOccurs       string `xml:"minOccurs,attr"`
+	MaxOccurs       string `xml:"maxOccurs,attr"`
+}
+
+// XSDAnyAttribute represents an attribute wildcard.
+type XSDAnyAttribute struct {
+	Namespace       string `xml:"namespace,attr"`
+	ProcessContents string `xml:"processContents,attr"`
+}
+
+// XSDAttributeGroup represents a reference to a named group of attributes.
+type XSDAttributeGroup struct {
+	Name string `xml:"name,attr"`
+	Ref  string `xml:"ref,attr"`
 }
 
 // XSDGroup element is used to define a group of elements to be used in complex type definitions.
@@ -164,8 +299,17 @@ type XSDGroup struct {
 // XSDComplexContent element defines extensions or restrictions on a complex
 // type that contains mixed content or elements only.
 type XSDComplexContent struct {
-	XMLName   xml.Name     `xml:"complexContent"`
-	Extension XSDExtension `xml:"extension"`
+	XMLName     xml.Name              `xml:"complexContent"`
+	Extension   XSDExtension          `xml:"extension"`
+	Restriction XSDComplexRestriction `xml:"restriction"`
+}
+
+// XSDComplexRestriction element restricts a complex type, as SOAP encoded
+// arrays restrict soapenc:Array.
+type XSDComplexRestriction struct {
+	Base       string          `xml:"base,attr"`
+	Attributes []*XSDAttribute `xml:"attribute"`
+	Sequence   []*XSDElement   `xml:"sequence>element"`
 }
 
 // XSDSimpleContent element contains extensions or restrictions on a text-only
@@ -192,8 +336,12 @@ type XSDAttribute struct {
 	Ref        string         `xml:"ref,attr"`
 	Type       string         `xml:"type,attr"`
 	Use        string         `xml:"use,attr"`
+	Default    string         `xml:"default,attr"`
 	Fixed      string         `xml:"fixed,attr"`
 	SimpleType *XSDSimpleType `xml:"simpleType"`
+	// ArrayType is the wsdl:arrayType of a soapenc:arrayType attribute, such
+	// as xsd:string[].
+	ArrayType string `xml:"http://schemas.xmlsoap.org/wsdl/ arrayType,attr"`
 }
 
 // XSDSimpleType element defines a simple type and specifies the constraints
@@ -222,15 +370,19 @@ type XSDUnion struct {
 
 // XSDRestriction defines restrictions on a simpleType, simpleContent, or complexContent definition.
 type XSDRestriction struct {
-	Base         string                `xml:"base,attr"`
-	Enumeration  []XSDRestrictionValue `xml:"enumeration"`
-	Pattern      XSDRestrictionValue   `xml:"pattern"`
-	MinInclusive XSDRestrictionValue   `xml:"minInclusive"`
-	MaxInclusive XSDRestrictionValue   `xml:"maxInclusive"`
-	WhiteSpace   XSDRestrictionValue   `xml:"whitespace"`
-	Length       XSDRestrictionValue   `xml:"length"`
-	MinLength    XSDRestrictionValue   `xml:"minLength"`
-	MaxLength    XSDRestrictionValue   `xml:"maxLength"`
+	Base           string                `xml:"base,attr"`
+	Enumeration    []XSDRestrictionValue `xml:"enumeration"`
+	Pattern        XSDRestrictionValue   `xml:"pattern"`
+	MinInclusive   XSDRestrictionValue   `xml:"minInclusive"`
+	MaxInclusive   XSDRestrictionValue   `xml:"maxInclusive"`
+	MinExclusive   XSDRestrictionValue   `xml:"minExclusive"`
+	MaxExclusive   XSDRestrictionValue   `xml:"maxExclusive"`
+	TotalDigits    XSDRestrictionValue   `xml:"totalDigits"`
+	FractionDigits XSDRestrictionValue   `xml:"fractionDigits"`
+	WhiteSpace     XSDRestrictionValue   `xml:"whiteSpace"`
+	Length         XSDRestrictionValue   `xml:"length"`
+	MinLength      XSDRestrictionValue   `xml:"minLength"`
+	MaxLength      XSDRestrictionValue   `xml:"maxLength"`
 }
 
 // XSDRestrictionValue represents a restriction value.
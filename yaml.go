@@ -0,0 +1,612 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The workspace files are read by a parser of the YAML subset they need,
+// which spares gowsdl a dependency: block mappings and sequences, flow
+// sequences and mappings, plain, single- and double-quoted scalars, literal
+// (|) and folded (>) block scalars, and comments. Anchors, aliases, tags and
+// multiple documents are not supported.
+
+// yamlScalar is a scalar of a YAML document, whose type is told by the
+// value it is decoded into.
+type yamlScalar struct {
+	text string
+	// plain is false for quoted and block scalars, which are always strings.
+	plain bool
+}
+
+// yamlNode keeps a node as it was parsed, to be decoded later.
+type yamlNode struct {
+	node interface{}
+}
+
+var yamlNodeType = reflect.TypeOf(yamlNode{})
+
+// yamlLine is a line of a YAML document.
+type yamlLine struct {
+	num    int
+	indent int
+	// text is the line without its indentation, and without its comment
+	// for the lines that are not part of block scalars.
+	text string
+	raw  string
+}
+
+// yamlParser parses the nodes of a YAML document: map[string]interface{}
+// for mappings, []interface{} for sequences, yamlScalar for scalars and nil
+// for empty values.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseYAML parses data into its nodes.
+func parseYAML(data []byte) (interface{}, error) {
+	p := new(yamlParser)
+	for i, raw := range strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n") {
+		text := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs cannot indent YAML", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: len(raw) - len(text), text: stripYAMLComment(text), raw: raw})
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) && p.lines[p.pos].text == "---" {
+		p.pos++
+		p.skipBlank()
+	}
+	if p.pos == len(p.lines) {
+		return nil, nil
+	}
+	node, err := p.parseBlock(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.skipBlank(); p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected %q", p.lines[p.pos].text)
+	}
+	return node, nil
+}
+
+// stripYAMLComment returns text without its comment, which starts with a #
+// at the beginning of text or after a space, outside quotes.
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.IndexByte(" [{,:-", text[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' '):
+			return strings.TrimRight(text[:i], " ")
+		}
+	}
+	return strings.TrimRight(text, " ")
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	line := len(p.lines)
+	if p.pos < len(p.lines) {
+		line = p.lines[p.pos].num
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipBlank skips the blank and comment lines.
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+}
+
+// parseBlock parses the mapping or sequence whose lines are indented by
+// indent.
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYAMLItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+// isYAMLItem reports whether text starts an item of a block sequence.
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	seq := []interface{}{}
+	for p.skipBlank(); p.pos < len(p.lines); p.skipBlank() {
+		line := &p.lines[p.pos]
+		// Sequences indented as their keys end at the next key.
+		if line.indent < indent || !isYAMLItem(line.text) && line.indent == indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected %q in a sequence", line.text)
+		}
+		rest := strings.TrimLeft(line.text[1:], " ")
+		if rest == "" {
+			p.pos++
+			item, err := p.parseNested(indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, item)
+			continue
+		}
+		if _, _, ok := splitYAMLKey(rest); ok {
+			// The item is a mapping starting on the line of the dash.
+			line.indent += len(line.text) - len(rest)
+			line.text = rest
+			item, err := p.parseMapping(line.indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, item)
+			continue
+		}
+		item, err := p.parseValue(rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, item)
+	}
+	return seq, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for p.skipBlank(); p.pos < len(p.lines); p.skipBlank() {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation of %q", line.text)
+		}
+		key, value, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, p.errorf("expected a key: value pair, got %q", line.text)
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		if value == "" {
+			p.pos++
+			node, err := p.parseNested(indent)
+			if err != nil {
+				return nil, err
+			}
+			// Sequences may be indented as their keys.
+			if node == nil && p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLItem(p.lines[p.pos].text) {
+				if node, err = p.parseSequence(indent); err != nil {
+					return nil, err
+				}
+			}
+			m[key] = node
+			continue
+		}
+		node, err := p.parseValue(value, indent)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = node
+	}
+	return m, nil
+}
+
+// parseNested parses the block indented more than indent following the
+// current line, or returns nil when there is none.
+func (p *yamlParser) parseNested(indent int) (interface{}, error) {
+	p.skipBlank()
+	if p.pos == len(p.lines) || p.lines[p.pos].indent <= indent {
+		return nil, nil
+	}
+	return p.parseBlock(p.lines[p.pos].indent)
+}
+
+// parseValue parses the value ending the current line, whose block is
+// indented by indent, and moves to the next line.
+func (p *yamlParser) parseValue(value string, indent int) (interface{}, error) {
+	if value[0] == '|' || value[0] == '>' {
+		return p.parseBlockScalar(value, indent)
+	}
+	node, rest, err := parseYAMLFlow(value, false)
+	if err == nil && strings.TrimSpace(rest) != "" {
+		err = fmt.Errorf("unexpected %q after the value", rest)
+	}
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	p.pos++
+	return node, nil
+}
+
+// parseBlockScalar parses the literal or folded scalar introduced by header
+// on the current line, whose lines are indented more than indent.
+func (p *yamlParser) parseBlockScalar(header string, indent int) (interface{}, error) {
+	folded, chomp := header[0] == '>', header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, p.errorf("unsupported block scalar header %q", header)
+	}
+	p.pos++
+	var lines []string
+	blockIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if line.indent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent < blockIndent {
+			return nil, p.errorf("block scalar lines must be indented by %d spaces", blockIndent)
+		}
+		lines = append(lines, line.raw[blockIndent:])
+	}
+	// Trailing blank lines are kept by + chomping only.
+	trailing := 0
+	for trailing < len(lines) && lines[len(lines)-1-trailing] == "" {
+		trailing++
+	}
+	// The blank lines following the scalar are not part of it.
+	p.pos -= trailing
+	lines = lines[:len(lines)-trailing]
+
+	var text string
+	if folded {
+		var b strings.Builder
+		for i, line := range lines {
+			// Line breaks fold into spaces, and blank lines into line
+			// breaks.
+			switch {
+			case i == 0 || lines[i-1] == "":
+			case line == "":
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		text = b.String()
+	} else {
+		text = strings.Join(lines, "\n")
+	}
+	switch {
+	case chomp == "+":
+		text += strings.Repeat("\n", trailing+1)
+	case chomp == "" && text != "":
+		text += "\n"
+	}
+	return yamlScalar{text: text}, nil
+}
+
+// splitYAMLKey splits the key: value pair of a mapping line.
+func splitYAMLKey(text string) (key, value string, ok bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		node, rest, err := parseYAMLFlow(text, false)
+		if err != nil || !strings.HasPrefix(rest, ":") || (len(rest) > 1 && rest[1] != ' ') {
+			return "", "", false
+		}
+		return node.(yamlScalar).text, strings.TrimSpace(rest[1:]), true
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		i = len(text) - 1
+	}
+	key = strings.TrimSpace(text[:i])
+	if key == "" || isYAMLItem(text) || strings.ContainsAny(key[:1], "[{") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(text[i+1:]), true
+}
+
+// parseYAMLFlow parses the flow node at the start of text and returns it
+// with the text following it. Plain scalars end at the end of text, or at
+// the indicators ending flow collection items when inFlow is set.
+func parseYAMLFlow(text string, inFlow bool) (interface{}, string, error) {
+	text = strings.TrimLeft(text, " ")
+	if text == "" {
+		return nil, "", nil
+	}
+	switch text[0] {
+	case '[':
+		seq := []interface{}{}
+		rest := strings.TrimLeft(text[1:], " ")
+		for {
+			if strings.HasPrefix(rest, "]") {
+				return seq, rest[1:], nil
+			}
+			item, r, err := parseYAMLFlow(rest, true)
+			if err != nil {
+				return nil, "", err
+			}
+			seq = append(seq, item)
+			if rest = strings.TrimLeft(r, " "); strings.HasPrefix(rest, ",") {
+				rest = strings.TrimLeft(rest[1:], " ")
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, "", fmt.Errorf("unterminated flow sequence %q", text)
+			}
+		}
+	case '{':
+		m := make(map[string]interface{})
+		rest := strings.TrimLeft(text[1:], " ")
+		for {
+			if strings.HasPrefix(rest, "}") {
+				return m, rest[1:], nil
+			}
+			key, r, err := parseYAMLFlow(rest, true)
+			if err != nil {
+				return nil, "", err
+			}
+			scalar, ok := key.(yamlScalar)
+			if r = strings.TrimLeft(r, " "); !ok || !strings.HasPrefix(r, ":") {
+				return nil, "", fmt.Errorf("expected a key: value pair in flow mapping %q", text)
+			}
+			value, r, err := parseYAMLFlow(r[1:], true)
+			if err != nil {
+				return nil, "", err
+			}
+			m[scalar.text] = value
+			if rest = strings.TrimLeft(r, " "); strings.HasPrefix(rest, ",") {
+				rest = strings.TrimLeft(rest[1:], " ")
+			} else if !strings.HasPrefix(rest, "}") {
+				return nil, "", fmt.Errorf("unterminated flow mapping %q", text)
+			}
+		}
+	case '"':
+		for i := 1; i < len(text); i++ {
+			switch text[i] {
+			case '\\':
+				i++
+			case '"':
+				s, err := strconv.Unquote(text[:i+1])
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid double-quoted scalar %s", text[:i+1])
+				}
+				return yamlScalar{text: s}, text[i+1:], nil
+			}
+		}
+		return nil, "", fmt.Errorf("unterminated double-quoted scalar %s", text)
+	case '\'':
+		var b strings.Builder
+		for i := 1; i < len(text); i++ {
+			if text[i] != '\'' {
+				b.WriteByte(text[i])
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			return yamlScalar{text: b.String()}, text[i+1:], nil
+		}
+		return nil, "", fmt.Errorf("unterminated single-quoted scalar %s", text)
+	}
+	end := len(text)
+	if inFlow {
+		if i := strings.IndexAny(text, ",]}"); i >= 0 {
+			end = i
+		}
+		// Keys of flow mappings end at ": ".
+		if i := strings.Index(text[:end], ": "); i >= 0 {
+			end = i
+		} else if strings.HasSuffix(text[:end], ":") {
+			end--
+		}
+	}
+	scalar := strings.TrimSpace(text[:end])
+	if scalar == "" || scalar == "~" || scalar == "null" || scalar == "Null" || scalar == "NULL" {
+		return nil, text[end:], nil
+	}
+	return yamlScalar{text: scalar, plain: true}, text[end:], nil
+}
+
+// decodeYAML stores node in v, matching the keys of mappings with the yaml
+// tags of struct fields, or with their names regardless of case for fields
+// without tags. path locates node in the errors.
+func decodeYAML(v reflect.Value, node interface{}, path string) error {
+	if v.Type() == yamlNodeType {
+		v.Set(reflect.ValueOf(yamlNode{node}))
+		return nil
+	}
+	if node == nil {
+		// Empty documents leave the values they are decoded into as they are.
+		if v.CanSet() {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeYAML(v.Elem(), node, path)
+	case reflect.Struct:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a mapping", path)
+		}
+		for key, value := range m {
+			field, ok := yamlField(v, key)
+			if !ok {
+				return fmt.Errorf("%s: unknown key %q", path, key)
+			}
+			if err := decodeYAML(field, value, joinYAMLPath(path, key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		m, ok := node.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("%s: expected a mapping", path)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for key, value := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeYAML(elem, value, joinYAMLPath(path, key)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Slice:
+		seq, ok := node.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a sequence", path)
+		}
+		s := reflect.MakeSlice(v.Type(), len(seq), len(seq))
+		for i, item := range seq {
+			if err := decodeYAML(s.Index(i), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return fmt.Errorf("%s: cannot be set in YAML", path)
+		}
+		v.Set(reflect.ValueOf(yamlInterface(node)))
+		return nil
+	}
+
+	scalar, ok := node.(yamlScalar)
+	if !ok {
+		return fmt.Errorf("%s: expected a scalar", path)
+	}
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(scalar.text)
+	case reflect.Bool:
+		b, ok := yamlBool(scalar)
+		if !ok {
+			return fmt.Errorf("%s: invalid bool %q", path, scalar.text)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(scalar.text, 0, v.Type().Bits()); err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if n, err = strconv.ParseUint(scalar.text, 0, v.Type().Bits()); err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(scalar.text, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	default:
+		return fmt.Errorf("%s: cannot be set in YAML", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid %s %q", path, v.Kind(), scalar.text)
+	}
+	return nil
+}
+
+// yamlField returns the field of the struct v for key.
+func yamlField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Tag.Get("yaml")
+		if name == "-" {
+			continue
+		}
+		if name == key || name == "" && strings.EqualFold(f.Name, key) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// yamlInterface returns node as the values encoding/json decodes into
+// interface{} values, with plain scalars typed as YAML types them.
+func yamlInterface(node interface{}) interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			m[key] = yamlInterface(value)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(node))
+		for i, item := range node {
+			s[i] = yamlInterface(item)
+		}
+		return s
+	case yamlScalar:
+		if b, ok := yamlBool(node); ok {
+			return b
+		}
+		if node.plain {
+			if f, err := strconv.ParseFloat(node.text, 64); err == nil {
+				return f
+			}
+		}
+		return node.text
+	}
+	return nil
+}
+
+// yamlBool returns the boolean of the plain scalar s, whose case is that of
+// its first letter or of all of them.
+func yamlBool(s yamlScalar) (b, ok bool) {
+	if !s.plain {
+		return false, false
+	}
+	switch s.text {
+	case "true", "True", "TRUE":
+		return true, true
+	case "false", "False", "FALSE":
+		return false, true
+	}
+	return false, false
+}
+
+func joinYAMLPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
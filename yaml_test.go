@@ -0,0 +1,118 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type yamlTestConfig struct {
+	Name     string            `yaml:"name"`
+	Enabled  bool              `yaml:"enabled"`
+	Count    int               `yaml:"count"`
+	Ratio    float64           `yaml:"ratio"`
+	Version  string            `yaml:"version"`
+	Tags     []string          `yaml:"tags"`
+	Labels   map[string]string `yaml:"labels"`
+	Header   string            `yaml:"header"`
+	Summary  string            `yaml:"summary"`
+	Items    []yamlTestItem    `yaml:"items"`
+	Extra    interface{}       `yaml:"extra"`
+	Untagged string
+}
+
+type yamlTestItem struct {
+	ID    int      `yaml:"id"`
+	Names []string `yaml:"names"`
+}
+
+func TestDecodeYAML(t *testing.T) {
+	doc := `---
+# A comment
+name: "orders # not a comment"   # a comment
+enabled: true
+count: 0x10
+ratio: 1.5
+version: 1.18
+tags: [a, 'b, c', "d"]
+labels: {team: core, tier: 'gold'}
+header: |
+  // Code generated.
+
+  // Do not edit.
+summary: >-
+  folded
+  text
+
+  kept
+items:
+- id: 1
+  names:
+    - x
+    - y
+-
+  id: 2
+  names: []
+extra:
+  on: true
+  size: 3
+  list: [1, two]
+untagged: it's plain
+`
+	node, err := parseYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c yamlTestConfig
+	if err := decodeYAML(reflect.ValueOf(&c), node, ""); err != nil {
+		t.Fatal(err)
+	}
+	expected := yamlTestConfig{
+		Name:    "orders # not a comment",
+		Enabled: true,
+		Count:   16,
+		Ratio:   1.5,
+		Version: "1.18",
+		Tags:    []string{"a", "b, c", "d"},
+		Labels:  map[string]string{"team": "core", "tier": "gold"},
+		Header:  "// Code generated.\n\n// Do not edit.\n",
+		Summary: "folded text\nkept",
+		Items: []yamlTestItem{
+			{ID: 1, Names: []string{"x", "y"}},
+			{ID: 2, Names: []string{}},
+		},
+		Extra:    map[string]interface{}{"on": true, "size": 3.0, "list": []interface{}{1.0, "two"}},
+		Untagged: "it's plain",
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("got %#v, want %#v", c, expected)
+	}
+}
+
+func TestDecodeYAMLErrors(t *testing.T) {
+	for doc, expected := range map[string]string{
+		"name: a\n\tcount: 1\n":      "line 2: tabs",
+		"name: a\nname: b\n":         `line 2: duplicate key "name"`,
+		"name: a\n  count: 1\n":      "line 2: unexpected indentation",
+		"tags: [a, b\n":              "unterminated flow sequence",
+		"name: \"a\n":                "unterminated double-quoted",
+		"unknown: 1\n":               `unknown key "unknown"`,
+		"count: ten\n":               `count: invalid int "ten"`,
+		"enabled: \"true\"\n":        `enabled: invalid bool "true"`,
+		"items:\n  - id: 1\n  - 2\n": "items[1]: expected a mapping",
+		"tags: a\n":                  "tags: expected a sequence",
+	} {
+		var c yamlTestConfig
+		node, err := parseYAML([]byte(doc))
+		if err == nil {
+			err = decodeYAML(reflect.ValueOf(&c), node, "")
+		}
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: got error %v, want %q", doc, err, expected)
+		}
+	}
+}